@@ -5,6 +5,7 @@ package bingo
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 )
@@ -77,6 +78,10 @@ type Card struct {
 	// 5. Column 5 is column O and can have numbers 61–75
 	//
 	// The free space is represented as 0.
+	//
+	// Cards for other variants follow the layout described by their Variant
+	// instead. Blank cells (like the ones on a 90-ball ticket) are also
+	// represented as 0.
 	Cells    [][]*Cell `json:"cells"`
 	ID       uuid.UUID `json:"id"`
 	PlayerID uuid.UUID `json:"playerId"`
@@ -131,6 +136,131 @@ const (
 	GamePhaseGameOver GamePhase = "game_over"
 )
 
+// phaseTransitions lists every phase that a game is allowed to move to
+// directly from a given phase. Any phase that isn't a key in the map is
+// treated as terminal.
+var phaseTransitions = map[GamePhase][]GamePhase{
+	GamePhaseInitialized: {
+		GamePhaseRoundStart,
+		GamePhaseInitializationFailure,
+		GamePhaseGameOver,
+	},
+	GamePhaseRoundStart: {
+		GamePhaseCalling,
+		GamePhaseGameOver,
+	},
+	GamePhaseCalling: {
+		GamePhaseConfirmingBingo,
+		GamePhaseRoundEnd,
+		GamePhaseGameOver,
+	},
+	GamePhaseConfirmingBingo: {
+		GamePhaseCalling,
+		GamePhaseTiebreaker,
+		GamePhaseRoundEnd,
+		GamePhaseGameOver,
+	},
+	GamePhaseTiebreaker: {
+		GamePhaseRoundEnd,
+		GamePhaseGameOver,
+	},
+	GamePhaseRoundEnd: {
+		GamePhaseRoundStart,
+		GamePhaseGameOver,
+	},
+}
+
+// CanTransitionTo indicates whether a game is allowed to move directly from
+// the current phase to the next one.
+func (gp GamePhase) CanTransitionTo(next GamePhase) bool {
+	return slices.Contains(phaseTransitions[gp], next)
+}
+
+// CallingMode indicates where a game's balls come from.
+type CallingMode string
+
+const (
+	// CallingModeAutomatic indicates that the game draws every ball itself,
+	// whenever the host requests a new one.
+	CallingModeAutomatic CallingMode = "automatic"
+	// CallingModeManual indicates that the host is drawing balls from a
+	// physical ball machine, and enters each ball into the game as it comes
+	// out. Manually-entered balls can be undone if they were mis-keyed.
+	CallingModeManual CallingMode = "manual"
+)
+
+// Validate makes sure that a calling mode is one of the supported modes.
+func (cm CallingMode) Validate() error {
+	if cm != CallingModeAutomatic && cm != CallingModeManual {
+		return fmt.Errorf("%q is not a valid calling mode", cm)
+	}
+	return nil
+}
+
+// TiebreakerRule indicates how a tiebreaker decides which of the tied players
+// wins the round.
+type TiebreakerRule string
+
+const (
+	// TiebreakerRuleHighBall has the host draw one ball for each tied player,
+	// in the order that they called bingo. Whoever gets the highest ball wins.
+	TiebreakerRuleHighBall TiebreakerRule = "high_ball"
+	// TiebreakerRuleNextLine clears every tied player's daubs, and has them
+	// race to get the round's win pattern again with a fresh draw. The first
+	// player to call a valid bingo wins.
+	TiebreakerRuleNextLine TiebreakerRule = "next_line"
+	// TiebreakerRuleSplitPrize has every tied player win the round, without
+	// drawing any balls.
+	TiebreakerRuleSplitPrize TiebreakerRule = "split_prize"
+)
+
+// Validate makes sure that a tiebreaker rule is one of the supported rules.
+func (tr TiebreakerRule) Validate() error {
+	if tr != TiebreakerRuleHighBall && tr != TiebreakerRuleNextLine && tr != TiebreakerRuleSplitPrize {
+		return fmt.Errorf("%q is not a valid tiebreaker rule", tr)
+	}
+	return nil
+}
+
+// HostPermission is a group of host commands that the host can let co-hosts
+// use. Some commands (e.g., terminating the game or managing co-hosts) can
+// only ever be used by the host.
+type HostPermission string
+
+const (
+	// HostPermissionCallBalls covers everything to do with calling balls:
+	// requesting, syncing, and undoing balls, running the auto-caller, and
+	// ending a round once there are no balls left to call.
+	HostPermissionCallBalls HostPermission = "call_balls"
+	// HostPermissionJudgeBingo covers confirming and rejecting bingo calls,
+	// awarding players, and starting tiebreakers.
+	HostPermissionJudgeBingo HostPermission = "judge_bingo"
+	// HostPermissionModeratePlayers covers banning and suspending players.
+	HostPermissionModeratePlayers HostPermission = "moderate_players"
+	// HostPermissionConfigureGame covers starting the game, and changing its
+	// settings between rounds (the win pattern, card limit, and calling mode).
+	HostPermissionConfigureGame HostPermission = "configure_game"
+)
+
+// Validate makes sure that a host permission is one of the supported
+// permissions.
+func (hp HostPermission) Validate() error {
+	switch hp {
+	case HostPermissionCallBalls, HostPermissionJudgeBingo, HostPermissionModeratePlayers, HostPermissionConfigureGame:
+		return nil
+	default:
+		return fmt.Errorf("%q is not a valid host permission", hp)
+	}
+}
+
+// CoHost is a card player who has been given some of the host's permissions.
+// Co-hosts keep playing with their own cards, but they can never use their
+// permissions on themselves (e.g., confirming their own bingo call).
+type CoHost struct {
+	PlayerID    uuid.UUID        `json:"playerId"`
+	Permissions []HostPermission `json:"permissions"`
+}
+
 // PlayerStatus indicates the status of a player
 type PlayerStatus string
 
@@ -226,10 +356,26 @@ type GameManager interface {
 	// player struct will have the same ID provided as input. If the player is
 	// able to join successfully, they should have their Cards field already be
 	// pre-populated with bingo cards.
-	JoinGame(playerID uuid.UUID, playerName string) (player *Player, leaveGame func() error, err error)
+	//
+	// cardCount is how many cards the player wants, from MinCards up to the
+	// game's limit. Zero gives the player as many cards as the game allows.
+	JoinGame(playerID uuid.UUID, playerName string, cardCount int) (player *Player, leaveGame func() error, err error)
 
 	// Snapshot produces an immutable snapshot of the entire "public" game
 	// state. The resulting value should be fully JSON-serializable out of the
 	// box.
 	Snapshot() GameSnapshot
+
+	// SnapshotFor produces an immutable snapshot of the game state, as seen by
+	// a specific viewer. The viewer's role is derived from their ID; any ID
+	// that doesn't belong to the host or a card player is treated as a
+	// spectator. All parts of the snapshot must come from the same consistent
+	// read of the game state.
+	SnapshotFor(viewerID uuid.UUID) ViewerSnapshot
+
+	// DiffSince produces everything a viewer needs to catch up from the last
+	// state version they received (via SnapshotFor or an earlier diff) to the
+	// current version. Implementations are allowed to fall back to a full
+	// snapshot whenever they can't produce a patch.
+	DiffSince(viewerID uuid.UUID, sinceVersion uint64) StateDiff
 }
@@ -0,0 +1,16 @@
+//go:build embedclient
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:client/dist
+var clientFiles embed.FS
+
+// clientAssets returns the client files built by Vite.
+func clientAssets() (fs.FS, error) {
+	return fs.Sub(clientFiles, "client/dist")
+}
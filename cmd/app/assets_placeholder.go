@@ -0,0 +1,17 @@
+//go:build !embedclient
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed placeholder
+var placeholderFiles embed.FS
+
+// clientAssets returns a placeholder page explaining how to embed the real
+// client. It lets the server be built and run without needing Node installed.
+func clientAssets() (fs.FS, error) {
+	return fs.Sub(placeholderFiles, "placeholder")
+}
@@ -40,6 +40,7 @@ export type UsePlayerSocketResult = Readonly<{
     callBingo: () => void;
     rescindBingo: () => void;
     replaceCards: () => void;
+    replaceSelectedCards: (cardIds: readonly string[]) => void;
   }>;
 }>;
 
@@ -178,6 +179,19 @@ export function usePlayerSocket(): UsePlayerSocketResult {
         };
         socket.send(JSON.stringify(command));
       },
+      replaceSelectedCards: (cardIds) => {
+        if (cardIds.length === 0) {
+          return;
+        }
+
+        const socket = getSocket();
+        const command: GameCommand = {
+          type: "player_replace_selected_cards",
+          commanderId: sessionState.playerId,
+          payload: { cardIds },
+        };
+        socket.send(JSON.stringify(command));
+      },
       callBingo: () => {
         const socket = getSocket();
         const command: GameCommand = {
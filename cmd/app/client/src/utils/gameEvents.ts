@@ -0,0 +1,143 @@
+// Mirrors the GameEvent type (and all its payload types) from the Go bingo
+// package. Every event type has exactly one payload shape, so the union can be
+// narrowed by checking eventType.
+
+export type CellPosition = Readonly<{
+  row: number;
+  col: number;
+}>;
+
+export type CardValidation = Readonly<{
+  cardId: string;
+  winningCells: readonly CellPosition[];
+  invalidDaubs: readonly CellPosition[];
+}>;
+
+type PlayerPayload = Readonly<{
+  playerId: string;
+  name: string;
+  status: string;
+}>;
+
+type BingoClaimPayload = Readonly<{
+  playerId: string;
+}>;
+
+type TiebreakerRule = "high_ball" | "next_line" | "split_prize";
+
+type HostPermission =
+  | "call_balls"
+  | "judge_bingo"
+  | "moderate_players"
+  | "configure_game";
+
+type CoHostPayload = Readonly<{
+  playerId: string;
+  permissions: readonly HostPermission[];
+  name: string;
+}>;
+
+type DrawRevealedPayload = Readonly<{
+  round: number;
+  variant?: "75_ball" | "90_ball" | "80_ball" | "30_ball";
+  commitment: string;
+  seed: string;
+  scripted: boolean;
+  order: number[];
+  called: number[];
+  manualCalls: number[];
+}>;
+
+type GameEventBase<TType extends string, TPayload> = Readonly<{
+  id: string;
+  createdById: string;
+  phase: string;
+  eventType: TType;
+  created: string;
+  message: string;
+  recipientIds: readonly string[] | null;
+  payload: TPayload;
+}>;
+
+export type GameEvent =
+  | GameEventBase<"error", { commandType: string; reason: string }>
+  | GameEventBase<"game_terminated", undefined>
+  | GameEventBase<
+      "phase_changed",
+      {
+        previousPhase: string;
+        phase: string;
+        round: number;
+        maxRounds: number;
+      }
+    >
+  | GameEventBase<"round_ended", { round: number; winnerIds: string[] }>
+  | GameEventBase<"win_pattern_changed", { pattern: unknown }>
+  | GameEventBase<"card_limit_changed", { maxCards: number }>
+  | GameEventBase<"ball_called", { ball: number; calledCount: number }>
+  | GameEventBase<"ball_undone", { ball: number; calledCount: number }>
+  | GameEventBase<"daub_invalidated", { ball: number; cardIds: string[] }>
+  | GameEventBase<"calling_mode_changed", { mode: "automatic" | "manual" }>
+  | GameEventBase<
+      "auto_caller_changed",
+      {
+        autoCaller: {
+          running: boolean;
+          intervalMs: number;
+          nextCallAt: string | null;
+        };
+      }
+    >
+  | GameEventBase<"daub_applied", { cardId: string; ball: number }>
+  | GameEventBase<"daub_removed", { cardId: string; ball: number }>
+  | GameEventBase<"bingo_claimed", BingoClaimPayload>
+  | GameEventBase<"bingo_rescinded", BingoClaimPayload>
+  | GameEventBase<
+      "bingo_validated",
+      { playerId: string; valid: boolean; cards: CardValidation[] }
+    >
+  | GameEventBase<"bingo_confirmed", BingoClaimPayload>
+  | GameEventBase<"bingo_rejected", BingoClaimPayload>
+  | GameEventBase<"player_joined", PlayerPayload>
+  | GameEventBase<"player_left", PlayerPayload>
+  | GameEventBase<"player_promoted", PlayerPayload>
+  | GameEventBase<
+      "player_suspended",
+      {
+        suspension: {
+          playerId: string;
+          duration: number;
+          currentRound: number;
+        };
+      }
+    >
+  | GameEventBase<"player_reinstated", PlayerPayload>
+  | GameEventBase<"player_banned", PlayerPayload>
+  | GameEventBase<"hand_replaced", { cards: unknown[] }>
+  | GameEventBase<
+      "draw_committed",
+      { round: number; commitment: string; scripted: boolean }
+    >
+  | GameEventBase<"draw_revealed", DrawRevealedPayload>
+  | GameEventBase<
+      "tiebreaker_started",
+      { rule: TiebreakerRule; playerIds: string[]; commitment?: string }
+    >
+  | GameEventBase<
+      "tiebreaker_ball_called",
+      { ball: number; calledCount: number; playerId?: string }
+    >
+  | GameEventBase<
+      "tiebreaker_resolved",
+      {
+        rule: TiebreakerRule;
+        winnerIds: string[];
+        draw?: DrawRevealedPayload;
+      }
+    >
+  | GameEventBase<
+      "host_transferred",
+      { previousHostId: string; newHostId: string; newHostName: string }
+    >
+  | GameEventBase<"co_host_appointed", CoHostPayload>
+  | GameEventBase<"co_host_dismissed", CoHostPayload>;
@@ -0,0 +1,232 @@
+// Command app runs a bingo server, serving both the API and the browser
+// client from a single binary.
+//
+// Every flag can also be set via an environment variable, with flags taking
+// precedence:
+//
+//	-addr         BINGO_ADDR         Address to listen on (default ":8080")
+//	-seeded-rng   BINGO_SEEDED_RNG   Generate cards and IDs from a seed, so
+//	                                 that games can be replayed from their
+//	                                 recordings (default false, which uses
+//	                                 crypto/rand and disables recordings).
+//	                                 Balls are always shuffled with a secure
+//	                                 seed
+//	-seed         BINGO_SEED         Base RNG seed for every game when using
+//	                                 -seeded-rng (default 0, which seeds each
+//	                                 game from the clock)
+//	-max-players  BINGO_MAX_PLAYERS  Max players per game (default 0, which
+//	                                 uses the game package default)
+//	-max-rounds   BINGO_MAX_ROUNDS   Max rounds per game (default 0, which
+//	                                 uses the game package default)
+//	-log-dir      BINGO_LOG_DIR      Directory for per-room event logs and
+//	                                 recordings (default "", which disables
+//	                                 logging)
+//	-data-dir     BINGO_DATA_DIR     Directory for persisting games, so that
+//	                                 they survive restarts (default "", which
+//	                                 disables persistence)
+//
+// The client is only embedded when building with the embedclient tag, after
+// the client has been built with Vite:
+//
+//	cd cmd/app/client && pnpm build && cd ../../..
+//	go build -tags embedclient ./cmd/app
+//
+// Without the tag, the binary serves a placeholder page instead.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Parkreiner/bingo/gamestore"
+	"github.com/Parkreiner/bingo/server"
+)
+
+// shutdownTimeout is how long the HTTP server will wait for in-flight requests
+// to finish once the process has been told to stop
+const shutdownTimeout = 10 * time.Second
+
+type config struct {
+	addr       string
+	seed       int64
+	seededRNG  bool
+	maxPlayers int
+	maxRounds  int
+	logDir     string
+	dataDir    string
+}
+
+func main() {
+	cfg, err := parseConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseConfig reads the config from environment variables first, and then
+// lets any flags override them.
+func parseConfig(args []string) (config, error) {
+	var cfg config
+	var err error
+	envInt := func(key string, fallback int64) int64 {
+		raw, ok := os.LookupEnv(key)
+		if !ok || err != nil {
+			return fallback
+		}
+		parsed, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil {
+			err = fmt.Errorf("environment variable %s must be an integer: %v", key, parseErr)
+			return fallback
+		}
+		return parsed
+	}
+	envBool := func(key string, fallback bool) bool {
+		raw, ok := os.LookupEnv(key)
+		if !ok || err != nil {
+			return fallback
+		}
+		parsed, parseErr := strconv.ParseBool(raw)
+		if parseErr != nil {
+			err = fmt.Errorf("environment variable %s must be a boolean: %v", key, parseErr)
+			return fallback
+		}
+		return parsed
+	}
+	envString := func(key string, fallback string) string {
+		if raw, ok := os.LookupEnv(key); ok {
+			return raw
+		}
+		return fallback
+	}
+
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags.StringVar(&cfg.addr, "addr", envString("BINGO_ADDR", ":8080"), "address to listen on")
+	flags.BoolVar(&cfg.seededRNG, "seeded-rng", envBool("BINGO_SEEDED_RNG", false), "generate cards and IDs from a seed, so that games can be replayed")
+	flags.Int64Var(&cfg.seed, "seed", envInt("BINGO_SEED", 0), "base RNG seed for every game with -seeded-rng (0 seeds each game from the clock)")
+	flags.IntVar(&cfg.maxPlayers, "max-players", int(envInt("BINGO_MAX_PLAYERS", 0)), "max players per game (0 uses the default)")
+	flags.IntVar(&cfg.maxRounds, "max-rounds", int(envInt("BINGO_MAX_ROUNDS", 0)), "max rounds per game (0 uses the default)")
+	flags.StringVar(&cfg.logDir, "log-dir", envString("BINGO_LOG_DIR", ""), "directory for per-room event logs and recordings (empty disables logging)")
+	flags.StringVar(&cfg.dataDir, "data-dir", envString("BINGO_DATA_DIR", ""), "directory for persisting games across restarts (empty disables persistence)")
+	if err != nil {
+		return cfg, err
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if cfg.maxPlayers < 0 {
+		return cfg, errors.New("max players must not be negative")
+	}
+	if cfg.maxRounds < 0 {
+		return cfg, errors.New("max rounds must not be negative")
+	}
+	return cfg, nil
+}
+
+func run(cfg config) error {
+	options := server.Options{
+		SeededRNG:   cfg.seededRNG,
+		RNGSeed:     cfg.seed,
+		MaxPlayers:  nil,
+		MaxRounds:   nil,
+		EventLogDir: cfg.logDir,
+		Store:       nil,
+	}
+	if cfg.maxPlayers != 0 {
+		options.MaxPlayers = &cfg.maxPlayers
+	}
+	if cfg.maxRounds != 0 {
+		options.MaxRounds = &cfg.maxRounds
+	}
+	if cfg.logDir != "" {
+		if err := os.MkdirAll(cfg.logDir, 0o755); err != nil {
+			return fmt.Errorf("unable to create log directory: %v", err)
+		}
+	}
+
+	if cfg.dataDir != "" {
+		store, err := gamestore.NewFileStore(cfg.dataDir)
+		if err != nil {
+			return err
+		}
+		options.Store = store
+	}
+
+	assets, err := clientAssets()
+	if err != nil {
+		return fmt.Errorf("unable to load client assets: %v", err)
+	}
+
+	bingoServer := server.New(options)
+	// Rooms that can't be restored shouldn't keep every other room from
+	// being served
+	if err := bingoServer.RestoreRooms(); err != nil {
+		log.Printf("unable to restore some rooms: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/", bingoServer)
+	mux.Handle("/", clientHandler(assets))
+	httpServer := &http.Server{
+		Addr:              cfg.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", cfg.addr)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		_ = bingoServer.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("shutting down")
+	// Games need to be disposed first, because Shutdown doesn't wait for any
+	// hijacked connections (i.e., WebSockets). Disposing a game closes every
+	// socket connected to it.
+	gamesErr := bingoServer.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	httpErr := httpServer.Shutdown(shutdownCtx)
+	return errors.Join(gamesErr, httpErr)
+}
+
+// clientHandler serves the client's static assets. Any path that doesn't
+// match a file gets the client's index page, so that client-side routing
+// works on page reloads.
+func clientHandler(assets fs.FS) http.Handler {
+	fileServer := http.FileServerFS(assets)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)[1:]
+		if name == "" {
+			name = "."
+		}
+		if _, err := fs.Stat(assets, name); err != nil {
+			http.ServeFileFS(w, r, assets, "index.html")
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
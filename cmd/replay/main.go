@@ -0,0 +1,91 @@
+// Command replay re-runs a recorded bingo game from scratch, reproducing every
+// card, ball, and event from the original game. Recordings are saved by the
+// app server once a game is over (see the -log-dir flag for cmd/app).
+//
+// Usage:
+//
+//	replay [-state] <recording.json>
+//
+// Every event is written to stdout as a single line of JSON. With -state, the
+// game's final state is written as the last line instead. If any step fails
+// differently than it did in the original game, the differences are written
+// to stderr, and the command exits with a non-zero status.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Parkreiner/bingo/game"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("replay: ")
+
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	printState := flags.Bool("state", false, "print the game's final state after the last event")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if flags.NArg() != 1 {
+		log.Fatal("expected exactly one recording file (use - for stdin)")
+	}
+
+	recording, err := readRecording(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := game.Replay(recording, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, event := range result.Events {
+		if err := encoder.Encode(event); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *printState {
+		if err := encoder.Encode(result.Final); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, d := range result.Divergences {
+		log.Printf("step %d diverged: expected error %q, got %q", d.Step, d.Expected, d.Actual)
+	}
+	if len(result.Divergences) != 0 {
+		os.Exit(1)
+	}
+}
+
+// readRecording parses a recording from a file, or from stdin if the path is
+// a single dash.
+func readRecording(path string) (game.Recording, error) {
+	var recording game.Recording
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return recording, fmt.Errorf("unable to open recording: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	if err := json.NewDecoder(r).Decode(&recording); err != nil {
+		return recording, fmt.Errorf("unable to parse recording: %v", err)
+	}
+	if len(recording.Steps) == 0 {
+		return recording, errors.New("recording does not have any steps")
+	}
+	return recording, nil
+}
@@ -0,0 +1,113 @@
+// Command verifydraw checks that every ball draw in a game matched the
+// commitment published before its round started.
+//
+// Usage:
+//
+//	verifydraw <events.jsonl>
+//
+// The input is a log with one game event per line, like the per-room logs
+// written by the app server (see the -log-dir flag for cmd/app). Use - to read
+// from stdin. Each revealed draw is checked against the most recent
+// commitment for the same round, and the result is printed to stdout. The
+// command exits with a non-zero status if any draw fails verification, or if
+// the log doesn't contain any revealed draws.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/Parkreiner/bingo/game"
+)
+
+// maxLineBytes is the longest line that can be read from an event log
+const maxLineBytes = 16 << 20
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("verifydraw: ")
+
+	if len(os.Args) != 2 {
+		log.Fatal("expected exactly one event log (use - for stdin)")
+	}
+
+	var r io.Reader = os.Stdin
+	if os.Args[1] != "-" {
+		file, err := os.Open(os.Args[1])
+		if err != nil {
+			log.Fatalf("unable to open event log: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	verified, failed, err := verifyLog(r, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if failed != 0 {
+		log.Fatalf("%d of %d draws failed verification", failed, verified+failed)
+	}
+	if verified == 0 {
+		log.Fatal("log does not contain any revealed draws")
+	}
+}
+
+// verifyLog checks every revealed draw in an event log, writing the result
+// for each draw to out.
+func verifyLog(r io.Reader, out io.Writer) (verified int, failed int, err error) {
+	commitments := map[int]string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineBytes)
+	for scanner.Scan() {
+		// Logs can also contain manual writes, so anything that isn't an
+		// event gets skipped
+		var event bingo.GameEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case bingo.EventTypeDrawCommitted:
+			var payload bingo.GameEventPayloadDrawCommitted
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				return verified, failed, fmt.Errorf("unable to parse commitment %s: %v", event.ID, err)
+			}
+			commitments[payload.Round] = payload.Commitment
+
+		case bingo.EventTypeDrawRevealed:
+			var payload bingo.GameEventPayloadDrawRevealed
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				return verified, failed, fmt.Errorf("unable to parse reveal %s: %v", event.ID, err)
+			}
+
+			commitment, ok := commitments[payload.Round]
+			if !ok {
+				failed++
+				fmt.Fprintf(out, "round %d: FAILED: no commitment was published before the reveal\n", payload.Round)
+				continue
+			}
+			if err := game.VerifyDraw(commitment, payload); err != nil {
+				failed++
+				fmt.Fprintf(out, "round %d: FAILED: %v\n", payload.Round, err)
+				continue
+			}
+			verified++
+			scripted := ""
+			if payload.Scripted {
+				scripted = " (scripted)"
+			}
+			fmt.Fprintf(out, "round %d: verified %d calls (%d manual) against commitment %s%s\n", payload.Round, len(payload.Called), len(payload.ManualCalls), commitment, scripted)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return verified, failed, fmt.Errorf("unable to read event log: %v", err)
+	}
+	return verified, failed, nil
+}
@@ -26,17 +26,81 @@ const (
 	// assumed that it will not ever be updated, and it will automatically
 	// handle all cleanup logic
 	GameCommandSystemDispose GameCommandType = "system_dispose"
+	// GameCommandSystemAutoCallBall is issued by a game's auto-caller
+	// whenever the next ball is due. It is rejected if the auto-caller has
+	// been paused (or has had its speed changed) since the ball was
+	// scheduled, so it never needs to be issued by anything else.
+	GameCommandSystemAutoCallBall GameCommandType = "system_auto_call_ball"
 )
 
 const (
-	GameCommandHostStartGame            GameCommandType = "host_start_game"
-	GameCommandHostTerminateGame        GameCommandType = "host_terminate_game"
-	GameCommandHostBanPlayer            GameCommandType = "host_ban_player"
+	GameCommandHostStartGame     GameCommandType = "host_start_game"
+	GameCommandHostTerminateGame GameCommandType = "host_terminate_game"
+	GameCommandHostBanPlayer     GameCommandType = "host_ban_player"
+	// GameCommandHostTransferHostStatus hands the game over to one of its card
+	// players. The new host gives up their cards, and the old host stops
+	// receiving any events (though they can join again as a player).
+	GameCommandHostTransferHostStatus GameCommandType = "host_transfer_host_status"
+	// GameCommandHostAppointCoHost gives a card player some of the host's
+	// permissions (see HostPermission). Appointing someone who is already a
+	// co-host replaces their permissions.
+	GameCommandHostAppointCoHost GameCommandType = "host_appoint_co_host"
+	// GameCommandHostDismissCoHost takes away all of a co-host's permissions.
+	// They stay in the game as a normal card player.
+	GameCommandHostDismissCoHost GameCommandType = "host_dismiss_co_host"
+	// GameCommandHostSuspendPlayer takes a player out of play for a number
+	// of rounds. Suspended players stay connected and keep their cards, but
+	// can't daub or call bingo. Once the suspension is over, they are
+	// automatically made active again.
 	GameCommandHostSuspendPlayer        GameCommandType = "host_suspend_player"
 	GameCommandHostRequestBall          GameCommandType = "host_request_ball"
 	GameCommandHostSyncBall             GameCommandType = "host_sync_ball"
 	GameCommandHostAcknowledgeBingoCall GameCommandType = "host_acknowledge_bingo_call"
+	// GameCommandHostStartTiebreakerRound settles a round where the host has
+	// accepted bingo calls from more than one player, using a rule picked by
+	// the host (see TiebreakerRule). Only the players with accepted calls take
+	// part; everyone else spectates until the tiebreaker is over. Any balls needed for the
+	// tiebreaker come from a fresh draw, and are called with the same
+	// commands as normal balls.
 	GameCommandHostStartTiebreakerRound GameCommandType = "host_start_tiebreaker_round"
+	// GameCommandHostSetCallingMode lets the host switch between having the
+	// game draw balls (via GameCommandHostRequestBall) and entering balls
+	// drawn from a physical ball machine (via GameCommandHostSyncBall). Like
+	// the win pattern, it can only be changed before a round's first ball is
+	// called.
+	GameCommandHostSetCallingMode GameCommandType = "host_set_calling_mode"
+	// GameCommandHostUndoBall takes back the most recent ball entered via
+	// GameCommandHostSyncBall, for when the host mis-keys a ball. It is only
+	// allowed in manual mode, while balls are being called. Any player who
+	// already daubed the ball is notified, but their daubs are left alone.
+	GameCommandHostUndoBall GameCommandType = "host_undo_ball"
+	// GameCommandHostStartAutoCaller has the game call a new ball on its own
+	// at a regular interval, until the host pauses it or the round ends. The
+	// auto-caller pauses itself while any bingo calls are being confirmed,
+	// and resumes once they have all been rejected. It is only allowed in
+	// automatic mode.
+	GameCommandHostStartAutoCaller GameCommandType = "host_start_auto_caller"
+	// GameCommandHostPauseAutoCaller stops the auto-caller until the host
+	// starts it again.
+	GameCommandHostPauseAutoCaller GameCommandType = "host_pause_auto_caller"
+	// GameCommandHostSetAutoCallerSpeed changes how long the auto-caller
+	// waits between balls. If the auto-caller is running, the wait for the
+	// next ball starts over.
+	GameCommandHostSetAutoCallerSpeed GameCommandType = "host_set_auto_caller_speed"
+	// GameCommandHostEndRound ends a round without a winner, for when every
+	// ball has been called and nobody has won (e.g., because every player
+	// left). It is only allowed while balls are being called, once there are
+	// no balls left to call.
+	GameCommandHostEndRound GameCommandType = "host_end_round"
+	// GameCommandHostSetWinPattern lets the host change which pattern players
+	// need to daub in order to win. It can only be used before the first ball
+	// of a round has been called, so that the rules never change mid-round.
+	GameCommandHostSetWinPattern GameCommandType = "host_set_win_pattern"
+	// GameCommandHostSetCardLimit changes the most cards that each player is
+	// allowed to have. Any player with more cards than the new limit has
+	// their extra cards taken away. Like GameCommandHostSetWinPattern, it can
+	// only be used before the first ball of a round has been called.
+	GameCommandHostSetCardLimit GameCommandType = "host_set_card_limit"
 	// GameCommandHostAwardPlayers indicates that the host acknowledges a
 	// successful bingo call from one or more players. It is allowed to be
 	// called at any time during the Confirming or Tiebreaker phases. For the
@@ -52,7 +116,20 @@ const (
 	GameCommandPlayerUndoDaub     GameCommandType = "player_undo_daub"
 	GameCommandPlayerCallBingo    GameCommandType = "player_call_bingo"
 	GameCommandPlayerRescindBingo GameCommandType = "player_rescind_bingo"
+	// GameCommandPlayerReplaceCards swaps out the player's entire hand. Like
+	// every other command that changes a player's cards, it is only allowed
+	// before a round's first ball is called.
 	GameCommandPlayerReplaceCards GameCommandType = "player_replace_cards"
+	// GameCommandPlayerCheckOutStrip replaces the player's hand with a full
+	// strip of cards, which together contain every ball exactly once. It is
+	// only available for variants with strips (see Variant.StripSize), and
+	// only before a round's first ball is called.
+	GameCommandPlayerCheckOutStrip GameCommandType = "player_check_out_strip"
+	// GameCommandPlayerReplaceSelectedCards swaps out specific cards from the
+	// player's hand, and leaves the rest alone. Either every selected card is
+	// replaced, or none of them are. It is only allowed before a round's first
+	// ball is called.
+	GameCommandPlayerReplaceSelectedCards GameCommandType = "player_replace_selected_cards"
 )
 
 // GameCommand is any instruction that can be dispatched directly and
@@ -80,15 +157,36 @@ type GameCommandPayloadSystemBroadcastState struct {
 }
 
 type GameCommandPayloadTransferHostStatus struct {
+	// It is assumed that this field always has exactly one element in it,
+	// which must be the ID of a card player who isn't suspended
 	NewHostID []uuid.UUID `json:"newHostId"`
 }
 
+type GameCommandPayloadHostAppointCoHost struct {
+	PlayerID uuid.UUID `json:"playerId"`
+	// Permissions must have at least one permission in it
+	Permissions []HostPermission `json:"permissions"`
+}
+
+type GameCommandPayloadHostDismissCoHost struct {
+	PlayerID uuid.UUID `json:"playerId"`
+}
+
+// GameCommandPayloadHostBanPlayer bans a player from the game, along with
+// every future game run by the same host. Player IDs are only as stable as
+// whatever the player uses to identify themselves, so a banned player can
+// come back under a new ID.
 type GameCommandPayloadHostBanPlayer struct {
 	PlayerID uuid.UUID `json:"playerId"`
 }
 
 type GameCommandPayloadHostSuspendPlayer struct {
 	PlayerID uuid.UUID `json:"player_id"`
+	// RoundDuration is how many round ends the suspension lasts for. The
+	// round that the player is suspended in counts towards the duration, so a
+	// one-round suspension issued mid-round ends once that round does. If it
+	// is missing or zero, the player is suspended for a single round.
+	RoundDuration int `json:"duration"`
 }
 
 type GameCommandPayloadHostAwardsPlayers struct {
@@ -97,10 +195,69 @@ type GameCommandPayloadHostAwardsPlayers struct {
 	PlayerIDs []uuid.UUID `json:"playerIds"`
 }
 
+type GameCommandPayloadHostAcknowledgeBingoCall struct {
+	PlayerID uuid.UUID `json:"playerId"`
+	// Indicates whether the host agrees that the player has a valid bingo. A
+	// rejected call removes the player from the list of bingo callers.
+	Accepted bool `json:"accepted"`
+}
+
+type GameCommandPayloadHostStartTiebreakerRound struct {
+	Rule TiebreakerRule `json:"rule"`
+}
+
+type GameCommandPayloadHostSetWinPattern struct {
+	// The ID of one of the standard win patterns. Ignored if CustomPattern is
+	// defined.
+	PatternID string `json:"patternId"`
+	// A fully custom pattern defined by the host
+	CustomPattern *WinPattern `json:"customPattern,omitempty"`
+}
+
 type GameCommandPayloadHostSyncBall struct {
 	Value int `json:"value"`
 }
 
+type GameCommandPayloadHostSetCallingMode struct {
+	Mode CallingMode `json:"mode"`
+}
+
+type GameCommandPayloadHostStartAutoCaller struct {
+	// IntervalMs is how long to wait between balls, in milliseconds. The
+	// payload is optional; if it is missing or zero, the auto-caller keeps
+	// its current interval.
+	IntervalMs int64 `json:"intervalMs"`
+}
+
+type GameCommandPayloadHostSetAutoCallerSpeed struct {
+	// IntervalMs is how long to wait between balls, in milliseconds
+	IntervalMs int64 `json:"intervalMs"`
+}
+
+type GameCommandPayloadHostUndoBall struct {
+	// Value must match the most recently called ball. This keeps a repeated
+	// undo request from taking back a ball that was called correctly.
+	Value int `json:"value"`
+}
+
+type GameCommandPayloadHostSetCardLimit struct {
+	// MaxCards must be between MinCards and MaxCards (both inclusive)
+	MaxCards int `json:"maxCards"`
+}
+
+type GameCommandPayloadPlayerReplaceCards struct {
+	// CardCount is how many cards the player wants in their new hand. The
+	// payload is optional; if it is missing or zero, the new hand has as
+	// many cards as the old one.
+	CardCount int `json:"cardCount"`
+}
+
+type GameCommandPayloadPlayerReplaceSelectedCards struct {
+	// CardIDs lists every card that should be replaced. It must have at least
+	// one ID, and each ID must belong to a card in the player's hand.
+	CardIDs []uuid.UUID `json:"cardIds"`
+}
+
 type GameCommandPayloadPlayerDaub struct {
 	CardID uuid.UUID `json:"cardId"`
 	Cell   int       `json:"cell"`
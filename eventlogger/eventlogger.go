@@ -3,6 +3,7 @@
 package eventlogger
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -22,8 +23,9 @@ type loggerRequest struct {
 }
 
 // EventLogger handles logs of two types:
-// 1. Automatic logs in response to every game event
-// 2.
+// 1. Automatic logs in response to every game event. Each event is written as
+// a single line of JSON, so that the log can be consumed by other tools
+// 2. Manual logs written via the Write method
 // Once instantiated, the logger will automatically start logging any events for
 // phase types. The logger can be disposed by calling the Close method.
 type EventLogger struct {
@@ -43,9 +45,9 @@ type Init struct {
 // New instantiates an EventLogger and automatically subscribes it to all events
 // dispatched for every possible game phase.
 func New(init Init) (*EventLogger, error) {
-	file, err := os.Open(init.OutputPath)
+	file, err := os.OpenFile(init.OutputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
 	if err != nil {
-		return nil, fmt.Errorf("filepath %q does not exist: %v", init.OutputPath, err)
+		return nil, fmt.Errorf("unable to open filepath %q: %v", init.OutputPath, err)
 	}
 
 	allEventsChan, unsub, err := init.Subscriber.Subscribe(nil)
@@ -62,47 +64,48 @@ func New(init Init) (*EventLogger, error) {
 	}
 
 	go func() {
-		defer unsub()
-		done := false
+		defer func() {
+			unsub()
+			_ = logger.file.Close()
+			close(disposedChan)
+		}()
 
 		for {
 			select {
-			case req, closed := <-loggerChan:
-				if closed {
-					done = true
-					break
+			case req, ok := <-loggerChan:
+				if !ok {
+					return
 				}
 				b, err := logger.file.Write(req.content)
 				req.resultChan <- logWriteResult{
 					bytesWritten: b,
 					err:          err,
 				}
-			case event, closed := <-allEventsChan:
-				if closed {
-					done = true
-					break
+			case event, ok := <-allEventsChan:
+				if !ok {
+					// The game has been disposed, but the logger should still
+					// be able to accept manual writes until it's closed
+					allEventsChan = nil
+					continue
 				}
-				logLine := fmt.Sprintf("[phase %s] [type %s] [id %s] %s", event.Phase, event.Type, event.ID, event.Message)
-				_, _ = logger.file.Write([]byte(logLine))
-			}
-
-			if done {
-				break
+				logLine, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				_, _ = logger.file.Write(append(logLine, '\n'))
 			}
 		}
-
-		close(disposedChan)
 	}()
 
 	return logger, nil
 }
 
 func (el *EventLogger) Write(content []byte) (int, error) {
+	// disposedChan is only ever closed, never sent to, so any receive means
+	// that the logger has been disposed
 	select {
-	case _, closed := <-el.disposedChan:
-		if closed {
-			return 0, errors.New("logger is closed")
-		}
+	case <-el.disposedChan:
+		return 0, errors.New("logger is closed")
 	default:
 	}
 
@@ -121,10 +124,8 @@ func (el *EventLogger) Write(content []byte) (int, error) {
 // safe to call multiple times; calling it more than once results in a no-op.
 func (el *EventLogger) Close() error {
 	select {
-	case _, closed := <-el.disposedChan:
-		if closed {
-			return nil
-		}
+	case <-el.disposedChan:
+		return nil
 	default:
 	}
 
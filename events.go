@@ -1,20 +1,130 @@
 package bingo
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// GameEventType indicates the type and context of a new event's message
-// TODO: Definitely need to revamp this type with better granularity
+// GameEventType indicates the type and context of a new event. Each event type
+// has an accompanying payload struct (unless noted otherwise), so that
+// consumers never need to parse an event's message to figure out what
+// happened.
 type GameEventType string
 
-// TODO: Add comments for each event type once I figure out exactly how I want
-// to structure event types in general
 const (
-	EventTypeUpdate GameEventType = "update"
-	EventTypeError  GameEventType = "error"
+	// EventTypeError indicates that a command could not be processed. Uses
+	// GameEventPayloadError.
+	EventTypeError GameEventType = "error"
+	// EventTypeGameTerminated indicates that a game has been torn down, and
+	// that no other events will be dispatched for it. Has no payload.
+	EventTypeGameTerminated GameEventType = "game_terminated"
+	// EventTypePhaseChanged indicates that a game has moved from one phase to
+	// another. Uses GameEventPayloadPhaseChanged.
+	EventTypePhaseChanged GameEventType = "phase_changed"
+	// EventTypeRoundEnded indicates that a round has finished, along with who
+	// won it. Uses GameEventPayloadRoundEnded.
+	EventTypeRoundEnded GameEventType = "round_ended"
+	// EventTypeWinPatternChanged indicates that the host has changed which
+	// pattern is needed to win a round. Uses GameEventPayloadWinPatternChanged.
+	EventTypeWinPatternChanged GameEventType = "win_pattern_changed"
+	// EventTypeCardLimitChanged indicates that the host has changed the most
+	// cards that each player is allowed to have. Uses
+	// GameEventPayloadCardLimitChanged.
+	EventTypeCardLimitChanged GameEventType = "card_limit_changed"
+	// EventTypeBallCalled indicates that a new ball has been called. Uses
+	// GameEventPayloadBallCalled.
+	EventTypeBallCalled GameEventType = "ball_called"
+	// EventTypeBallUndone indicates that the host has taken back a mis-keyed
+	// ball. Uses GameEventPayloadBallUndone.
+	EventTypeBallUndone GameEventType = "ball_undone"
+	// EventTypeDaubInvalidated is sent to each player who daubed a ball that
+	// was then undone, so that they can remove their daubs. Uses
+	// GameEventPayloadDaubInvalidated.
+	EventTypeDaubInvalidated GameEventType = "daub_invalidated"
+	// EventTypeCallingModeChanged indicates that the host has switched
+	// between automatic and manual calling. Uses
+	// GameEventPayloadCallingModeChanged.
+	EventTypeCallingModeChanged GameEventType = "calling_mode_changed"
+	// EventTypeAutoCallerChanged indicates that the auto-caller has been
+	// started, paused, or had its speed changed. Uses
+	// GameEventPayloadAutoCallerChanged.
+	EventTypeAutoCallerChanged GameEventType = "auto_caller_changed"
+	// EventTypeDaubApplied indicates that a player has daubed a cell on one of
+	// their cards. Uses GameEventPayloadDaub.
+	EventTypeDaubApplied GameEventType = "daub_applied"
+	// EventTypeDaubRemoved indicates that a player has removed a daub from one
+	// of their cards. Uses GameEventPayloadDaub.
+	EventTypeDaubRemoved GameEventType = "daub_removed"
+	// EventTypeBingoClaimed indicates that a player has called bingo. Uses
+	// GameEventPayloadBingoClaimed.
+	EventTypeBingoClaimed GameEventType = "bingo_claimed"
+	// EventTypeBingoRescinded indicates that a player has taken back their
+	// bingo call. Uses GameEventPayloadBingoClaimed.
+	EventTypeBingoRescinded GameEventType = "bingo_rescinded"
+	// EventTypeBingoValidated contains the results of the server automatically
+	// validating a bingo claim. It is only ever sent to the host, and is purely
+	// advisory. Uses GameEventPayloadBingoValidated.
+	EventTypeBingoValidated GameEventType = "bingo_validated"
+	// EventTypeBingoConfirmed indicates that the host has accepted a player's
+	// bingo call. Uses GameEventPayloadBingoClaimed.
+	EventTypeBingoConfirmed GameEventType = "bingo_confirmed"
+	// EventTypeBingoRejected indicates that the host has rejected a player's
+	// bingo call. Uses GameEventPayloadBingoClaimed.
+	EventTypeBingoRejected GameEventType = "bingo_rejected"
+	// EventTypePlayerJoined indicates that a new player has joined the game.
+	// Uses GameEventPayloadPlayer.
+	EventTypePlayerJoined GameEventType = "player_joined"
+	// EventTypePlayerLeft indicates that a player has left the game of their
+	// own accord. Uses GameEventPayloadPlayer.
+	EventTypePlayerLeft GameEventType = "player_left"
+	// EventTypePlayerPromoted indicates that a player has been moved off the
+	// waitlist, and can play in the current round. Uses GameEventPayloadPlayer.
+	EventTypePlayerPromoted GameEventType = "player_promoted"
+	// EventTypePlayerSuspended indicates that the host has suspended a player.
+	// Uses GameEventPayloadPlayerSuspended.
+	EventTypePlayerSuspended GameEventType = "player_suspended"
+	// EventTypePlayerReinstated indicates that a player's suspension has
+	// elapsed. Uses GameEventPayloadPlayer.
+	EventTypePlayerReinstated GameEventType = "player_reinstated"
+	// EventTypePlayerBanned indicates that the host has banned a player. Uses
+	// GameEventPayloadPlayer.
+	EventTypePlayerBanned GameEventType = "player_banned"
+	// EventTypeHandReplaced indicates that a player has received a new set of
+	// cards. Uses GameEventPayloadHandReplaced.
+	EventTypeHandReplaced GameEventType = "hand_replaced"
+	// EventTypeDrawCommitted indicates that the balls for a new round have
+	// been shuffled, and publishes a commitment to their order before any of
+	// them are called. Uses GameEventPayloadDrawCommitted.
+	EventTypeDrawCommitted GameEventType = "draw_committed"
+	// EventTypeDrawRevealed reveals everything needed to verify a round's
+	// draw against its commitment, once the round is over. Uses
+	// GameEventPayloadDrawRevealed.
+	EventTypeDrawRevealed GameEventType = "draw_revealed"
+	// EventTypeTiebreakerStarted indicates that the host has started a
+	// tiebreaker between the players who called bingo. Uses
+	// GameEventPayloadTiebreakerStarted.
+	EventTypeTiebreakerStarted GameEventType = "tiebreaker_started"
+	// EventTypeTiebreakerBallCalled indicates that a ball has been called for
+	// the current tiebreaker. These balls don't count towards the round's
+	// called balls. Uses GameEventPayloadTiebreakerBallCalled.
+	EventTypeTiebreakerBallCalled GameEventType = "tiebreaker_ball_called"
+	// EventTypeTiebreakerResolved indicates that a tiebreaker has decided who
+	// wins the round. It is always followed by EventTypeRoundEnded. Uses
+	// GameEventPayloadTiebreakerResolved.
+	EventTypeTiebreakerResolved GameEventType = "tiebreaker_resolved"
+	// EventTypeHostTransferred indicates that a new player is hosting the
+	// game, either because the old host handed it over, or because the old
+	// host left. Uses GameEventPayloadHostTransferred.
+	EventTypeHostTransferred GameEventType = "host_transferred"
+	// EventTypeCoHostAppointed indicates that a player has been made a
+	// co-host, or has had their permissions changed. Uses
+	// GameEventPayloadCoHost.
+	EventTypeCoHostAppointed GameEventType = "co_host_appointed"
+	// EventTypeCoHostDismissed indicates that a player is no longer a
+	// co-host. Uses GameEventPayloadCoHost, with no permissions.
+	EventTypeCoHostDismissed GameEventType = "co_host_dismissed"
 )
 
 // GameEvent represents something that has happened in the game (either the
@@ -25,8 +135,167 @@ type GameEvent struct {
 	Phase       GamePhase     `json:"phase"`
 	Type        GameEventType `json:"eventType"`
 	Created     time.Time     `json:"created"`
-	Message     string        `json:"message"`
+	// Payload works the same way as GameCommand's Payload. Each event type
+	// documents which payload struct it uses.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Message is a human-readable description of the event, meant for logs.
+	// It should never be parsed; use the payload instead.
+	Message string `json:"message"`
 	// If the player ID slice is empty/nil, it's assumed that the event should
 	// be broadcast to all players
 	RecipientIDs []uuid.UUID `json:"recipientIds"`
 }
+
+type GameEventPayloadError struct {
+	CommandType GameCommandType `json:"commandType"`
+	Reason      string          `json:"reason"`
+}
+
+type GameEventPayloadPhaseChanged struct {
+	PreviousPhase GamePhase `json:"previousPhase"`
+	Phase         GamePhase `json:"phase"`
+	Round         int       `json:"round"`
+	MaxRounds     int       `json:"maxRounds"`
+}
+
+type GameEventPayloadRoundEnded struct {
+	Round     int         `json:"round"`
+	WinnerIDs []uuid.UUID `json:"winnerIds"`
+}
+
+type GameEventPayloadWinPatternChanged struct {
+	Pattern WinPattern `json:"pattern"`
+}
+
+type GameEventPayloadCardLimitChanged struct {
+	MaxCards int `json:"maxCards"`
+}
+
+type GameEventPayloadBallCalled struct {
+	Ball Ball `json:"ball"`
+	// The total number of balls that have been called in the current round,
+	// including this one
+	CalledCount int `json:"calledCount"`
+}
+
+type GameEventPayloadBallUndone struct {
+	Ball Ball `json:"ball"`
+	// The total number of balls that have been called in the current round,
+	// now that the ball has been undone
+	CalledCount int `json:"calledCount"`
+}
+
+type GameEventPayloadDaubInvalidated struct {
+	Ball Ball `json:"ball"`
+	// CardIDs contains every one of the player's cards that has the ball
+	// daubed
+	CardIDs []uuid.UUID `json:"cardIds"`
+}
+
+type GameEventPayloadCallingModeChanged struct {
+	Mode CallingMode `json:"mode"`
+}
+
+type GameEventPayloadAutoCallerChanged struct {
+	AutoCaller AutoCallerStatus `json:"autoCaller"`
+}
+
+type GameEventPayloadDaub struct {
+	CardID uuid.UUID `json:"cardId"`
+	Ball   Ball      `json:"ball"`
+}
+
+type GameEventPayloadBingoClaimed struct {
+	PlayerID uuid.UUID `json:"playerId"`
+}
+
+type GameEventPayloadBingoValidated struct {
+	ClaimValidation
+}
+
+type GameEventPayloadPlayer struct {
+	PlayerID uuid.UUID    `json:"playerId"`
+	Name     string       `json:"name"`
+	Status   PlayerStatus `json:"status"`
+}
+
+type GameEventPayloadPlayerSuspended struct {
+	Suspension PlayerSuspension `json:"suspension"`
+}
+
+type GameEventPayloadHandReplaced struct {
+	Cards []*Card `json:"cards"`
+}
+
+type GameEventPayloadDrawCommitted struct {
+	Round int `json:"round"`
+	// Commitment is the hex-encoded SHA-256 hash of the round's seed and
+	// shuffled ball order
+	Commitment string `json:"commitment"`
+	// Scripted indicates that the order was predetermined (for a demo or QA
+	// session) instead of being shuffled from a random seed
+	Scripted bool `json:"scripted"`
+}
+
+type GameEventPayloadDrawRevealed struct {
+	Round int `json:"round"`
+	// Variant determines which balls the order should contain. It is empty
+	// for draws revealed before variants existed, which were all 75-ball.
+	Variant    VariantID `json:"variant,omitempty"`
+	Commitment string    `json:"commitment"`
+	// Seed is serialized as a string, because it won't always fit in a
+	// JavaScript number
+	Seed int64 `json:"seed,string"`
+	// Scripted indicates that the order was predetermined. Scripted draws
+	// always have a seed of zero, and their order can't be checked against it.
+	Scripted bool `json:"scripted"`
+	// Order contains every ball in the order that it would have been drawn,
+	// if every ball had been drawn automatically
+	Order []Ball `json:"order"`
+	// Called contains every ball called during the round, in order
+	Called []Ball `json:"called"`
+	// ManualCalls contains every called ball that was synced from a physical
+	// ball machine. Manual calls can't be verified against the order.
+	ManualCalls []Ball `json:"manualCalls"`
+}
+
+type GameEventPayloadTiebreakerStarted struct {
+	Rule TiebreakerRule `json:"rule"`
+	// PlayerIDs contains every player taking part in the tiebreaker, in the
+	// order that they called bingo
+	PlayerIDs []uuid.UUID `json:"playerIds"`
+	// Commitment works the same way as GameEventPayloadDrawCommitted's, but
+	// for the tiebreaker's draw. It is empty for rules that don't draw any
+	// balls.
+	Commitment string `json:"commitment,omitempty"`
+}
+
+type GameEventPayloadTiebreakerBallCalled struct {
+	Ball Ball `json:"ball"`
+	// The total number of balls that have been called for the tiebreaker,
+	// including this one
+	CalledCount int `json:"calledCount"`
+	// PlayerID is the player that the ball was drawn for. It is only defined
+	// for TiebreakerRuleHighBall.
+	PlayerID *uuid.UUID `json:"playerId,omitempty"`
+}
+
+type GameEventPayloadTiebreakerResolved struct {
+	Rule      TiebreakerRule `json:"rule"`
+	WinnerIDs []uuid.UUID    `json:"winnerIds"`
+	// Draw reveals the tiebreaker's draw, so that it can be checked against
+	// the commitment from GameEventPayloadTiebreakerStarted. It is nil for
+	// rules that don't draw any balls.
+	Draw *GameEventPayloadDrawRevealed `json:"draw,omitempty"`
+}
+
+type GameEventPayloadHostTransferred struct {
+	PreviousHostID uuid.UUID `json:"previousHostId"`
+	NewHostID      uuid.UUID `json:"newHostId"`
+	NewHostName    string    `json:"newHostName"`
+}
+
+type GameEventPayloadCoHost struct {
+	CoHost
+	Name string `json:"name"`
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultAutoCallInterval is how long the auto-caller waits between balls
+	// when the host does not specify an interval
+	defaultAutoCallInterval = 10 * time.Second
+	// minAutoCallInterval keeps the auto-caller from calling balls faster than
+	// anyone could reasonably daub them
+	minAutoCallInterval = 2 * time.Second
+	// maxAutoCallInterval is the longest the auto-caller can wait between
+	// balls
+	maxAutoCallInterval = 5 * time.Minute
+)
+
+// autoCaller keeps track of a game's auto-caller. Everything except for the
+// timer is part of the game state; the timer only exists to issue the
+// command for the next call once it comes due.
+type autoCaller struct {
+	running  bool
+	interval time.Duration
+	// nextCallAt is when the next ball is due. It is zero whenever no ball is
+	// scheduled.
+	nextCallAt time.Time
+	// stopTimer cancels the timer that was started for timerAt. It is nil if
+	// there is no timer.
+	stopTimer func() bool
+	timerAt   time.Time
+}
+
+// AutoCallerState is the serializable state for a game's auto-caller.
+type AutoCallerState struct {
+	Running    bool          `json:"running"`
+	Interval   time.Duration `json:"interval"`
+	NextCallAt time.Time     `json:"nextCallAt"`
+}
+
+func newAutoCaller() autoCaller {
+	return autoCaller{
+		running:    false,
+		interval:   defaultAutoCallInterval,
+		nextCallAt: time.Time{},
+		stopTimer:  nil,
+		timerAt:    time.Time{},
+	}
+}
+
+func (ac *autoCaller) export() AutoCallerState {
+	return AutoCallerState{
+		Running:    ac.running,
+		Interval:   ac.interval,
+		NextCallAt: ac.nextCallAt,
+	}
+}
+
+// restore replaces the auto-caller's state with a previously-saved state.
+// Games saved before the auto-caller existed won't have an interval, so they
+// fall back to the default.
+func (ac *autoCaller) restore(state AutoCallerState) {
+	ac.running = state.Running
+	ac.interval = state.Interval
+	if ac.interval == 0 {
+		ac.interval = defaultAutoCallInterval
+	}
+	ac.nextCallAt = state.NextCallAt
+}
+
+func (ac *autoCaller) status() bingo.AutoCallerStatus {
+	status := bingo.AutoCallerStatus{
+		Running:    ac.running,
+		IntervalMs: ac.interval.Milliseconds(),
+		NextCallAt: nil,
+	}
+	if !ac.nextCallAt.IsZero() {
+		nextCallAt := ac.nextCallAt
+		status.NextCallAt = &nextCallAt
+	}
+	return status
+}
+
+// stop cancels the timer for the next call, if there is one. It does not
+// change whether the auto-caller is running.
+func (ac *autoCaller) stop() {
+	if ac.stopTimer != nil {
+		ac.stopTimer()
+	}
+	ac.stopTimer = nil
+	ac.timerAt = time.Time{}
+}
+
+// parseAutoCallInterval turns an interval from a command payload into a
+// duration, making sure that it is within the allowed range.
+func parseAutoCallInterval(intervalMs int64) (time.Duration, error) {
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if interval < minAutoCallInterval || interval > maxAutoCallInterval {
+		return 0, fmt.Errorf("interval must be between %v and %v", minAutoCallInterval, maxAutoCallInterval)
+	}
+	return interval, nil
+}
+
+// autoCallerActive indicates whether the auto-caller should be calling balls
+// right now. This method is NOT thread-safe.
+func (g *Game) autoCallerActive() bool {
+	if !g.autoCaller.running || g.callingMode != bingo.CallingModeAutomatic {
+		return false
+	}
+	phase := g.phase.value()
+	return phase == bingo.GamePhaseRoundStart || phase == bingo.GamePhaseCalling
+}
+
+// syncAutoCaller schedules or cancels the auto-caller's next call to match
+// the current game state. It should be called at the end of every change,
+// since almost any change can pause or resume the auto-caller. This method is
+// NOT thread-safe.
+func (g *Game) syncAutoCaller() {
+	ac := &g.autoCaller
+	if !g.autoCallerActive() {
+		ac.nextCallAt = time.Time{}
+	} else if ac.nextCallAt.IsZero() {
+		ac.nextCallAt = g.now().Add(ac.interval)
+	}
+
+	if ac.nextCallAt.Equal(ac.timerAt) {
+		return
+	}
+	ac.stop()
+	if ac.nextCallAt.IsZero() {
+		return
+	}
+
+	// The command loop is what actually calls the ball, so that it gets
+	// recorded (and replayed) just like any other input
+	ac.timerAt = ac.nextCallAt
+	ac.stopTimer = g.clock.AfterFunc(ac.nextCallAt.Sub(g.clock.Now()), func() {
+		_ = g.IssueCommand(bingo.GameCommand{
+			Type:        bingo.GameCommandSystemAutoCallBall,
+			CommanderID: g.systemID,
+			Payload:     nil,
+		})
+	})
+}
+
+// dispatchAutoCallerChanged notifies every subscriber about the auto-caller's
+// new state. This method is NOT thread-safe.
+func (g *Game) dispatchAutoCallerChanged(commanderID uuid.UUID, message string) {
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        g.phase.value(),
+		Type:         bingo.EventTypeAutoCallerChanged,
+		CreatedByID:  commanderID,
+		Message:      message,
+		Payload:      eventPayload(bingo.GameEventPayloadAutoCallerChanged{AutoCaller: g.autoCaller.status()}),
+		RecipientIDs: nil,
+	})
+}
+
+// pauseAutoCallerForRoundEnd stops the auto-caller once a round is over, so
+// that players have time to join before the host starts calling balls for the
+// next round. This method is NOT thread-safe.
+func (g *Game) pauseAutoCallerForRoundEnd(commanderID uuid.UUID) {
+	if !g.autoCaller.running {
+		return
+	}
+	g.autoCaller.running = false
+	g.autoCaller.nextCallAt = time.Time{}
+	g.dispatchAutoCallerChanged(commanderID, "auto-caller paused for the end of the round")
+}
+
+// pauseAutoCallerForEmptyDraw stops the auto-caller once every ball has been
+// called, so that the host knows they need to step in to finish the round.
+// This method is NOT thread-safe.
+func (g *Game) pauseAutoCallerForEmptyDraw(commanderID uuid.UUID) {
+	g.autoCaller.running = false
+	g.autoCaller.nextCallAt = time.Time{}
+	g.dispatchAutoCallerChanged(commanderID, "auto-caller paused because there are no balls left to call")
+}
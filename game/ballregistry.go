@@ -3,6 +3,7 @@ package game
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/Parkreiner/bingo"
@@ -10,25 +11,47 @@ import (
 
 // ballRegistry manages all bingo balls in a round of bingo. The registry can be
 // reused across multiple rounds.
+//
+// Every round's balls are shuffled with a fresh seed from the registry's
+// source, so that the seed can be revealed once the round is over without
+// giving away anything about the rounds after it.
 type ballRegistry struct {
 	called   []bingo.Ball
 	uncalled []bingo.Ball
-	shuffler *shuffler
-	mtx      *sync.Mutex
+	// manualCalls contains every called ball that came from syncManualCall
+	manualCalls []bingo.Ball
+	// drawSeed is the seed used to shuffle the current round's balls. It must
+	// stay secret until the round is over.
+	drawSeed int64
+	// drawOrder is the order that every ball for the current round would be
+	// drawn in, if every ball was drawn automatically
+	drawOrder []bingo.Ball
+	// drawScripted indicates that the current round's order came from a
+	// script instead of a seed
+	drawScripted bool
+	// source produces the seed for each round
+	source RNG
+	// maxBall is the highest ball for the game's variant
+	maxBall int
+	mtx     *sync.Mutex
 }
 
-// newBallRegistry creates a new instance of a bingo ball registry
-func newBallRegistry(rngSeed int64) *ballRegistry {
-	shuffler := newShuffler(rngSeed)
-	uncalled := generateBingoBallsForRange(1, 75)
-	shuffler.shuffleBalls(uncalled)
-
-	return &ballRegistry{
-		called:   nil,
-		uncalled: uncalled,
-		shuffler: shuffler,
-		mtx:      &sync.Mutex{},
+// newBallRegistry creates a new instance of a bingo ball registry, with every
+// ball from 1 to maxBall
+func newBallRegistry(source RNG, maxBall int) *ballRegistry {
+	br := &ballRegistry{
+		called:       nil,
+		uncalled:     nil,
+		manualCalls:  nil,
+		drawSeed:     0,
+		drawOrder:    nil,
+		drawScripted: false,
+		source:       source,
+		maxBall:      maxBall,
+		mtx:          &sync.Mutex{},
 	}
+	br.reset()
+	return br
 }
 
 func (br *ballRegistry) getCalledBalls() []bingo.Ball {
@@ -78,6 +101,7 @@ func (br *ballRegistry) syncManualCall(ball bingo.Ball) error {
 	}
 
 	br.called = append(br.called, br.uncalled[foundIndex])
+	br.manualCalls = append(br.manualCalls, br.uncalled[foundIndex])
 	end := len(br.uncalled) - 1
 	for i := foundIndex; i < end; i++ {
 		br.uncalled[i] = br.uncalled[i+1]
@@ -87,14 +111,130 @@ func (br *ballRegistry) syncManualCall(ball bingo.Ball) error {
 	return nil
 }
 
+// undoManualCall takes back the most recent call, which must have been the
+// given ball, and must have come from syncManualCall. The ball goes back to
+// the same spot in the draw order that it was in before it was called.
+func (br *ballRegistry) undoManualCall(ball bingo.Ball) error {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+
+	if len(br.called) == 0 {
+		return errors.New("no balls have been called yet")
+	}
+	last := br.called[len(br.called)-1]
+	if last != ball {
+		return fmt.Errorf("can only undo the most recent ball (%d), not ball %d", last, ball)
+	}
+	if len(br.manualCalls) == 0 || br.manualCalls[len(br.manualCalls)-1] != ball {
+		return fmt.Errorf("ball %d was drawn automatically and cannot be undone", ball)
+	}
+
+	br.called = br.called[:len(br.called)-1]
+	br.manualCalls = br.manualCalls[:len(br.manualCalls)-1]
+
+	// Every uncalled ball is still in its original relative order, so the
+	// easiest way to put the ball back is to rebuild the uncalled list from
+	// the draw order
+	uncalled := make([]bingo.Ball, 0, len(br.uncalled)+1)
+	for _, b := range br.drawOrder {
+		if !slices.Contains(br.called, b) {
+			uncalled = append(uncalled, b)
+		}
+	}
+	slices.Reverse(uncalled)
+	br.uncalled = uncalled
+	return nil
+}
+
 // reset reverts the state of the bingo ball registry to its initial state.
 // Should be called at the start of each round of bingo.
 func (br *ballRegistry) reset() {
 	br.mtx.Lock()
 	defer br.mtx.Unlock()
 
-	newUncalled := generateBingoBallsForRange(1, 75)
-	br.shuffler.shuffleBalls(newUncalled)
-	br.uncalled = newUncalled
 	br.called = nil
+	br.manualCalls = nil
+	if script, ok := br.source.(drawScript); ok {
+		// Orders written for a different variant can't be drawn from, so
+		// the round gets shuffled normally instead
+		if order, ok := script.nextDrawOrder(); ok && len(order) == br.maxBall {
+			br.drawSeed = 0
+			br.drawOrder = order
+			// Reversing a draw order works the same in both directions
+			br.uncalled = drawOrderFor(order)
+			br.drawScripted = true
+			return
+		}
+	}
+
+	br.drawSeed = br.source.Int63()
+	br.uncalled = shuffleDraw(br.drawSeed, br.maxBall)
+	br.drawOrder = drawOrderFor(br.uncalled)
+	br.drawScripted = false
+}
+
+// commitment produces the commitment for the current round's draw, and
+// whether the draw was scripted. Both are safe to share at any time.
+func (br *ballRegistry) commitment() (string, bool) {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+	return drawCommitment(br.drawSeed, br.drawOrder), br.drawScripted
+}
+
+// reveal produces everything needed to verify the current round's draw. It
+// gives away every ball that hasn't been called yet, so it must only be
+// shared once the round is over.
+func (br *ballRegistry) reveal(round int, variant bingo.VariantID) bingo.GameEventPayloadDrawRevealed {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+	return bingo.GameEventPayloadDrawRevealed{
+		Round:       round,
+		Variant:     variant,
+		Commitment:  drawCommitment(br.drawSeed, br.drawOrder),
+		Seed:        br.drawSeed,
+		Scripted:    br.drawScripted,
+		Order:       append([]bingo.Ball{}, br.drawOrder...),
+		Called:      append([]bingo.Ball{}, br.called...),
+		ManualCalls: append([]bingo.Ball{}, br.manualCalls...),
+	}
+}
+
+// getUncalledBalls returns every ball that hasn't been called yet, in the
+// order they would be drawn in reverse (the last ball is drawn next).
+func (br *ballRegistry) getUncalledBalls() []bingo.Ball {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+	return append([]bingo.Ball{}, br.uncalled...)
+}
+
+// exportDraw copies everything about the current round's balls into a game
+// state.
+func (br *ballRegistry) exportDraw(state *State) {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+	state.CalledBalls = append([]bingo.Ball{}, br.called...)
+	state.UncalledBalls = append([]bingo.Ball{}, br.uncalled...)
+	state.ManualCalls = append([]bingo.Ball{}, br.manualCalls...)
+	state.DrawSeed = br.drawSeed
+	state.DrawOrder = append([]bingo.Ball{}, br.drawOrder...)
+	state.DrawScripted = br.drawScripted
+}
+
+// restore replaces the registry's balls with a previously-saved set, so that a
+// restored game keeps drawing balls in the same order as before, and can still
+// reveal the round's draw once it's over.
+func (br *ballRegistry) restore(state State) {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+	br.called = append([]bingo.Ball(nil), state.CalledBalls...)
+	br.uncalled = append([]bingo.Ball{}, state.UncalledBalls...)
+	br.manualCalls = append([]bingo.Ball(nil), state.ManualCalls...)
+	br.drawSeed = state.DrawSeed
+	br.drawOrder = append([]bingo.Ball(nil), state.DrawOrder...)
+	br.drawScripted = state.DrawScripted
+	// Older checkpoints didn't store the order, but it can always be
+	// re-derived from the seed
+	if len(br.drawOrder) == 0 && !br.drawScripted {
+		br.drawOrder = drawOrderFor(shuffleDraw(br.drawSeed, br.maxBall))
+	}
 }
@@ -5,20 +5,21 @@ import (
 	"fmt"
 	"slices"
 	"sync"
-	"time"
 
 	"github.com/Parkreiner/bingo"
 	"github.com/google/uuid"
 )
 
-// The number of cells that two cards are allowed to have in common to still
-// be called unique from a fun, gameplay standpoint. A unique cell, in this
-// case, refers to not just the numeric value of a cell, but also the
-// position.
+// uniquenessThreshold returns the number of cells that two cards are allowed
+// to have in common to still be called unique from a fun, gameplay
+// standpoint. A unique cell, in this case, refers to not just the numeric
+// value of a cell, but also the position.
 //
-// The number is 2/3 of the total cells of a bingo card (25), excluding the
-// free space.
-const uniquenessThreshold = 16
+// The number is 2/3 of the numbered cells on a card, so it excludes the free
+// space and any blanks (16 for a 75-ball card).
+func uniquenessThreshold(variant bingo.Variant) int {
+	return variant.NumbersPerCard() * 2 / 3
+}
 
 // Indicates the maximum number of attempts that the CardRegistry is able to
 // make at creating a single unique bingo card in one sitting
@@ -46,6 +47,10 @@ type registryBingoCard struct {
 	// Indicates whether the card has been checked out and is in use by an
 	// external system
 	checkedOut bool
+	// stripID is shared by every card in the same strip, and is uuid.Nil for
+	// cards that aren't part of a strip. Cards from a strip are only ever
+	// checked out together.
+	stripID uuid.UUID
 }
 
 // cardGenStatus represents the cardGenStatus of a CardRegistry
@@ -68,35 +73,41 @@ const (
 // Start method (which creates a callback for terminating the registry). Once a
 // registry has been terminated, it cannot be used to generate any additional
 // cards; a new registry will need to be created from scratch.
+//
+// The registry never does any work in the background. Every card is generated
+// (and every returned card is recycled) as part of a method call, so that the
+// cards handed out by a registry only depend on its seed and the order that
+// its methods are called in.
 type cardRegistry struct {
 	status            cardGenStatus
 	statusMtx         *sync.RWMutex
 	registeredEntries []*registryBingoCard
 	entriesMtx        *sync.Mutex
 	generator         *cellsGenerator
-	doneChan          chan struct{}
-	cardReturnChan    chan uuid.UUID
-	surplusTicker     *time.Ticker
+	// threshold is the uniqueness threshold for the registry's variant
+	threshold int
+	// newID produces the ID for every generated card
+	newID func() uuid.UUID
 }
 
-// newCardRegistry produces a new instance of a CardRegistry. It is not ready to
-// use until you call the .Start method on it.
-func newCardRegistry(rngSeed int64) *cardRegistry {
+// newCardRegistry produces a new instance of a CardRegistry, which generates
+// cards for the given variant. It is not ready to use until you call the
+// .Start method on it.
+func newCardRegistry(source RNG, variant bingo.Variant, newID func() uuid.UUID) *cardRegistry {
 	return &cardRegistry{
 		status:            statusIdle,
 		registeredEntries: nil,
 		entriesMtx:        &sync.Mutex{},
 		statusMtx:         &sync.RWMutex{},
-		generator:         newCellsGenerator(rngSeed),
-		doneChan:          make(chan struct{}),
-		cardReturnChan:    make(chan uuid.UUID, 1),
-		surplusTicker:     nil,
+		generator:         newCellsGenerator(source, variant),
+		threshold:         uniquenessThreshold(variant),
+		newID:             newID,
 	}
 }
 
 func (cr *cardRegistry) getStatus() cardGenStatus {
 	cr.statusMtx.RLock()
-	defer cr.statusMtx.Unlock()
+	defer cr.statusMtx.RUnlock()
 	return cr.status
 }
 
@@ -104,8 +115,8 @@ func (cr *cardRegistry) getStatus() cardGenStatus {
 // few extra bingo cards. This helps because of the random nature of the bingo
 // card generation logic. (Needing to generate multiple cards with a threshold
 // for uniqueness can take some time to run.)
-// This method is meant to be a background operation, so we need to be very
-// mindful of how long we keep things locked for each operation.
+// It runs after every checkout and return, so in practice, it only ever needs
+// to generate a single card at a time.
 func (cr *cardRegistry) equalizeEntrySurplus() {
 	// Add any needed surplus
 	availableCards := 0
@@ -114,7 +125,7 @@ func (cr *cardRegistry) equalizeEntrySurplus() {
 
 		cr.entriesMtx.Lock()
 		for _, entry := range cr.registeredEntries {
-			if !entry.checkedOut {
+			if !entry.checkedOut && entry.stripID == uuid.Nil {
 				availableCards++
 			}
 		}
@@ -133,7 +144,9 @@ func (cr *cardRegistry) equalizeEntrySurplus() {
 	}
 	cr.entriesMtx.Lock()
 	defer cr.entriesMtx.Unlock()
-	slices.SortFunc(cr.registeredEntries, func(e1 *registryBingoCard, e2 *registryBingoCard) int {
+	// The sort needs to be stable, so that the order cards get recycled in
+	// stays predictable
+	slices.SortStableFunc(cr.registeredEntries, func(e1 *registryBingoCard, e2 *registryBingoCard) int {
 		if e1.checkedOut && !e2.checkedOut {
 			return -1
 		}
@@ -179,10 +192,9 @@ func (cr *cardRegistry) Start() (func(), error) {
 	}
 
 	cleanup := func() {
-		select {
-		case cr.doneChan <- struct{}{}:
-		default:
-		}
+		cr.statusMtx.Lock()
+		defer cr.statusMtx.Unlock()
+		cr.status = statusTerminated
 	}
 	if status == statusRunning {
 		return cleanup, nil
@@ -193,30 +205,6 @@ func (cr *cardRegistry) Start() (func(), error) {
 	cr.status = statusRunning
 	// Pre-populate the registry to get ready for new players
 	cr.equalizeEntrySurplus()
-	cr.surplusTicker = time.NewTicker(5 * time.Second)
-
-	go func() {
-		defer func() {
-			cr.statusMtx.Lock()
-			defer cr.statusMtx.Unlock()
-			cr.status = statusTerminated
-			close(cr.cardReturnChan)
-			cr.surplusTicker.Stop()
-		}()
-
-	loop:
-		for {
-			select {
-			case <-cr.doneChan:
-				break loop
-			case returnedCardID := <-cr.cardReturnChan:
-				cr.flushReturn(returnedCardID)
-			case <-cr.surplusTicker.C:
-				cr.equalizeEntrySurplus()
-			}
-		}
-	}()
-
 	return cleanup, nil
 }
 
@@ -238,12 +226,16 @@ func (cr *cardRegistry) generateUniqueEntry() (*registryBingoCard, error) {
 	var attempts int
 	for attempts = 1; attempts <= maxGenAttempts; attempts++ {
 		newCells = cr.generator.generateCells()
-		cellConflicts := 0
+		unique := true
 
+		// The threshold applies to each pair of cards, rather than to the
+		// registry as a whole. Otherwise, the conflicts would keep piling up
+		// as the registry grows, and no new card could ever be generated
 		for _, entry := range cr.registeredEntries {
+			cellConflicts := 0
 			for i, row := range entry.cells {
 				for j, cell := range row {
-					// Skip over the free space
+					// Skip over the free space and any blanks
 					if cell == bingo.FreeSpace {
 						continue
 					}
@@ -254,9 +246,13 @@ func (cr *cardRegistry) generateUniqueEntry() (*registryBingoCard, error) {
 					}
 				}
 			}
+			if cellConflicts > cr.threshold {
+				unique = false
+				break
+			}
 		}
 
-		if cellConflicts <= uniquenessThreshold {
+		if unique {
 			break
 		}
 	}
@@ -267,9 +263,10 @@ func (cr *cardRegistry) generateUniqueEntry() (*registryBingoCard, error) {
 
 	newEntry := &registryBingoCard{
 		cells:         newCells,
-		id:            uuid.New(),
+		id:            cr.newID(),
 		prevPlayerIDs: nil,
 		checkedOut:    false,
+		stripID:       uuid.Nil,
 	}
 	cr.registeredEntries = append(cr.registeredEntries, newEntry)
 	return newEntry, nil
@@ -284,7 +281,9 @@ func (cr *cardRegistry) checkOutRecycledEntry(playerID uuid.UUID) *registryBingo
 	defer cr.entriesMtx.Unlock()
 
 	for _, entry := range cr.registeredEntries {
-		foundReusable := !entry.checkedOut && !slices.Contains(entry.prevPlayerIDs, playerID)
+		foundReusable := !entry.checkedOut &&
+			entry.stripID == uuid.Nil &&
+			!slices.Contains(entry.prevPlayerIDs, playerID)
 		if foundReusable {
 			entry.checkedOut = true
 			entry.prevPlayerIDs = append(entry.prevPlayerIDs, playerID)
@@ -308,10 +307,12 @@ func (cr *cardRegistry) CheckOutCard(playerID uuid.UUID) (*bingo.Card, error) {
 		return nil, errors.New("tried generating card for terminated CardGen")
 	}
 
+	// Only the cards that the player is holding right now count, so that
+	// players who return their cards can check out new ones
 	cr.entriesMtx.Lock()
 	playerCards := 0
 	for _, entry := range cr.registeredEntries {
-		if slices.Contains(entry.prevPlayerIDs, playerID) {
+		if entry.isHeldBy(playerID) {
 			playerCards++
 		}
 	}
@@ -321,24 +322,168 @@ func (cr *cardRegistry) CheckOutCard(playerID uuid.UUID) (*bingo.Card, error) {
 		return nil, errors.New("player cannot check out any more cards")
 	}
 
-	var activeEntry *registryBingoCard
+	activeEntry, err := cr.checkOutEntry(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("CheckOutCard: %v", err)
+	}
+	cr.equalizeEntrySurplus()
+	return activeEntry.statefulCard(playerID), nil
+}
+
+// checkOutEntry checks out a single card for a player, recycling an old card
+// if possible, and generating a new one otherwise.
+func (cr *cardRegistry) checkOutEntry(playerID uuid.UUID) (*registryBingoCard, error) {
 	reusable := cr.checkOutRecycledEntry(playerID)
 	if reusable != nil {
-		activeEntry = reusable
-	} else {
-		activeEntry, err := cr.generateUniqueEntry()
-		if err != nil {
-			return nil, fmt.Errorf("CheckOutCard: %v", err)
+		return reusable, nil
+	}
+
+	generated, err := cr.generateUniqueEntry()
+	if err != nil {
+		return nil, err
+	}
+	cr.entriesMtx.Lock()
+	generated.checkedOut = true
+	generated.prevPlayerIDs = append(generated.prevPlayerIDs, playerID)
+	cr.entriesMtx.Unlock()
+	return generated, nil
+}
+
+// isStripCard indicates whether a card is part of a strip.
+func (cr *cardRegistry) isStripCard(cardID uuid.UUID) bool {
+	cr.entriesMtx.Lock()
+	defer cr.entriesMtx.Unlock()
+	index := slices.IndexFunc(cr.registeredEntries, func(entry *registryBingoCard) bool {
+		return entry.id == cardID
+	})
+	return index != -1 && cr.registeredEntries[index].stripID != uuid.Nil
+}
+
+// isHeldBy indicates whether the card is currently checked out by the given
+// player.
+func (e *registryBingoCard) isHeldBy(playerID uuid.UUID) bool {
+	return e.checkedOut && len(e.prevPlayerIDs) != 0 &&
+		e.prevPlayerIDs[len(e.prevPlayerIDs)-1] == playerID
+}
+
+// SwapCards takes back specific cards that a player is holding, and checks out
+// the same number of new cards in their place (in the same order). The swap is
+// all-or-nothing: if any new card can't be checked out, every card checked out
+// so far is taken back, and the player keeps their original cards. Cards from
+// a strip can't be swapped, because the rest of the strip would no longer
+// cover every ball. Errors if the registry is not running.
+func (cr *cardRegistry) SwapCards(playerID uuid.UUID, cardIDs []uuid.UUID) ([]*bingo.Card, error) {
+	status := cr.getStatus()
+	if status == statusIdle {
+		return nil, errors.New("must Start CardGen before swapping cards")
+	}
+	if status == statusTerminated {
+		return nil, errors.New("tried swapping cards with terminated CardGen")
+	}
+
+	cr.entriesMtx.Lock()
+	for _, id := range cardIDs {
+		index := slices.IndexFunc(cr.registeredEntries, func(entry *registryBingoCard) bool {
+			return entry.id == id
+		})
+		if index == -1 || !cr.registeredEntries[index].isHeldBy(playerID) {
+			cr.entriesMtx.Unlock()
+			return nil, fmt.Errorf("player does not have card with ID %q", id)
 		}
+		if cr.registeredEntries[index].stripID != uuid.Nil {
+			cr.entriesMtx.Unlock()
+			return nil, fmt.Errorf("card %q is part of a strip, and can't be swapped on its own", id)
+		}
+	}
+	cr.entriesMtx.Unlock()
 
-		cr.entriesMtx.Lock()
-		activeEntry.checkedOut = true
-		activeEntry.prevPlayerIDs = append(activeEntry.prevPlayerIDs, playerID)
-		cr.entriesMtx.Unlock()
+	swapped, err := cr.checkOutEntries(playerID, len(cardIDs))
+	if err != nil {
+		return nil, fmt.Errorf("SwapCards: %v", err)
+	}
+
+	// The old cards only go back once every new card is guaranteed
+	for _, id := range cardIDs {
+		cr.flushReturn(id)
+	}
+	cr.equalizeEntrySurplus()
+
+	var cards []*bingo.Card
+	for _, entry := range swapped {
+		cards = append(cards, entry.statefulCard(playerID))
+	}
+	return cards, nil
+}
+
+// ReplaceHand takes back every card in a player's hand, and checks out count
+// new cards in their place. Like SwapCards, the replacement is all-or-nothing,
+// but since the whole hand goes back at once, any strips in it are returned
+// in full. Errors if the registry is not running.
+func (cr *cardRegistry) ReplaceHand(playerID uuid.UUID, cardIDs []uuid.UUID, count int) ([]*bingo.Card, error) {
+	status := cr.getStatus()
+	if status == statusIdle {
+		return nil, errors.New("must Start CardGen before replacing hands")
+	}
+	if status == statusTerminated {
+		return nil, errors.New("tried replacing hand with terminated CardGen")
 	}
 
+	cr.entriesMtx.Lock()
+	for _, id := range cardIDs {
+		index := slices.IndexFunc(cr.registeredEntries, func(entry *registryBingoCard) bool {
+			return entry.id == id
+		})
+		if index == -1 || !cr.registeredEntries[index].isHeldBy(playerID) {
+			cr.entriesMtx.Unlock()
+			return nil, fmt.Errorf("player does not have card with ID %q", id)
+		}
+	}
+	cr.entriesMtx.Unlock()
+
+	replacements, err := cr.checkOutEntries(playerID, count)
+	if err != nil {
+		return nil, fmt.Errorf("ReplaceHand: %v", err)
+	}
+	for _, id := range cardIDs {
+		cr.flushReturn(id)
+	}
+	cr.equalizeEntrySurplus()
+
+	var cards []*bingo.Card
+	for _, entry := range replacements {
+		cards = append(cards, entry.statefulCard(playerID))
+	}
+	return cards, nil
+}
+
+// checkOutEntries checks out several cards for a player at once. If any of
+// them can't be checked out, every card checked out so far is taken back, as
+// if none of them were ever checked out.
+func (cr *cardRegistry) checkOutEntries(playerID uuid.UUID, count int) ([]*registryBingoCard, error) {
+	var entries []*registryBingoCard
+	for i := 0; i < count; i++ {
+		entry, err := cr.checkOutEntry(playerID)
+		if err != nil {
+			// The player was never given any of the new cards, so it's safe
+			// to act like they were never checked out
+			cr.entriesMtx.Lock()
+			for _, e := range entries {
+				e.checkedOut = false
+				e.prevPlayerIDs = e.prevPlayerIDs[:len(e.prevPlayerIDs)-1]
+			}
+			cr.entriesMtx.Unlock()
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// statefulCard produces a brand new, undaubed copy of the entry for a player
+// to use.
+func (e *registryBingoCard) statefulCard(playerID uuid.UUID) *bingo.Card {
 	var statefulCells [][]*bingo.Cell
-	for _, row := range activeEntry.cells {
+	for _, row := range e.cells {
 		var statefulRow []*bingo.Cell
 		for _, cell := range row {
 			statefulRow = append(statefulRow, &bingo.Cell{
@@ -351,9 +496,115 @@ func (cr *cardRegistry) CheckOutCard(playerID uuid.UUID) (*bingo.Card, error) {
 
 	return &bingo.Card{
 		PlayerID: playerID,
-		ID:       activeEntry.id,
+		ID:       e.id,
 		Cells:    statefulCells,
-	}, nil
+	}
+}
+
+// CheckOutStrip lets a player check out a full strip of cards, which together
+// contain every ball exactly once. The whole strip is checked out at once, so
+// the player either gets every card in it, or none of them. Strips don't
+// count towards the player's card limit, since they're meant to replace a
+// player's hand; the caller is responsible for returning the player's old
+// cards. Errors if the registry is not running, or if the registry's variant
+// doesn't have strips.
+func (cr *cardRegistry) CheckOutStrip(playerID uuid.UUID) ([]*bingo.Card, error) {
+	status := cr.getStatus()
+	if status == statusIdle {
+		return nil, errors.New("must Start CardGen before calling other methods")
+	}
+	if status == statusTerminated {
+		return nil, errors.New("tried generating strip for terminated CardGen")
+	}
+
+	strip := cr.checkOutRecycledStrip(playerID)
+	if strip == nil {
+		generated, err := cr.generateStripEntries()
+		if err != nil {
+			return nil, fmt.Errorf("CheckOutStrip: %v", err)
+		}
+		strip = generated
+
+		cr.entriesMtx.Lock()
+		for _, entry := range strip {
+			entry.checkedOut = true
+			entry.prevPlayerIDs = append(entry.prevPlayerIDs, playerID)
+		}
+		cr.entriesMtx.Unlock()
+	}
+
+	var cards []*bingo.Card
+	for _, entry := range strip {
+		cards = append(cards, entry.statefulCard(playerID))
+	}
+	return cards, nil
+}
+
+// generateStripEntries creates a new strip, and adds every card in it to the
+// registry. Strips already guarantee that none of their cards share any
+// numbers, so they skip the uniqueness threshold.
+func (cr *cardRegistry) generateStripEntries() ([]*registryBingoCard, error) {
+	cr.entriesMtx.Lock()
+	defer cr.entriesMtx.Unlock()
+
+	cells, err := cr.generator.generateStrip()
+	if err != nil {
+		return nil, err
+	}
+	stripID := cr.newID()
+	var entries []*registryBingoCard
+	for _, c := range cells {
+		entries = append(entries, &registryBingoCard{
+			cells:         c,
+			id:            cr.newID(),
+			prevPlayerIDs: nil,
+			checkedOut:    false,
+			stripID:       stripID,
+		})
+	}
+	cr.registeredEntries = append(cr.registeredEntries, entries...)
+	return entries, nil
+}
+
+// checkOutRecycledStrip tries to check out a complete strip that nobody is
+// using, and that the player has never had before. Returns nil if none could
+// be found.
+func (cr *cardRegistry) checkOutRecycledStrip(playerID uuid.UUID) []*registryBingoCard {
+	cr.entriesMtx.Lock()
+	defer cr.entriesMtx.Unlock()
+
+	strips := map[uuid.UUID][]*registryBingoCard{}
+	var stripIDs []uuid.UUID
+	for _, entry := range cr.registeredEntries {
+		if entry.stripID == uuid.Nil {
+			continue
+		}
+		if _, ok := strips[entry.stripID]; !ok {
+			stripIDs = append(stripIDs, entry.stripID)
+		}
+		strips[entry.stripID] = append(strips[entry.stripID], entry)
+	}
+
+	for _, id := range stripIDs {
+		strip := strips[id]
+		// A strip that has lost any of its cards can't cover every ball
+		// anymore
+		if len(strip) != cr.generator.variant.StripSize {
+			continue
+		}
+		reusable := !slices.ContainsFunc(strip, func(entry *registryBingoCard) bool {
+			return entry.checkedOut || slices.Contains(entry.prevPlayerIDs, playerID)
+		})
+		if !reusable {
+			continue
+		}
+		for _, entry := range strip {
+			entry.checkedOut = true
+			entry.prevPlayerIDs = append(entry.prevPlayerIDs, playerID)
+		}
+		return strip
+	}
+	return nil
 }
 
 // ReturnCard lets a player return a card that they no longer wish to use. Once
@@ -369,6 +620,68 @@ func (cr *cardRegistry) ReturnCard(cardID uuid.UUID) error {
 		return errors.New("tried returning card to terminated CardGen")
 	}
 
-	cr.cardReturnChan <- cardID
+	cr.flushReturn(cardID)
+	cr.equalizeEntrySurplus()
+	return nil
+}
+
+// exportEntries produces a deep copy of every card that the registry has ever
+// generated (and hasn't pruned), so that the registry can be restored later.
+func (cr *cardRegistry) exportEntries() []CardState {
+	cr.entriesMtx.Lock()
+	defer cr.entriesMtx.Unlock()
+
+	cards := []CardState{}
+	for _, entry := range cr.registeredEntries {
+		var cells [][]bingo.Ball
+		for _, row := range entry.cells {
+			cells = append(cells, append([]bingo.Ball{}, row...))
+		}
+		var stripID *uuid.UUID
+		if entry.stripID != uuid.Nil {
+			id := entry.stripID
+			stripID = &id
+		}
+		cards = append(cards, CardState{
+			ID:            entry.id,
+			Cells:         cells,
+			PrevPlayerIDs: append([]uuid.UUID{}, entry.prevPlayerIDs...),
+			StripID:       stripID,
+		})
+	}
+	return cards
+}
+
+// restoreEntries replaces every entry in the registry with previously-exported
+// cards. Exports don't include which cards are checked out, so the caller
+// needs to provide the IDs of every card that is currently in a player's hand.
+// Must be called before the registry is started.
+func (cr *cardRegistry) restoreEntries(cards []CardState, checkedOutIDs []uuid.UUID) error {
+	if cr.getStatus() != statusIdle {
+		return errors.New("cannot restore entries for a registry that has already started")
+	}
+
+	cr.entriesMtx.Lock()
+	defer cr.entriesMtx.Unlock()
+
+	var entries []*registryBingoCard
+	for _, card := range cards {
+		var cells [][]bingo.Ball
+		for _, row := range card.Cells {
+			cells = append(cells, append([]bingo.Ball{}, row...))
+		}
+		stripID := uuid.Nil
+		if card.StripID != nil {
+			stripID = *card.StripID
+		}
+		entries = append(entries, &registryBingoCard{
+			cells:         cells,
+			id:            card.ID,
+			prevPlayerIDs: append([]uuid.UUID{}, card.PrevPlayerIDs...),
+			checkedOut:    slices.Contains(checkedOutIDs, card.ID),
+			stripID:       stripID,
+		})
+	}
+	cr.registeredEntries = entries
 	return nil
 }
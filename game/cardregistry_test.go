@@ -0,0 +1,203 @@
+package game
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// newTestCardRegistry starts a 90-ball registry that is stopped once the test
+// finishes.
+func newTestCardRegistry(t *testing.T) *cardRegistry {
+	t.Helper()
+
+	registry := newCardRegistry(NewSeededRNG(1), bingo.Variant90Ball, uuid.New)
+	stop, err := registry.Start()
+	if err != nil {
+		t.Fatalf("unable to start registry: %v", err)
+	}
+	t.Cleanup(stop)
+	return registry
+}
+
+func cardIDs(cards []*bingo.Card) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, c := range cards {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestCheckOutStrip(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Returned strip is given out whole", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newTestCardRegistry(t)
+		first := uuid.New()
+		strip, err := registry.CheckOutStrip(first)
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		for _, c := range strip {
+			if err := registry.ReturnCard(c.ID); err != nil {
+				t.Fatalf("unable to return card: %v", err)
+			}
+		}
+
+		reused, err := registry.CheckOutStrip(uuid.New())
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		if !slices.Equal(cardIDs(reused), cardIDs(strip)) {
+			t.Fatal("expected the returned strip to be reused as a whole")
+		}
+	})
+
+	t.Run("Player never gets a strip they returned", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newTestCardRegistry(t)
+		playerID := uuid.New()
+		strip, err := registry.CheckOutStrip(playerID)
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		for _, c := range strip {
+			if err := registry.ReturnCard(c.ID); err != nil {
+				t.Fatalf("unable to return card: %v", err)
+			}
+		}
+
+		again, err := registry.CheckOutStrip(playerID)
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		for _, id := range cardIDs(again) {
+			if slices.Contains(cardIDs(strip), id) {
+				t.Fatal("player got back a card from a strip they returned")
+			}
+		}
+	})
+
+	t.Run("Partially returned strip is never split up", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newTestCardRegistry(t)
+		strip, err := registry.CheckOutStrip(uuid.New())
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		if err := registry.ReturnCard(strip[0].ID); err != nil {
+			t.Fatalf("unable to return card: %v", err)
+		}
+
+		otherStrip, err := registry.CheckOutStrip(uuid.New())
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		card, err := registry.CheckOutCard(uuid.New())
+		if err != nil {
+			t.Fatalf("unable to check out card: %v", err)
+		}
+		for _, id := range append(cardIDs(otherStrip), card.ID) {
+			if slices.Contains(cardIDs(strip), id) {
+				t.Fatal("a card from a strip that's still in use was given out")
+			}
+		}
+	})
+
+	t.Run("Variant without strips", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newCardRegistry(NewSeededRNG(1), bingo.Variant75Ball, uuid.New)
+		stop, err := registry.Start()
+		if err != nil {
+			t.Fatalf("unable to start registry: %v", err)
+		}
+		defer stop()
+		if _, err := registry.CheckOutStrip(uuid.New()); err == nil {
+			t.Fatal("75-ball bingo should not have strips")
+		}
+	})
+}
+
+func TestSwapCards(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Swapped cards keep their place in the hand", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newTestCardRegistry(t)
+		playerID := uuid.New()
+		var hand []*bingo.Card
+		for range 3 {
+			card, err := registry.CheckOutCard(playerID)
+			if err != nil {
+				t.Fatalf("unable to check out card: %v", err)
+			}
+			hand = append(hand, card)
+		}
+
+		swapped, err := registry.SwapCards(playerID, []uuid.UUID{hand[2].ID, hand[0].ID})
+		if err != nil {
+			t.Fatalf("unable to swap cards: %v", err)
+		}
+		if len(swapped) != 2 {
+			t.Fatalf("expected 2 new cards, got %d", len(swapped))
+		}
+		for _, c := range swapped {
+			if slices.Contains(cardIDs(hand), c.ID) {
+				t.Fatal("player got back a card they already had")
+			}
+		}
+		if _, err := registry.SwapCards(playerID, []uuid.UUID{hand[0].ID}); err == nil {
+			t.Fatal("a card that was swapped out should not be swappable again")
+		}
+		if _, err := registry.SwapCards(playerID, []uuid.UUID{hand[1].ID}); err != nil {
+			t.Fatalf("the card that wasn't swapped should still be held: %v", err)
+		}
+	})
+
+	t.Run("Card held by another player", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newTestCardRegistry(t)
+		card, err := registry.CheckOutCard(uuid.New())
+		if err != nil {
+			t.Fatalf("unable to check out card: %v", err)
+		}
+		if _, err := registry.SwapCards(uuid.New(), []uuid.UUID{card.ID}); err == nil {
+			t.Fatal("players should not be able to swap cards they don't have")
+		}
+	})
+
+	t.Run("Cards from a strip can't be swapped", func(t *testing.T) {
+		t.Parallel()
+
+		registry := newTestCardRegistry(t)
+		playerID := uuid.New()
+		strip, err := registry.CheckOutStrip(playerID)
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		if _, err := registry.SwapCards(playerID, []uuid.UUID{strip[3].ID}); err == nil {
+			t.Fatal("swapping a single card out of a strip should fail")
+		}
+
+		// The refused swap shouldn't have taken anything back, so the strip
+		// can't be handed out to anybody else
+		other, err := registry.CheckOutStrip(uuid.New())
+		if err != nil {
+			t.Fatalf("unable to check out strip: %v", err)
+		}
+		for _, id := range cardIDs(other) {
+			if slices.Contains(cardIDs(strip), id) {
+				t.Fatal("a card from a strip that's still in use was given out")
+			}
+		}
+	})
+}
@@ -1,58 +1,250 @@
 package game
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
 	"github.com/Parkreiner/bingo"
 )
 
 type cellsGenerator struct {
 	shuffler *shuffler
+	rng      RNG
+	variant  bingo.Variant
 }
 
-func newCellsGenerator(seed int64) *cellsGenerator {
+func newCellsGenerator(source RNG, variant bingo.Variant) *cellsGenerator {
 	return &cellsGenerator{
-		shuffler: newShuffler(seed),
+		shuffler: newShuffler(source),
+		rng:      source,
+		variant:  variant,
 	}
 }
 
+// generateCells creates the cells for a single card, laid out the way the
+// generator's variant describes.
 func (cg *cellsGenerator) generateCells() [][]bingo.Ball {
+	if cg.variant.NumbersPerRow == 0 || cg.variant.NumbersPerRow == cg.variant.Cols() {
+		return cg.generateFullCells()
+	}
+	return cg.generateSparseCells()
+}
+
+// generateFullCells creates a card where every cell has a number (aside from
+// the free space, if the variant has one).
+func (cg *cellsGenerator) generateFullCells() [][]bingo.Ball {
 	// Generate all cells. There might be a way to do this that doesn't involve
-	// generating 10 extra cells per column, but the shuffling approach
+	// generating extra cells for every column, but the shuffling approach
 	// guarantees that we cannot ever have duplicate cells in the same column
-	allBCells := generateBingoBallsForRange(1, 15)
-	allICells := generateBingoBallsForRange(16, 30)
-	allNCells := generateBingoBallsForRange(31, 45)
-	allGCells := generateBingoBallsForRange(46, 60)
-	allOCells := generateBingoBallsForRange(61, 75)
-
-	cg.shuffler.shuffleBalls(allBCells)
-	cg.shuffler.shuffleBalls(allICells)
-	cg.shuffler.shuffleBalls(allNCells)
-	cg.shuffler.shuffleBalls(allGCells)
-	cg.shuffler.shuffleBalls(allOCells)
-
-	aggregateCells := [][]bingo.Ball{
-		allBCells[0:5],
-		allICells[0:5],
-		allNCells[0:5],
-		allGCells[0:5],
-		allOCells[0:5],
-	}
-	aggregateCells[2][2] = bingo.FreeSpace
+	var columns [][]bingo.Ball
+	for _, r := range cg.variant.Columns {
+		columns = append(columns, generateBingoBallsForRange(int(r.Min), int(r.Max)))
+	}
+	for _, col := range columns {
+		cg.shuffler.shuffleBalls(col)
+	}
 
 	// Rotate the card so that it looks like a proper bingo card, and so that
 	// fewer data transformations need to be done per render in the frontend
-	for i := 0; i < len(aggregateCells); i++ {
-		row1 := aggregateCells[i]
-		for j := i; j < len(row1); j++ {
-			cell1 := row1[j]
-			row2 := aggregateCells[j]
-			cell2 := row2[i]
-			row2[i] = cell1
-			row1[j] = cell2
+	rows := make([][]bingo.Ball, cg.variant.Rows)
+	for i := range rows {
+		rows[i] = make([]bingo.Ball, len(columns))
+		for j, col := range columns {
+			rows[i][j] = col[i]
+		}
+	}
+	if cg.variant.FreeSpace {
+		rows[cg.variant.Rows/2][cg.variant.Cols()/2] = bingo.FreeSpace
+	}
+	return rows
+}
+
+// generateSparseCells creates a card where each row only has some of its cells
+// filled in (like a 90-ball ticket). Every column gets at least one number,
+// and the numbers in each column go from smallest to largest, top to bottom.
+// Every other cell is left blank.
+func (cg *cellsGenerator) generateSparseCells() [][]bingo.Ball {
+	v := cg.variant
+	cols := v.Cols()
+
+	// Decide how many numbers each column gets. Every column needs at least
+	// one, and the rest are spread out at random
+	counts := make([]int, cols)
+	for i := range counts {
+		counts[i] = 1
+	}
+	for remaining := v.NumbersPerCard() - cols; remaining > 0; remaining-- {
+		var open []int
+		for i, count := range counts {
+			if count < v.Rows && count < int(v.Columns[i].Max-v.Columns[i].Min)+1 {
+				open = append(open, i)
+			}
 		}
+		counts[open[cg.rng.Intn(len(open))]]++
 	}
 
-	return aggregateCells
+	rowsForCol := cg.assignRows(counts)
+	numbers := make([][]bingo.Ball, cols)
+	for col, r := range v.Columns {
+		balls := generateBingoBallsForRange(int(r.Min), int(r.Max))
+		cg.shuffler.shuffleBalls(balls)
+		numbers[col] = balls[:counts[col]]
+	}
+	return cg.layOutSparseCells(rowsForCol, numbers)
+}
+
+// generateStrip creates a full strip of sparse cards (like a strip of 90-ball
+// tickets), where every ball for the variant appears on exactly one card.
+// Each card follows the same rules as the ones from generateSparseCells.
+func (cg *cellsGenerator) generateStrip() ([][][]bingo.Ball, error) {
+	v := cg.variant
+	if v.StripSize == 0 {
+		return nil, fmt.Errorf("%s bingo does not have strips", v.Name)
+	}
+
+	var counts [][]int
+	for attempts := 1; counts == nil; attempts++ {
+		if attempts > maxGenAttempts {
+			return nil, errors.New("ran out of attempts to generate new strip")
+		}
+		counts = cg.stripColumnCounts()
+	}
+
+	// Deal each column's numbers out between the cards
+	numbers := make([][][]bingo.Ball, v.StripSize)
+	for i := range numbers {
+		numbers[i] = make([][]bingo.Ball, v.Cols())
+	}
+	for col, r := range v.Columns {
+		balls := generateBingoBallsForRange(int(r.Min), int(r.Max))
+		cg.shuffler.shuffleBalls(balls)
+		for card := range numbers {
+			numbers[card][col] = balls[:counts[card][col]]
+			balls = balls[counts[card][col]:]
+		}
+	}
+
+	var strip [][][]bingo.Ball
+	for card := range numbers {
+		rowsForCol := cg.assignRows(counts[card])
+		strip = append(strip, cg.layOutSparseCells(rowsForCol, numbers[card]))
+	}
+	return strip, nil
+}
+
+// stripColumnCounts decides how many numbers each column of each card in a
+// strip gets. Every column on every card gets at least one number, and every
+// ball in a column's range ends up on exactly one card. Returns nil if the
+// numbers couldn't be spread out, in which case the caller should try again.
+func (cg *cellsGenerator) stripColumnCounts() [][]int {
+	v := cg.variant
+	counts := make([][]int, v.StripSize)
+	needed := make([]int, v.StripSize)
+	for card := range counts {
+		counts[card] = make([]int, v.Cols())
+		for col := range counts[card] {
+			counts[card][col] = 1
+		}
+		needed[card] = v.NumbersPerCard() - v.Cols()
+	}
+
+	// Every ball that isn't covered by the one-per-column minimum still needs
+	// a card. Going through them in a random order keeps the strips varied
+	var extras []int
+	for col, r := range v.Columns {
+		for n := int(r.Max-r.Min) + 1 - v.StripSize; n > 0; n-- {
+			extras = append(extras, col)
+		}
+	}
+	for i := len(extras) - 1; i >= 1; i-- {
+		j := cg.rng.Intn(i + 1)
+		extras[i], extras[j] = extras[j], extras[i]
+	}
+
+	for _, col := range extras {
+		// Giving each ball to whichever card needs the most numbers keeps any
+		// card from being stuck needing numbers from a column it has filled
+		var candidates []int
+		mostNeeded := 0
+		for card, count := range counts {
+			if needed[card] == 0 || count[col] >= v.Rows {
+				continue
+			}
+			if needed[card] > mostNeeded {
+				mostNeeded = needed[card]
+				candidates = nil
+			}
+			if needed[card] == mostNeeded {
+				candidates = append(candidates, card)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		card := candidates[cg.rng.Intn(len(candidates))]
+		counts[card][col]++
+		needed[card]--
+	}
+	return counts
+}
+
+// assignRows decides which rows each column's numbers go in, given how many
+// numbers each column has. Filling the busiest columns first, and always using
+// the rows with the most space left, guarantees that every row ends up with
+// exactly NumbersPerRow numbers.
+func (cg *cellsGenerator) assignRows(counts []int) [][]int {
+	v := cg.variant
+	capacity := make([]int, v.Rows)
+	for i := range capacity {
+		capacity[i] = v.NumbersPerRow
+	}
+	colOrder := make([]int, len(counts))
+	for i := range colOrder {
+		colOrder[i] = i
+	}
+	slices.SortStableFunc(colOrder, func(a int, b int) int {
+		return counts[b] - counts[a]
+	})
+	rowsForCol := make([][]int, len(counts))
+	for _, col := range colOrder {
+		candidates := make([]int, v.Rows)
+		for i := range candidates {
+			candidates[i] = i
+		}
+		// Shuffling first breaks any ties between rows at random
+		for i := len(candidates) - 1; i >= 1; i-- {
+			j := cg.rng.Intn(i + 1)
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+		slices.SortStableFunc(candidates, func(a int, b int) int {
+			return capacity[b] - capacity[a]
+		})
+		chosen := candidates[:counts[col]]
+		for _, row := range chosen {
+			capacity[row]--
+		}
+		slices.Sort(chosen)
+		rowsForCol[col] = chosen
+	}
+	return rowsForCol
+}
+
+// layOutSparseCells places each column's numbers in the rows picked by
+// assignRows, from smallest to largest. Every other cell is left blank.
+func (cg *cellsGenerator) layOutSparseCells(rowsForCol [][]int, numbers [][]bingo.Ball) [][]bingo.Ball {
+	rows := make([][]bingo.Ball, cg.variant.Rows)
+	for i := range rows {
+		rows[i] = make([]bingo.Ball, cg.variant.Cols())
+	}
+	for col, picked := range numbers {
+		slices.Sort(picked)
+		for i, row := range rowsForCol[col] {
+			rows[row][col] = picked[i]
+		}
+	}
+	return rows
 }
 
 // generateBingoBallsForRange creates a range of bingo balls for a given
@@ -62,7 +254,7 @@ func generateBingoBallsForRange(start int, end int) []bingo.Ball {
 	var cells []bingo.Ball
 	inputIsInvalid := end <= start ||
 		start <= 0 || end <= 0 ||
-		start > bingo.MaxBallValue || end > bingo.MaxBallValue
+		start > math.MaxUint8 || end > math.MaxUint8
 	if inputIsInvalid {
 		return cells
 	}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+)
+
+func TestGenerateStrip(t *testing.T) {
+	t.Parallel()
+
+	v := bingo.Variant90Ball
+	for seed := int64(1); seed <= 500; seed++ {
+		strip, err := newCellsGenerator(NewSeededRNG(seed), v).generateStrip()
+		if err != nil {
+			t.Fatalf("seed %d: unable to generate strip: %v", seed, err)
+		}
+		if len(strip) != v.StripSize {
+			t.Fatalf("seed %d: expected %d tickets, got %d", seed, v.StripSize, len(strip))
+		}
+
+		seen := map[bingo.Ball]int{}
+		for i, ticket := range strip {
+			if len(ticket) != v.Rows {
+				t.Fatalf("seed %d, ticket %d: expected %d rows, got %d", seed, i, v.Rows, len(ticket))
+			}
+
+			total := 0
+			for row, cells := range ticket {
+				if len(cells) != v.Cols() {
+					t.Fatalf("seed %d, ticket %d: expected %d columns in row %d, got %d", seed, i, v.Cols(), row, len(cells))
+				}
+				inRow := 0
+				for _, b := range cells {
+					if b != bingo.FreeSpace {
+						inRow++
+						seen[b]++
+					}
+				}
+				if inRow != v.NumbersPerRow {
+					t.Fatalf("seed %d, ticket %d: expected %d numbers in row %d, got %d", seed, i, v.NumbersPerRow, row, inRow)
+				}
+				total += inRow
+			}
+			if total != v.NumbersPerCard() {
+				t.Fatalf("seed %d, ticket %d: expected %d numbers, got %d", seed, i, v.NumbersPerCard(), total)
+			}
+
+			for col, r := range v.Columns {
+				var prev bingo.Ball
+				inCol := 0
+				for row := range ticket {
+					b := ticket[row][col]
+					if b == bingo.FreeSpace {
+						continue
+					}
+					if b < r.Min || b > r.Max {
+						t.Fatalf("seed %d, ticket %d: ball %d is outside of column %d's range", seed, i, b, col)
+					}
+					if b <= prev {
+						t.Fatalf("seed %d, ticket %d: column %d does not go from smallest to largest", seed, i, col)
+					}
+					prev = b
+					inCol++
+				}
+				if inCol == 0 {
+					t.Fatalf("seed %d, ticket %d: column %d is empty", seed, i, col)
+				}
+			}
+		}
+
+		for b := bingo.Ball(1); int(b) <= v.MaxBall; b++ {
+			if seen[b] != 1 {
+				t.Fatalf("seed %d: expected ball %d to appear exactly once, got %d", seed, b, seen[b])
+			}
+		}
+		if len(seen) != v.MaxBall {
+			t.Fatalf("seed %d: expected %d different balls, got %d", seed, v.MaxBall, len(seen))
+		}
+	}
+}
+
+func TestGenerateStripWithoutStrips(t *testing.T) {
+	t.Parallel()
+
+	if _, err := newCellsGenerator(NewSeededRNG(1), bingo.Variant75Ball).generateStrip(); err == nil {
+		t.Fatal("75-ball bingo should not be able to generate strips")
+	}
+}
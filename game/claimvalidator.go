@@ -0,0 +1,136 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// cardValidation describes whether a single card is able to back up a bingo
+// claim
+type cardValidation struct {
+	cardID uuid.UUID
+	// winningCells contains every cell that is part of the first pattern mask
+	// satisfied by the card. It will be empty if the card does not satisfy the
+	// pattern.
+	winningCells []bingo.CellPosition
+	// invalidDaubs contains every cell that was daubed, even though its number
+	// has not been called yet. Any invalid daubs disqualify the entire card.
+	invalidDaubs []bingo.CellPosition
+}
+
+func (cv cardValidation) valid() bool {
+	return len(cv.winningCells) != 0 && len(cv.invalidDaubs) == 0
+}
+
+// claimValidation describes whether a player's bingo claim is backed up by
+// their cards. The validation is purely advisory; it is always up to the host
+// to make the final call.
+type claimValidation struct {
+	playerID uuid.UUID
+	cards    []cardValidation
+}
+
+// valid indicates whether any of the player's cards satisfy the win pattern
+func (cv claimValidation) valid() bool {
+	for _, c := range cv.cards {
+		if c.valid() {
+			return true
+		}
+	}
+	return false
+}
+
+// export converts the validation into a form that can be sent to the host
+func (cv claimValidation) export() bingo.ClaimValidation {
+	exported := bingo.ClaimValidation{
+		PlayerID: cv.playerID,
+		Valid:    cv.valid(),
+		Cards:    []bingo.CardValidation{},
+	}
+	for _, c := range cv.cards {
+		converted := bingo.CardValidation{
+			CardID:       c.cardID,
+			WinningCells: c.winningCells,
+			InvalidDaubs: c.invalidDaubs,
+		}
+		if converted.WinningCells == nil {
+			converted.WinningCells = []bingo.CellPosition{}
+		}
+		if converted.InvalidDaubs == nil {
+			converted.InvalidDaubs = []bingo.CellPosition{}
+		}
+		exported.Cards = append(exported.Cards, converted)
+	}
+	return exported
+}
+
+// summary produces a human-readable description of the validation result,
+// meant to help the host decide whether to accept a bingo claim.
+func (cv claimValidation) summary() string {
+	var lines []string
+	for _, c := range cv.cards {
+		if len(c.invalidDaubs) != 0 {
+			lines = append(lines, fmt.Sprintf("card %s has daubs on uncalled numbers at %s", c.cardID, joinPositions(c.invalidDaubs)))
+			continue
+		}
+		if len(c.winningCells) != 0 {
+			lines = append(lines, fmt.Sprintf("card %s satisfies the pattern at %s", c.cardID, joinPositions(c.winningCells)))
+		}
+	}
+
+	verdict := "no winning cards found"
+	if cv.valid() {
+		verdict = "claim appears valid"
+	}
+	if len(lines) == 0 {
+		return verdict
+	}
+	return fmt.Sprintf("%s: %s", verdict, strings.Join(lines, "; "))
+}
+
+// validateClaim checks every card in a player's hand against the active win
+// pattern and the balls that have been called so far.
+func validateClaim(player *bingo.Player, called []bingo.Ball, pattern bingo.WinPattern) claimValidation {
+	result := claimValidation{playerID: player.ID}
+	for _, card := range player.Cards {
+		result.cards = append(result.cards, validateCard(card, called, pattern))
+	}
+	return result
+}
+
+// validateCard checks whether a single card satisfies a win pattern, using only
+// daubs that correspond to balls that have actually been called. The free
+// space always counts towards a pattern, even if it hasn't been daubed.
+func validateCard(card *bingo.Card, called []bingo.Ball, pattern bingo.WinPattern) cardValidation {
+	result := cardValidation{cardID: card.ID}
+
+	for i, row := range card.Cells {
+		for j, cell := range row {
+			if cell.Daubed && cell.Number != bingo.FreeSpace && !slices.Contains(called, cell.Number) {
+				result.invalidDaubs = append(result.invalidDaubs, bingo.CellPosition{Row: i, Col: j})
+			}
+		}
+	}
+
+	isMarked := func(pos bingo.CellPosition) bool {
+		cell := card.Cells[pos.Row][pos.Col]
+		return cell.Number == bingo.FreeSpace || cell.Daubed
+	}
+	if positions, ok := pattern.Match(isMarked); ok {
+		result.winningCells = positions
+	}
+
+	return result
+}
+
+func joinPositions(positions []bingo.CellPosition) string {
+	var strs []string
+	for _, p := range positions {
+		strs = append(strs, fmt.Sprintf("(row %d, col %d)", p.Row+1, p.Col+1))
+	}
+	return strings.Join(strs, ", ")
+}
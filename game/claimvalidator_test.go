@@ -0,0 +1,332 @@
+package game
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// newTestCard builds a 75-ball card where every column counts up from the
+// bottom of its range (B1-B5, I16-I20, etc.), with the free space in the
+// middle. Every listed ball is daubed, whether it has been called or not.
+func newTestCard(daubed ...bingo.Ball) *bingo.Card {
+	card := &bingo.Card{
+		ID:       uuid.New(),
+		PlayerID: uuid.Nil,
+	}
+	for row := 0; row < 5; row++ {
+		var cells []*bingo.Cell
+		for col := 0; col < 5; col++ {
+			number := bingo.Ball(col*15 + row + 1)
+			if row == 2 && col == 2 {
+				number = bingo.FreeSpace
+			}
+			cells = append(cells, &bingo.Cell{
+				Number: number,
+				Daubed: slices.Contains(daubed, number),
+			})
+		}
+		card.Cells = append(card.Cells, cells)
+	}
+	return card
+}
+
+func TestValidateCard(t *testing.T) {
+	t.Parallel()
+
+	topRow := []bingo.Ball{1, 16, 31, 46, 61}
+	middleRow := []bingo.Ball{3, 18, 48, 63}
+
+	testCases := []struct {
+		name   string
+		daubed []bingo.Ball
+		called []bingo.Ball
+		// pattern falls back to any line if it is empty
+		pattern      bingo.WinPattern
+		valid        bool
+		winningCells []bingo.CellPosition
+		invalidDaubs []bingo.CellPosition
+	}{
+		{
+			name:   "Nothing daubed",
+			daubed: nil,
+			called: topRow,
+			valid:  false,
+		},
+		{
+			name:   "Complete row",
+			daubed: topRow,
+			called: topRow,
+			valid:  true,
+			winningCells: []bingo.CellPosition{
+				{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 0, Col: 4},
+			},
+		},
+		{
+			name:   "Called balls that were never daubed",
+			daubed: []bingo.Ball{1, 16, 31, 46},
+			called: topRow,
+			valid:  false,
+		},
+		{
+			name:   "Free space completes a line without being daubed",
+			daubed: middleRow,
+			called: middleRow,
+			valid:  true,
+			winningCells: []bingo.CellPosition{
+				{Row: 2, Col: 0}, {Row: 2, Col: 1}, {Row: 2, Col: 2}, {Row: 2, Col: 3}, {Row: 2, Col: 4},
+			},
+		},
+		{
+			name:   "Daubing the free space isn't an invalid daub",
+			daubed: append([]bingo.Ball{bingo.FreeSpace}, middleRow...),
+			called: middleRow,
+			valid:  true,
+			winningCells: []bingo.CellPosition{
+				{Row: 2, Col: 0}, {Row: 2, Col: 1}, {Row: 2, Col: 2}, {Row: 2, Col: 3}, {Row: 2, Col: 4},
+			},
+		},
+		{
+			name:   "Complete column",
+			daubed: []bingo.Ball{46, 47, 48, 49, 50},
+			called: []bingo.Ball{50, 49, 48, 47, 46},
+			valid:  true,
+			winningCells: []bingo.CellPosition{
+				{Row: 0, Col: 3}, {Row: 1, Col: 3}, {Row: 2, Col: 3}, {Row: 3, Col: 3}, {Row: 4, Col: 3},
+			},
+		},
+		{
+			name:   "Complete diagonal",
+			daubed: []bingo.Ball{1, 17, 49, 65},
+			called: []bingo.Ball{1, 17, 49, 65},
+			valid:  true,
+			winningCells: []bingo.CellPosition{
+				{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 2, Col: 2}, {Row: 3, Col: 3}, {Row: 4, Col: 4},
+			},
+		},
+		{
+			name:   "Line made of uncalled daubs",
+			daubed: topRow,
+			called: []bingo.Ball{1, 16, 31, 46},
+			valid:  false,
+			winningCells: []bingo.CellPosition{
+				{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 0, Col: 4},
+			},
+			invalidDaubs: []bingo.CellPosition{{Row: 0, Col: 4}},
+		},
+		{
+			name:   "Uncalled daub away from a valid line disqualifies the card",
+			daubed: append([]bingo.Ball{65}, topRow...),
+			called: topRow,
+			valid:  false,
+			winningCells: []bingo.CellPosition{
+				{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 0, Col: 4},
+			},
+			invalidDaubs: []bingo.CellPosition{{Row: 4, Col: 4}},
+		},
+		{
+			name:    "Four corners",
+			daubed:  []bingo.Ball{1, 5, 61, 65},
+			called:  []bingo.Ball{1, 5, 61, 65},
+			pattern: bingo.WinPatternFourCorners,
+			valid:   true,
+			winningCells: []bingo.CellPosition{
+				{Row: 0, Col: 0}, {Row: 0, Col: 4}, {Row: 4, Col: 0}, {Row: 4, Col: 4},
+			},
+		},
+		{
+			name:    "Line doesn't satisfy four corners",
+			daubed:  topRow,
+			called:  topRow,
+			pattern: bingo.WinPatternFourCorners,
+			valid:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pattern := tc.pattern
+			if pattern.ID == "" {
+				pattern = bingo.WinPatternAnyLine
+			}
+			result := validateCard(newTestCard(tc.daubed...), tc.called, pattern)
+			if result.valid() != tc.valid {
+				t.Fatalf("expected valid to be %v, got %v", tc.valid, result.valid())
+			}
+			if !slices.Equal(result.winningCells, tc.winningCells) {
+				t.Fatalf("expected winning cells %v, got %v", tc.winningCells, result.winningCells)
+			}
+			if !slices.Equal(result.invalidDaubs, tc.invalidDaubs) {
+				t.Fatalf("expected invalid daubs %v, got %v", tc.invalidDaubs, result.invalidDaubs)
+			}
+		})
+	}
+}
+
+func TestValidateClaim(t *testing.T) {
+	t.Parallel()
+
+	topRow := []bingo.Ball{1, 16, 31, 46, 61}
+	disqualified := newTestCard(append([]bingo.Ball{65}, topRow...)...)
+	winning := newTestCard(topRow...)
+	empty := newTestCard()
+
+	testCases := []struct {
+		name  string
+		cards []*bingo.Card
+		valid bool
+	}{
+		{
+			name:  "No cards",
+			cards: nil,
+			valid: false,
+		},
+		{
+			name:  "One winning card",
+			cards: []*bingo.Card{empty, winning},
+			valid: true,
+		},
+		{
+			name:  "Only a disqualified card",
+			cards: []*bingo.Card{disqualified, empty},
+			valid: false,
+		},
+		{
+			name:  "Disqualified card doesn't affect the other cards",
+			cards: []*bingo.Card{disqualified, winning},
+			valid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			player := &bingo.Player{ID: uuid.New(), Cards: tc.cards}
+			result := validateClaim(player, topRow, bingo.WinPatternAnyLine)
+			if result.valid() != tc.valid {
+				t.Fatalf("expected valid to be %v, got %v (%s)", tc.valid, result.valid(), result.summary())
+			}
+			if len(result.cards) != len(tc.cards) {
+				t.Fatalf("expected %d card results, got %d", len(tc.cards), len(result.cards))
+			}
+			for i, c := range tc.cards {
+				if result.cards[i].cardID != c.ID {
+					t.Fatalf("card result %d is for the wrong card", i)
+				}
+			}
+		})
+	}
+}
+
+// newTestTicket builds a 90-ball ticket with five numbers in each row, where
+// every number is its column's tens digit followed by the row it's in (1, 2,
+// 3). Every other cell is blank. Every listed ball is daubed, whether it has
+// been called or not.
+func newTestTicket(daubed ...bingo.Ball) *bingo.Card {
+	layout := []string{
+		"X.X.X.X.X",
+		".X.X.X.XX",
+		"X.XX.X.X.",
+	}
+	card := &bingo.Card{
+		ID:       uuid.New(),
+		PlayerID: uuid.Nil,
+	}
+	for row, line := range layout {
+		var cells []*bingo.Cell
+		for col, c := range line {
+			number := bingo.FreeSpace
+			if c == 'X' {
+				number = bingo.Ball(col*10 + row + 1)
+			}
+			cells = append(cells, &bingo.Cell{
+				Number: number,
+				Daubed: slices.Contains(daubed, number),
+			})
+		}
+		card.Cells = append(card.Cells, cells)
+	}
+	return card
+}
+
+func TestValidateTicket(t *testing.T) {
+	t.Parallel()
+
+	topRow := []bingo.Ball{1, 21, 41, 61, 81}
+	topRowCells := []bingo.CellPosition{
+		{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 0, Col: 4},
+		{Row: 0, Col: 5}, {Row: 0, Col: 6}, {Row: 0, Col: 7}, {Row: 0, Col: 8},
+	}
+
+	testCases := []struct {
+		name         string
+		daubed       []bingo.Ball
+		called       []bingo.Ball
+		pattern      bingo.WinPattern
+		valid        bool
+		winningCells []bingo.CellPosition
+		invalidDaubs []bingo.CellPosition
+	}{
+		{
+			name:         "Blank cells complete a line without being daubed",
+			daubed:       topRow,
+			called:       topRow,
+			pattern:      bingo.WinPatternOneLine,
+			valid:        true,
+			winningCells: topRowCells,
+		},
+		{
+			name:         "Daubing blank cells isn't an invalid daub",
+			daubed:       append([]bingo.Ball{bingo.FreeSpace}, topRow...),
+			called:       topRow,
+			pattern:      bingo.WinPatternOneLine,
+			valid:        true,
+			winningCells: topRowCells,
+		},
+		{
+			name:    "Row missing a number",
+			daubed:  topRow[1:],
+			called:  topRow,
+			pattern: bingo.WinPatternOneLine,
+			valid:   false,
+		},
+		{
+			name:         "Uncalled number in a line",
+			daubed:       topRow,
+			called:       topRow[1:],
+			pattern:      bingo.WinPatternOneLine,
+			valid:        false,
+			winningCells: topRowCells,
+			invalidDaubs: []bingo.CellPosition{{Row: 0, Col: 0}},
+		},
+		{
+			name:    "One line doesn't satisfy two lines",
+			daubed:  topRow,
+			called:  topRow,
+			pattern: bingo.WinPatternTwoLines,
+			valid:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := validateCard(newTestTicket(tc.daubed...), tc.called, tc.pattern)
+			if result.valid() != tc.valid {
+				t.Fatalf("expected valid to be %v, got %v", tc.valid, result.valid())
+			}
+			if !slices.Equal(result.winningCells, tc.winningCells) {
+				t.Fatalf("expected winning cells %v, got %v", tc.winningCells, result.winningCells)
+			}
+			if !slices.Equal(result.invalidDaubs, tc.invalidDaubs) {
+				t.Fatalf("expected invalid daubs %v, got %v", tc.invalidDaubs, result.invalidDaubs)
+			}
+		})
+	}
+}
@@ -1,44 +1,853 @@
 package game
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/Parkreiner/bingo"
 	"github.com/google/uuid"
 )
 
-func (g *Game) processAutomaticBall(commanderID uuid.UUID) error {
-	g.mtx.Lock()
-	defer g.mtx.Unlock()
+const (
+	// defaultSuspensionRounds is the number of rounds that a player is
+	// suspended for when the host does not specify a duration
+	defaultSuspensionRounds = 1
+	// maxSuspensionRounds is the longest suspension that a host can give
+	// out. Anyone who needs to be out for longer should just be banned.
+	maxSuspensionRounds = 100
+)
 
-	if commanderID != g.host.ID {
-		return fmt.Errorf("provided ID %q does not match host ID %q", commanderID, g.host.ID)
+func (g *Game) processStartGame(commanderID uuid.UUID) error {
+	g.currentRound = 1
+	if err := g.changePhase(bingo.GamePhaseRoundStart, commanderID); err != nil {
+		return err
 	}
-	if g.phase.value() != bingo.GamePhaseCalling {
-		return errors.New("can only issue a new ball during the calling phase")
+
+	// Anyone who joined before the game started will have been waitlisted,
+	// but they should be able to play in the very first round
+	g.promoteWaitlistedPlayers(commanderID)
+	g.dispatchDrawCommitted(commanderID)
+	return nil
+}
+
+func (g *Game) processTerminateGame(commanderID uuid.UUID) error {
+	return g.terminate(commanderID)
+}
+
+// terminate ends the game for good. This method is NOT thread-safe.
+func (g *Game) terminate(commanderID uuid.UUID) error {
+	if g.dispose == nil {
+		return errors.New("game does not have any way to be terminated")
+	}
+
+	// The game will never call any more balls, so the current round's draw
+	// is safe to reveal. System disposals don't do this, because the game
+	// might be restored and continue the round later.
+	if g.phase.value() != bingo.GamePhaseInitialized {
+		g.dispatchDrawRevealed(commanderID)
+	}
+	return g.endGame(commanderID)
+}
+
+// endGame announces that the game is over, and then disposes it. Only system
+// disposals skip the announcement, since the game might be restored later.
+// This method is NOT thread-safe.
+func (g *Game) endGame(commanderID uuid.UUID) error {
+	if err := g.changePhase(bingo.GamePhaseGameOver, commanderID); err != nil {
+		return err
+	}
+	return g.dispose()
+}
+
+func (g *Game) processTransferHostStatus(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadTransferHostStatus{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse host transfer payload: %v", err)
+	}
+	if len(parsed.NewHostID) != 1 {
+		return errors.New("must transfer host status to exactly one player")
+	}
+	entry := g.findPlayerEntry(parsed.NewHostID[0])
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", parsed.NewHostID[0])
+	}
+	if entry.player.Status == bingo.PlayerStatusSuspended {
+		return fmt.Errorf("player %q is suspended and cannot become the host", entry.player.Name)
+	}
+	return g.transferHost(entry, command.CommanderID)
+}
+
+// leaveAsHost lets the current host leave without ending the game, by handing
+// it over to the first co-host who isn't suspended. If there aren't any, the
+// first card player who isn't suspended takes over instead. The game is only
+// terminated if there's nobody left to host it. This method is NOT
+// thread-safe.
+func (g *Game) leaveAsHost(hostID uuid.UUID) error {
+	var successor *playerEntry
+	for _, c := range g.coHosts {
+		entry := g.findPlayerEntry(c.PlayerID)
+		if entry != nil && entry.player.Status != bingo.PlayerStatusSuspended {
+			successor = entry
+			break
+		}
+	}
+	if successor == nil {
+		for _, e := range g.cardPlayers {
+			if e.player.Status != bingo.PlayerStatusSuspended {
+				successor = e
+				break
+			}
+		}
+	}
+	if successor == nil {
+		return g.terminate(hostID)
+	}
+	return g.transferHost(successor, hostID)
+}
+
+// transferHost makes a card player the new host. They give up their cards
+// (along with any bingo call or co-host permissions they had), but keep their
+// event subscription, since it already receives every event meant for their
+// ID. The old host is unsubscribed from all future events. This method is NOT
+// thread-safe.
+func (g *Game) transferHost(entry *playerEntry, commanderID uuid.UUID) error {
+	prevHost := g.host
+	prevUnsubscribe := g.hostUnsubscribe
+	newHostID := entry.player.ID
+	_, detachErr := g.detachPlayerEntry(newHostID)
+	g.host = &bingo.Player{
+		Status:        bingo.PlayerStatusHost,
+		ID:            newHostID,
+		Name:          entry.player.Name,
+		Cards:         nil,
+		EventReceiver: entry.player.EventReceiver,
+	}
+	g.hostUnsubscribe = entry.unsubscribe
+	delete(g.sentSnapshots, prevHost.ID)
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypeHostTransferred,
+		CreatedByID: commanderID,
+		Message:     fmt.Sprintf("player %q is now hosting the game (previously hosted by %q)", g.host.Name, prevHost.Name),
+		Payload: eventPayload(bingo.GameEventPayloadHostTransferred{
+			PreviousHostID: prevHost.ID,
+			NewHostID:      newHostID,
+			NewHostName:    g.host.Name,
+		}),
+		RecipientIDs: nil,
+	})
+	// Unsubscribing only after the event goes out lets the old host find out
+	// who took over
+	if prevUnsubscribe != nil {
+		prevUnsubscribe()
+	}
+
+	if err := g.resumeCallingIfNoCallers(commanderID); err != nil {
+		return err
+	}
+	if err := g.removeFromTiebreaker(newHostID, commanderID); err != nil {
+		return err
+	}
+	return detachErr
+}
+
+func (g *Game) processAppointCoHost(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostAppointCoHost{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse co-host payload: %v", err)
+	}
+	if len(parsed.Permissions) == 0 {
+		return errors.New("co-hosts must have at least one permission")
+	}
+	var permissions []bingo.HostPermission
+	for _, p := range parsed.Permissions {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if !slices.Contains(permissions, p) {
+			permissions = append(permissions, p)
+		}
+	}
+	entry := g.findPlayerEntry(parsed.PlayerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", parsed.PlayerID)
+	}
+
+	coHost := g.findCoHost(parsed.PlayerID)
+	if coHost == nil {
+		coHost = &bingo.CoHost{PlayerID: parsed.PlayerID}
+		g.coHosts = append(g.coHosts, coHost)
+	}
+	coHost.Permissions = permissions
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypeCoHostAppointed,
+		CreatedByID: command.CommanderID,
+		Message:     fmt.Sprintf("player %q is now a co-host with permissions %v", entry.player.Name, permissions),
+		Payload: eventPayload(bingo.GameEventPayloadCoHost{
+			CoHost: bingo.CoHost{
+				PlayerID:    coHost.PlayerID,
+				Permissions: slices.Clone(permissions),
+			},
+			Name: entry.player.Name,
+		}),
+		RecipientIDs: nil,
+	})
+	return nil
+}
+
+func (g *Game) processDismissCoHost(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostDismissCoHost{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse co-host payload: %v", err)
+	}
+	if g.findCoHost(parsed.PlayerID) == nil {
+		return fmt.Errorf("player %q is not a co-host", parsed.PlayerID)
+	}
+
+	g.coHosts = slices.DeleteFunc(g.coHosts, func(c *bingo.CoHost) bool {
+		return c.PlayerID == parsed.PlayerID
+	})
+	name := ""
+	if entry := g.findPlayerEntry(parsed.PlayerID); entry != nil {
+		name = entry.player.Name
+	}
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypeCoHostDismissed,
+		CreatedByID: command.CommanderID,
+		Message:     fmt.Sprintf("player %q is no longer a co-host", name),
+		Payload: eventPayload(bingo.GameEventPayloadCoHost{
+			CoHost: bingo.CoHost{
+				PlayerID:    parsed.PlayerID,
+				Permissions: []bingo.HostPermission{},
+			},
+			Name: name,
+		}),
+		RecipientIDs: nil,
+	})
+	return nil
+}
+
+func (g *Game) processBanPlayer(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostBanPlayer{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse ban payload: %v", err)
+	}
+	if parsed.PlayerID == g.host.ID {
+		return errors.New("cannot ban the host")
+	}
+	if parsed.PlayerID == g.systemID {
+		return errors.New("cannot ban the system")
+	}
+	if err := g.validateCoHostTarget(command.CommanderID, parsed.PlayerID); err != nil {
+		return err
+	}
+	if slices.Contains(g.bannedPlayerIDs, parsed.PlayerID) {
+		return fmt.Errorf("player %q is already banned", parsed.PlayerID)
+	}
+
+	// Players are allowed to be banned pre-emptively, even if they haven't
+	// joined the game yet. Only the host needs to hear about those bans.
+	g.bannedPlayerIDs = append(g.bannedPlayerIDs, parsed.PlayerID)
+	entry := g.findPlayerEntry(parsed.PlayerID)
+	if entry == nil {
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Phase:       g.phase.value(),
+			Type:        bingo.EventTypePlayerBanned,
+			CreatedByID: command.CommanderID,
+			Message:     fmt.Sprintf("player %q has been banned before joining", parsed.PlayerID),
+			Payload: eventPayload(bingo.GameEventPayloadPlayer{
+				PlayerID: parsed.PlayerID,
+				Name:     "",
+				Status:   bingo.PlayerStatusBanned,
+			}),
+			RecipientIDs: g.hostRecipients(bingo.HostPermissionModeratePlayers),
+		})
+		return nil
+	}
+
+	entry.player.Status = bingo.PlayerStatusBanned
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypePlayerBanned,
+		CreatedByID: command.CommanderID,
+		Message:     fmt.Sprintf("player %q has been banned", entry.player.Name),
+		Payload: eventPayload(bingo.GameEventPayloadPlayer{
+			PlayerID: entry.player.ID,
+			Name:     entry.player.Name,
+			Status:   entry.player.Status,
+		}),
+		RecipientIDs: nil,
+	})
+
+	// Removing the player unsubscribes them, gives their cards back to the
+	// card registry, and throws out any bingo call they still had pending
+	removeErr := g.removePlayerEntry(parsed.PlayerID)
+	if err := g.resumeCallingIfNoCallers(command.CommanderID); err != nil {
+		return err
+	}
+	if err := g.removeFromTiebreaker(parsed.PlayerID, command.CommanderID); err != nil {
+		return err
+	}
+	return removeErr
+}
+
+func (g *Game) processSuspendPlayer(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostSuspendPlayer{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse suspension payload: %v", err)
+	}
+	duration := parsed.RoundDuration
+	if duration == 0 {
+		duration = defaultSuspensionRounds
+	}
+	if duration < 1 || duration > maxSuspensionRounds {
+		return fmt.Errorf("suspensions must last between 1 and %d rounds", maxSuspensionRounds)
+	}
+	entry := g.findPlayerEntry(parsed.PlayerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", parsed.PlayerID)
+	}
+	if err := g.validateCoHostTarget(command.CommanderID, parsed.PlayerID); err != nil {
+		return err
+	}
+	if entry.player.Status == bingo.PlayerStatusSuspended {
+		return fmt.Errorf("player %q is already suspended", entry.player.Name)
+	}
+
+	entry.player.Status = bingo.PlayerStatusSuspended
+	suspension := &bingo.PlayerSuspension{
+		PlayerID:      parsed.PlayerID,
+		RoundDuration: duration,
+		RoundsPassed:  0,
+	}
+	g.suspensions = append(g.suspensions, suspension)
+	g.dropBingoCaller(parsed.PlayerID)
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        g.phase.value(),
+		Type:         bingo.EventTypePlayerSuspended,
+		CreatedByID:  command.CommanderID,
+		Message:      fmt.Sprintf("player %q has been suspended for %d round(s)", entry.player.Name, duration),
+		Payload:      eventPayload(bingo.GameEventPayloadPlayerSuspended{Suspension: *suspension}),
+		RecipientIDs: g.hostRecipients(bingo.HostPermissionModeratePlayers, parsed.PlayerID),
+	})
+
+	if err := g.resumeCallingIfNoCallers(command.CommanderID); err != nil {
+		return err
+	}
+	return g.removeFromTiebreaker(parsed.PlayerID, command.CommanderID)
+}
+
+func (g *Game) processAutomaticBall(commanderID uuid.UUID) error {
+	if g.callingMode != bingo.CallingModeAutomatic {
+		return errors.New("balls must be synced from the ball machine while in manual mode")
+	}
+	if g.phase.value() == bingo.GamePhaseTiebreaker {
+		return g.drawTiebreakerBall(commanderID)
+	}
+	if err := g.prepareForBallCall(commanderID); err != nil {
+		return err
 	}
 
 	ball, err := g.ballRegistry.nextAutomaticCall()
 	if err != nil {
 		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
-			Phase:        bingo.GamePhaseCalling,
-			Type:         bingo.EventTypeError,
-			CreatedByID:  commanderID,
-			Message:      "unable to generate new ball",
+			Phase:       bingo.GamePhaseCalling,
+			Type:        bingo.EventTypeError,
+			CreatedByID: commanderID,
+			Message:     "unable to generate new ball",
+			Payload: eventPayload(bingo.GameEventPayloadError{
+				CommandType: bingo.GameCommandHostRequestBall,
+				Reason:      err.Error(),
+			}),
 			RecipientIDs: []uuid.UUID{commanderID},
 		})
 		return err
 	}
 
+	g.dispatchBallCalled(ball, commanderID)
+	return nil
+}
+
+func (g *Game) processSyncBall(command bingo.GameCommand) error {
+	if g.callingMode != bingo.CallingModeManual {
+		return errors.New("balls can only be synced while in manual mode")
+	}
+
+	parsed := &bingo.GameCommandPayloadHostSyncBall{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse ball sync payload: %v", err)
+	}
+	ball, err := g.variant.ParseBall(parsed.Value)
+	if err != nil {
+		return err
+	}
+	if ball == bingo.FreeSpace {
+		return errors.New("free space cannot be called as a ball")
+	}
+	if g.phase.value() == bingo.GamePhaseTiebreaker {
+		return g.syncTiebreakerBall(ball, command.CommanderID)
+	}
+	if err := g.prepareForBallCall(command.CommanderID); err != nil {
+		return err
+	}
+
+	if err := g.ballRegistry.syncManualCall(ball); err != nil {
+		g.phaseSubscriptions.dispatchEvent(g.errorEvent(command, err))
+		return err
+	}
+
+	g.dispatchBallCalled(ball, command.CommanderID)
+	return nil
+}
+
+func (g *Game) processUndoBall(command bingo.GameCommand) error {
+	if g.callingMode != bingo.CallingModeManual {
+		return errors.New("balls can only be undone while in manual mode")
+	}
+
+	parsed := &bingo.GameCommandPayloadHostUndoBall{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse ball undo payload: %v", err)
+	}
+	ball, err := g.variant.ParseBall(parsed.Value)
+	if err != nil {
+		return err
+	}
+	if err := g.ballRegistry.undoManualCall(ball); err != nil {
+		g.phaseSubscriptions.dispatchEvent(g.errorEvent(command, err))
+		return err
+	}
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       bingo.GamePhaseCalling,
+		Type:        bingo.EventTypeBallUndone,
+		CreatedByID: command.CommanderID,
+		Message:     fmt.Sprintf("ball %d was undone", ball),
+		Payload: eventPayload(bingo.GameEventPayloadBallUndone{
+			Ball:        ball,
+			CalledCount: len(g.ballRegistry.getCalledBalls()),
+		}),
+		RecipientIDs: nil,
+	})
+
+	// Daubs are left alone, since players are allowed to daub balls that
+	// haven't been called. Anyone who daubed the ball still needs to know that
+	// it no longer counts, though.
+	for _, e := range g.cardPlayers {
+		var cardIDs []uuid.UUID
+		for _, c := range e.player.Cards {
+			if cardHasDaub(c, ball) {
+				cardIDs = append(cardIDs, c.ID)
+			}
+		}
+		if len(cardIDs) == 0 {
+			continue
+		}
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Phase:       bingo.GamePhaseCalling,
+			Type:        bingo.EventTypeDaubInvalidated,
+			CreatedByID: command.CommanderID,
+			Message:     fmt.Sprintf("ball %d was undone, but is daubed on %d card(s)", ball, len(cardIDs)),
+			Payload: eventPayload(bingo.GameEventPayloadDaubInvalidated{
+				Ball:    ball,
+				CardIDs: cardIDs,
+			}),
+			RecipientIDs: []uuid.UUID{e.player.ID},
+		})
+	}
+	return nil
+}
+
+func (g *Game) processEndRound(commanderID uuid.UUID) error {
+	// As long as there are balls left, somebody can still win
+	if len(g.ballRegistry.getUncalledBalls()) != 0 {
+		return errors.New("can only end a round without a winner once every ball has been called")
+	}
+	return g.endRound(commanderID, nil)
+}
+
+// cardHasDaub indicates whether a card has the given ball daubed.
+func cardHasDaub(card *bingo.Card, ball bingo.Ball) bool {
+	for _, row := range card.Cells {
+		for _, cell := range row {
+			if cell.Number == ball && cell.Daubed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *Game) processStartAutoCaller(command bingo.GameCommand) error {
+	if g.callingMode != bingo.CallingModeAutomatic {
+		return errors.New("auto-caller can only be used in automatic mode")
+	}
+	if g.autoCaller.running {
+		return errors.New("auto-caller is already running")
+	}
+
+	// The payload is optional, since the host might want to keep the
+	// current interval
+	if len(command.Payload) != 0 {
+		parsed := &bingo.GameCommandPayloadHostStartAutoCaller{}
+		if err := json.Unmarshal(command.Payload, parsed); err != nil {
+			return fmt.Errorf("unable to parse auto-caller payload: %v", err)
+		}
+		if parsed.IntervalMs != 0 {
+			interval, err := parseAutoCallInterval(parsed.IntervalMs)
+			if err != nil {
+				return err
+			}
+			g.autoCaller.interval = interval
+		}
+	}
+
+	g.autoCaller.running = true
+	g.syncAutoCaller()
+	g.dispatchAutoCallerChanged(command.CommanderID, fmt.Sprintf("auto-caller started with an interval of %v", g.autoCaller.interval))
+	return nil
+}
+
+func (g *Game) processPauseAutoCaller(commanderID uuid.UUID) error {
+	if !g.autoCaller.running {
+		return errors.New("auto-caller is not running")
+	}
+
+	g.autoCaller.running = false
+	g.syncAutoCaller()
+	g.dispatchAutoCallerChanged(commanderID, "auto-caller paused")
+	return nil
+}
+
+func (g *Game) processSetAutoCallerSpeed(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostSetAutoCallerSpeed{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse auto-caller speed payload: %v", err)
+	}
+	interval, err := parseAutoCallInterval(parsed.IntervalMs)
+	if err != nil {
+		return err
+	}
+
+	g.autoCaller.interval = interval
+	// Reschedule from scratch, so that the new interval applies right away
+	g.autoCaller.nextCallAt = time.Time{}
+	g.syncAutoCaller()
+	g.dispatchAutoCallerChanged(command.CommanderID, fmt.Sprintf("auto-caller interval changed to %v", interval))
+	return nil
+}
+
+func (g *Game) processAcknowledgeBingoCall(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostAcknowledgeBingoCall{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse bingo acknowledgement payload: %v", err)
+	}
+	if !slices.Contains(g.bingoCallerPlayerIDs, parsed.PlayerID) {
+		return fmt.Errorf("player %q has not called bingo", parsed.PlayerID)
+	}
+	if err := g.validateCoHostTarget(command.CommanderID, parsed.PlayerID); err != nil {
+		return err
+	}
+
+	entry := g.findPlayerEntry(parsed.PlayerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", parsed.PlayerID)
+	}
+
+	// Re-validate instead of reusing the result from when bingo was called,
+	// since the player might have changed their daubs since then
+	validation := validateClaim(entry.player, g.ballRegistry.getCalledBalls(), g.winPattern)
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        bingo.GamePhaseConfirmingBingo,
+		Type:         bingo.EventTypeBingoValidated,
+		CreatedByID:  command.CommanderID,
+		Message:      fmt.Sprintf("advisory for player %q: %s", entry.player.Name, validation.summary()),
+		Payload:      eventPayload(bingo.GameEventPayloadBingoValidated{ClaimValidation: validation.export()}),
+		RecipientIDs: g.hostRecipients(bingo.HostPermissionJudgeBingo),
+	})
+
+	eventType := bingo.EventTypeBingoConfirmed
+	message := "bingo call has been accepted by the host"
+	if parsed.Accepted {
+		if !slices.Contains(g.acceptedCallerPlayerIDs, parsed.PlayerID) {
+			g.acceptedCallerPlayerIDs = append(g.acceptedCallerPlayerIDs, parsed.PlayerID)
+		}
+	} else {
+		eventType = bingo.EventTypeBingoRejected
+		message = "bingo call has been rejected by the host"
+		g.dropBingoCaller(parsed.PlayerID)
+	}
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        bingo.GamePhaseConfirmingBingo,
+		Type:         eventType,
+		CreatedByID:  command.CommanderID,
+		Message:      message,
+		Payload:      eventPayload(bingo.GameEventPayloadBingoClaimed{PlayerID: parsed.PlayerID}),
+		RecipientIDs: nil,
+	})
+
+	return g.resumeCallingIfNoCallers(command.CommanderID)
+}
+
+func (g *Game) processStartTiebreakerRound(command bingo.GameCommand) error {
+	// Only calls that the host has accepted count towards a tie. The
+	// tiebreaker keeps the order that the players called bingo in.
+	tiedPlayerIDs := slices.DeleteFunc(slices.Clone(g.bingoCallerPlayerIDs), func(id uuid.UUID) bool {
+		return !slices.Contains(g.acceptedCallerPlayerIDs, id)
+	})
+	if len(tiedPlayerIDs) < 2 {
+		return errors.New("tiebreakers require the host to have accepted bingo calls from at least two players")
+	}
+
+	parsed := &bingo.GameCommandPayloadHostStartTiebreakerRound{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse tiebreaker payload: %v", err)
+	}
+	if err := parsed.Rule.Validate(); err != nil {
+		return err
+	}
+
+	if err := g.changePhase(bingo.GamePhaseTiebreaker, command.CommanderID); err != nil {
+		return err
+	}
+	// Shuffling the tiebreaker's draw uses up randomness
+	g.needsCheckpoint = true
+	g.tiebreaker = newTiebreaker(parsed.Rule, tiedPlayerIDs, g.ballRegistry.source, g.variant.MaxBall)
+
+	// Everyone in a next line tiebreaker starts over with clean cards, so
+	// that nobody gets a head start from the round's balls
+	if parsed.Rule == bingo.TiebreakerRuleNextLine {
+		for _, id := range g.tiebreaker.playerIDs {
+			entry := g.findPlayerEntry(id)
+			if entry == nil {
+				continue
+			}
+			for _, card := range entry.player.Cards {
+				clearDaubs(card)
+			}
+		}
+	}
+
+	status := g.tiebreaker.status()
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       bingo.GamePhaseTiebreaker,
+		Type:        bingo.EventTypeTiebreakerStarted,
+		CreatedByID: command.CommanderID,
+		Message:     fmt.Sprintf("%s tiebreaker started between %d players", parsed.Rule, len(status.PlayerIDs)),
+		Payload: eventPayload(bingo.GameEventPayloadTiebreakerStarted{
+			Rule:       status.Rule,
+			PlayerIDs:  status.PlayerIDs,
+			Commitment: status.Commitment,
+		}),
+		RecipientIDs: nil,
+	})
+	return g.settleTiebreaker(command.CommanderID)
+}
+
+func (g *Game) processSetWinPattern(command bingo.GameCommand) error {
+	phase := g.phase.value()
+
+	parsed := &bingo.GameCommandPayloadHostSetWinPattern{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse win pattern payload: %v", err)
+	}
+
+	var pattern bingo.WinPattern
+	if parsed.CustomPattern != nil {
+		if err := g.variant.ValidateWinPattern(*parsed.CustomPattern); err != nil {
+			return err
+		}
+		pattern = *parsed.CustomPattern
+	} else {
+		standard, ok := g.variant.LookupWinPattern(parsed.PatternID)
+		if !ok {
+			return fmt.Errorf("%q is not a standard win pattern for %s bingo", parsed.PatternID, g.variant.Name)
+		}
+		pattern = standard
+	}
+
+	g.winPattern = pattern
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        phase,
+		Type:         bingo.EventTypeWinPatternChanged,
+		CreatedByID:  command.CommanderID,
+		Message:      fmt.Sprintf("win pattern changed to %s", pattern.Name),
+		Payload:      eventPayload(bingo.GameEventPayloadWinPatternChanged{Pattern: pattern}),
+		RecipientIDs: nil,
+	})
+	return nil
+}
+
+func (g *Game) processSetCardLimit(command bingo.GameCommand) error {
+	phase := g.phase.value()
+
+	parsed := &bingo.GameCommandPayloadHostSetCardLimit{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse card limit payload: %v", err)
+	}
+	if err := validateCardLimit(parsed.MaxCards); err != nil {
+		return err
+	}
+	// Trimming part of a strip would leave the rest of it unable to cover
+	// every ball, so limits like that are refused before anything changes
+	for _, e := range g.cardPlayers {
+		if len(e.player.Cards) <= parsed.MaxCards {
+			continue
+		}
+		for _, card := range e.player.Cards[parsed.MaxCards:] {
+			if g.cardRegistry.isStripCard(card.ID) {
+				return fmt.Errorf("a limit of %d cards would split the strip held by player %q", parsed.MaxCards, e.player.Name)
+			}
+		}
+	}
+
+	g.maxCardsPerPlayer = parsed.MaxCards
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        phase,
+		Type:         bingo.EventTypeCardLimitChanged,
+		CreatedByID:  command.CommanderID,
+		Message:      fmt.Sprintf("card limit changed to %d", parsed.MaxCards),
+		Payload:      eventPayload(bingo.GameEventPayloadCardLimitChanged{MaxCards: parsed.MaxCards}),
+		RecipientIDs: nil,
+	})
+
+	// Players who already have too many cards lose the ones at the end of
+	// their hand
+	var errs []error
+	for _, e := range g.cardPlayers {
+		if len(e.player.Cards) <= g.maxCardsPerPlayer {
+			continue
+		}
+		g.needsCheckpoint = true
+		for _, card := range e.player.Cards[g.maxCardsPerPlayer:] {
+			if err := g.cardRegistry.ReturnCard(card.ID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		e.player.Cards = e.player.Cards[:g.maxCardsPerPlayer]
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Phase:        phase,
+			Type:         bingo.EventTypeHandReplaced,
+			CreatedByID:  command.CommanderID,
+			Message:      "hand trimmed to the new card limit",
+			Payload:      eventPayload(bingo.GameEventPayloadHandReplaced{Cards: e.player.Cards}),
+			RecipientIDs: []uuid.UUID{e.player.ID},
+		})
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("unable to return trimmed cards: %v", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (g *Game) processSetCallingMode(command bingo.GameCommand) error {
+	phase := g.phase.value()
+
+	parsed := &bingo.GameCommandPayloadHostSetCallingMode{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse calling mode payload: %v", err)
+	}
+	if err := parsed.Mode.Validate(); err != nil {
+		return err
+	}
+	if parsed.Mode == bingo.CallingModeManual && g.autoCaller.running {
+		return errors.New("auto-caller must be paused before switching to manual mode")
+	}
+
+	g.callingMode = parsed.Mode
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        phase,
+		Type:         bingo.EventTypeCallingModeChanged,
+		CreatedByID:  command.CommanderID,
+		Message:      fmt.Sprintf("calling mode changed to %s", parsed.Mode),
+		Payload:      eventPayload(bingo.GameEventPayloadCallingModeChanged{Mode: parsed.Mode}),
+		RecipientIDs: nil,
+	})
+	return nil
+}
+
+func (g *Game) processAwardPlayers(command bingo.GameCommand) error {
+	parsed := &bingo.GameCommandPayloadHostAwardsPlayers{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse award payload: %v", err)
+	}
+	if len(parsed.PlayerIDs) == 0 {
+		return errors.New("must award at least one player")
+	}
+
+	// Validate everything before mutating anything, so that a single bad ID
+	// doesn't result in a partially-awarded round
+	var winners []*bingo.Player
+	for _, id := range parsed.PlayerIDs {
+		if !slices.Contains(g.bingoCallerPlayerIDs, id) {
+			return fmt.Errorf("player %q has not called bingo", id)
+		}
+		if err := g.validateCoHostTarget(command.CommanderID, id); err != nil {
+			return err
+		}
+		entry := g.findPlayerEntry(id)
+		if entry == nil {
+			return fmt.Errorf("unable to find player with ID %q", id)
+		}
+		if !slices.Contains(winners, entry.player) {
+			winners = append(winners, entry.player)
+		}
+	}
+
+	// The host is allowed to settle a tiebreaker themselves at any point
+	g.tiebreaker = nil
+	g.winningPlayers = append(g.winningPlayers, winners...)
+	return g.endRound(command.CommanderID, winners)
+}
+
+// dispatchBallCalled notifies every subscriber that a new ball has been
+// called. This method is NOT thread-safe.
+func (g *Game) dispatchBallCalled(ball bingo.Ball, commanderID uuid.UUID) {
+	// Every new ball restarts the wait for the auto-caller, even if the host
+	// called it themselves
+	g.autoCaller.nextCallAt = time.Time{}
 	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
 		Phase:       bingo.GamePhaseCalling,
-		Type:        bingo.EventTypeUpdate,
+		Type:        bingo.EventTypeBallCalled,
 		CreatedByID: commanderID,
 		Message:     fmt.Sprintf("new ball: %d", ball),
+		Payload: eventPayload(bingo.GameEventPayloadBallCalled{
+			Ball:        ball,
+			CalledCount: len(g.ballRegistry.getCalledBalls()),
+		}),
 
 		// This one needs to be nil to make sure it reaches everyone
 		RecipientIDs: nil,
 	})
-	return nil
+}
+
+// prepareForBallCall makes sure that the game is able to have a new ball
+// called. Calling the first ball of a round is what moves the game out of
+// the round start phase. This method is NOT thread-safe.
+func (g *Game) prepareForBallCall(commanderID uuid.UUID) error {
+	switch g.phase.value() {
+	case bingo.GamePhaseCalling:
+		return nil
+	case bingo.GamePhaseRoundStart:
+		return g.changePhase(bingo.GamePhaseCalling, commanderID)
+	default:
+		return errors.New("can only issue a new ball during the calling phase")
+	}
+}
+
+// resumeCallingIfNoCallers moves the game back to the calling phase if the game
+// was waiting on bingo calls to be confirmed, but there are no bingo callers
+// left. This method is NOT thread-safe.
+func (g *Game) resumeCallingIfNoCallers(commanderID uuid.UUID) error {
+	if g.phase.value() != bingo.GamePhaseConfirmingBingo || len(g.bingoCallerPlayerIDs) != 0 {
+		return nil
+	}
+	return g.changePhase(bingo.GamePhaseCalling, commanderID)
 }
@@ -4,70 +4,52 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
+	"slices"
 
 	"github.com/Parkreiner/bingo"
 	"github.com/google/uuid"
 )
 
 func (g *Game) processPlayerDaub(command bingo.GameCommand) error {
-	err := setDaubValue(g, command, true)
-
-	var message string
-	var eventType bingo.GameEventType
+	payload, err := setDaubValue(g, command, true)
 	if err != nil {
-		message = "daubed card"
-		eventType = bingo.EventTypeUpdate
-	} else {
-		message = "failed to daub card"
-		eventType = bingo.EventTypeError
+		g.phaseSubscriptions.dispatchEvent(g.errorEvent(command, fmt.Errorf("failed to daub card: %v", err)))
+		return err
 	}
 
 	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
-		Type:         eventType,
+		Type:         bingo.EventTypeDaubApplied,
 		CreatedByID:  command.CommanderID,
 		Phase:        g.phase.value(),
-		Message:      message,
+		Message:      "daubed card",
+		Payload:      eventPayload(payload),
 		RecipientIDs: []uuid.UUID{command.CommanderID},
 	})
 
-	return err
+	return nil
 }
 
 func (g *Game) processPlayerUndoDaub(command bingo.GameCommand) error {
-	err := setDaubValue(g, command, false)
-
-	var message string
-	var eventType bingo.GameEventType
+	payload, err := setDaubValue(g, command, false)
 	if err != nil {
-		message = "removed daub from card"
-		eventType = bingo.EventTypeUpdate
-	} else {
-		message = "failed to remove daub from card"
-		eventType = bingo.EventTypeError
+		g.phaseSubscriptions.dispatchEvent(g.errorEvent(command, fmt.Errorf("failed to remove daub from card: %v", err)))
+		return err
 	}
 
 	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
-		Type:         eventType,
+		Type:         bingo.EventTypeDaubRemoved,
 		CreatedByID:  command.CommanderID,
 		Phase:        g.phase.value(),
-		Message:      message,
+		Message:      "removed daub from card",
+		Payload:      eventPayload(payload),
 		RecipientIDs: []uuid.UUID{command.CommanderID},
 	})
 
-	return err
+	return nil
 }
 
-func (g *Game) processHandReplacement(playerID uuid.UUID) error {
-	g.mtx.Lock()
-	defer g.mtx.Unlock()
-
-	if playerID == g.host.ID {
-		return errors.New("host is not allowed to have cards")
-	}
-	if playerID == g.systemID {
-		return errors.New("attempting to swap hand belonging to system")
-	}
+func (g *Game) processHandReplacement(command bingo.GameCommand) error {
+	playerID := command.CommanderID
 
 	var matchedPlayer *bingo.Player
 	for _, entry := range g.cardPlayers {
@@ -80,67 +62,268 @@ func (g *Game) processHandReplacement(playerID uuid.UUID) error {
 		return fmt.Errorf("unable to find player with ID %q", playerID)
 	}
 
-	// Unfortunately there's not a great way to stop early in the event of an
-	// error, since the player will have already been created at this point, and
-	// errors for long-lived stateful values are nasty in general. The best we
-	// can do is try to do EVERYTHING needed to refresh the hand, gathering up
-	// all errors generated along the way
-	var errs []error
-	for _, card := range matchedPlayer.Cards {
-		err := g.cardRegistry.ReturnCard(card.ID)
-		if err != nil {
-			errs = append(errs, err)
+	// The payload is optional, so that clients that don't care about the
+	// number of cards don't need to send one
+	parsed := &bingo.GameCommandPayloadPlayerReplaceCards{}
+	if len(command.Payload) != 0 {
+		if err := json.Unmarshal(command.Payload, parsed); err != nil {
+			return fmt.Errorf("unable to parse hand replacement payload: %v", err)
 		}
 	}
-	for i := 0; i < bingo.MaxCards; i++ {
-		card, err := g.cardRegistry.CheckOutCard(playerID)
-		if err != nil {
+	cardCount := parsed.CardCount
+	if cardCount == 0 {
+		cardCount = min(len(matchedPlayer.Cards), g.maxCardsPerPlayer)
+	}
+	if err := g.validateCardCount(cardCount); err != nil {
+		return err
+	}
+
+	// The registry either replaces the whole hand or none of it, so the
+	// player's hand only needs to change once every new card is secured
+	var oldCardIDs []uuid.UUID
+	for _, card := range matchedPlayer.Cards {
+		oldCardIDs = append(oldCardIDs, card.ID)
+	}
+	newCards, err := g.cardRegistry.ReplaceHand(playerID, oldCardIDs, cardCount)
+	if err != nil {
+		err = fmt.Errorf("unable to refresh hand: %v", err)
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Type:        bingo.EventTypeError,
+			CreatedByID: playerID,
+			Phase:       g.phase.value(),
+			Payload: eventPayload(bingo.GameEventPayloadError{
+				CommandType: bingo.GameCommandPlayerReplaceCards,
+				Reason:      err.Error(),
+			}),
+			RecipientIDs: []uuid.UUID{playerID},
+			Message:      err.Error(),
+		})
+		return err
+	}
+	g.needsCheckpoint = true
+	matchedPlayer.Cards = newCards
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Type:         bingo.EventTypeHandReplaced,
+		CreatedByID:  playerID,
+		Phase:        g.phase.value(),
+		Payload:      eventPayload(bingo.GameEventPayloadHandReplaced{Cards: matchedPlayer.Cards}),
+		RecipientIDs: []uuid.UUID{playerID},
+		Message:      "hand refresh successful",
+	})
+
+	return nil
+}
+
+func (g *Game) processStripCheckout(playerID uuid.UUID) error {
+	if g.variant.StripSize == 0 {
+		return fmt.Errorf("%s bingo does not have strips", g.variant.Name)
+	}
+	if g.variant.StripSize > g.maxCardsPerPlayer {
+		return fmt.Errorf("a strip has %d cards, but players are limited to %d", g.variant.StripSize, g.maxCardsPerPlayer)
+	}
+	phase := g.phase.value()
+	entry := g.findPlayerEntry(playerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", playerID)
+	}
+
+	// The strip is checked out before the old hand is returned, so that the
+	// player keeps their old hand if anything goes wrong
+	g.needsCheckpoint = true
+	strip, err := g.cardRegistry.CheckOutStrip(playerID)
+	if err != nil {
+		err = fmt.Errorf("unable to check out strip: %v", err)
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Type:        bingo.EventTypeError,
+			CreatedByID: playerID,
+			Phase:       phase,
+			Payload: eventPayload(bingo.GameEventPayloadError{
+				CommandType: bingo.GameCommandPlayerCheckOutStrip,
+				Reason:      err.Error(),
+			}),
+			RecipientIDs: []uuid.UUID{playerID},
+			Message:      err.Error(),
+		})
+		return err
+	}
+
+	var errs []error
+	for _, card := range entry.player.Cards {
+		if err := g.cardRegistry.ReturnCard(card.ID); err != nil {
 			errs = append(errs, err)
-			continue
 		}
-		matchedPlayer.Cards = append(matchedPlayer.Cards, card)
 	}
+	entry.player.Cards = strip
 
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Type:         bingo.EventTypeHandReplaced,
+		CreatedByID:  playerID,
+		Phase:        phase,
+		Payload:      eventPayload(bingo.GameEventPayloadHandReplaced{Cards: entry.player.Cards}),
+		RecipientIDs: []uuid.UUID{playerID},
+		Message:      "checked out strip",
+	})
 	if len(errs) != 0 {
-		joined := fmt.Errorf("unable to refresh hand: %v", errors.Join(errs...))
+		return fmt.Errorf("unable to return old hand: %v", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (g *Game) processSelectedCardReplacement(command bingo.GameCommand) error {
+	playerID := command.CommanderID
+	phase := g.phase.value()
+	entry := g.findPlayerEntry(playerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", playerID)
+	}
 
+	parsed := &bingo.GameCommandPayloadPlayerReplaceSelectedCards{}
+	if err := json.Unmarshal(command.Payload, parsed); err != nil {
+		return fmt.Errorf("unable to parse card replacement payload: %v", err)
+	}
+	if len(parsed.CardIDs) == 0 {
+		return errors.New("must select at least one card to replace")
+	}
+	var handIndices []int
+	for i, id := range parsed.CardIDs {
+		if slices.Contains(parsed.CardIDs[:i], id) {
+			return fmt.Errorf("card %q was selected more than once", id)
+		}
+		index := slices.IndexFunc(entry.player.Cards, func(card *bingo.Card) bool {
+			return card.ID == id
+		})
+		if index == -1 {
+			return fmt.Errorf("player does not have card with ID %q", id)
+		}
+		handIndices = append(handIndices, index)
+	}
+
+	// The registry either swaps every card or none of them, so the player's
+	// hand only needs to change once the swap has fully gone through
+	g.needsCheckpoint = true
+	replacements, err := g.cardRegistry.SwapCards(playerID, parsed.CardIDs)
+	if err != nil {
+		err = fmt.Errorf("unable to replace cards: %v", err)
 		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
-			ID:           uuid.New(),
-			Type:         bingo.EventTypeError,
-			CreatedByID:  playerID,
-			Phase:        g.phase.value(),
-			Created:      time.Now(),
+			Type:        bingo.EventTypeError,
+			CreatedByID: playerID,
+			Phase:       phase,
+			Payload: eventPayload(bingo.GameEventPayloadError{
+				CommandType: bingo.GameCommandPlayerReplaceSelectedCards,
+				Reason:      err.Error(),
+			}),
 			RecipientIDs: []uuid.UUID{playerID},
-			Message:      joined.Error(),
+			Message:      err.Error(),
 		})
-		return joined
+		return err
+	}
+
+	// Each new card takes the exact spot of the card it replaced, so that the
+	// rest of the hand doesn't move around on the player's screen
+	for i, card := range replacements {
+		entry.player.Cards[handIndices[i]] = card
 	}
 
 	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
-		ID:           uuid.New(),
-		Type:         bingo.EventTypeUpdate,
+		Type:         bingo.EventTypeHandReplaced,
 		CreatedByID:  playerID,
-		Phase:        g.phase.value(),
-		Created:      time.Now(),
+		Phase:        phase,
+		Payload:      eventPayload(bingo.GameEventPayloadHandReplaced{Cards: entry.player.Cards}),
 		RecipientIDs: []uuid.UUID{playerID},
-		Message:      "hand refresh successful",
+		Message:      fmt.Sprintf("replaced %d card(s)", len(replacements)),
+	})
+	return nil
+}
+
+func (g *Game) processCallBingo(playerID uuid.UUID) error {
+	phase := g.phase.value()
+	entry := g.findPlayerEntry(playerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", playerID)
+	}
+	if phase == bingo.GamePhaseTiebreaker {
+		return g.processTiebreakerClaim(entry)
+	}
+	if slices.Contains(g.bingoCallerPlayerIDs, playerID) {
+		return fmt.Errorf("player %q has already called bingo", entry.player.Name)
+	}
+
+	g.bingoCallerPlayerIDs = append(g.bingoCallerPlayerIDs, playerID)
+	if phase == bingo.GamePhaseCalling {
+		if err := g.changePhase(bingo.GamePhaseConfirmingBingo, playerID); err != nil {
+			return err
+		}
+	}
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Type:         bingo.EventTypeBingoClaimed,
+		CreatedByID:  playerID,
+		Phase:        bingo.GamePhaseConfirmingBingo,
+		Message:      fmt.Sprintf("player %q has called bingo", entry.player.Name),
+		Payload:      eventPayload(bingo.GameEventPayloadBingoClaimed{PlayerID: playerID}),
+		RecipientIDs: nil,
+	})
+
+	// The host is the only one who needs to see the results of the validation
+	validation := validateClaim(entry.player, g.ballRegistry.getCalledBalls(), g.winPattern)
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Type:         bingo.EventTypeBingoValidated,
+		CreatedByID:  playerID,
+		Phase:        bingo.GamePhaseConfirmingBingo,
+		Message:      fmt.Sprintf("bingo call from player %q: %s", entry.player.Name, validation.summary()),
+		Payload:      eventPayload(bingo.GameEventPayloadBingoValidated{ClaimValidation: validation.export()}),
+		RecipientIDs: g.hostRecipients(bingo.HostPermissionJudgeBingo),
 	})
 
 	return nil
 }
 
-func setDaubValue(game *Game, command bingo.GameCommand, daubValue bool) error {
-	phase := game.phase.value()
-	if phase == bingo.GamePhaseRoundStart {
-		return errors.New("cannot change daubs when no cards have been called")
+func (g *Game) processRescindBingo(playerID uuid.UUID) error {
+	entry := g.findPlayerEntry(playerID)
+	if entry == nil {
+		return fmt.Errorf("unable to find player with ID %q", playerID)
+	}
+	if !slices.Contains(g.bingoCallerPlayerIDs, playerID) {
+		return fmt.Errorf("player %q has not called bingo", entry.player.Name)
 	}
-	if phase == bingo.GamePhaseRoundEnd {
-		return errors.New("phase is ending; daub change discarded")
+
+	g.dropBingoCaller(playerID)
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Type:         bingo.EventTypeBingoRescinded,
+		CreatedByID:  playerID,
+		Phase:        bingo.GamePhaseConfirmingBingo,
+		Message:      fmt.Sprintf("player %q has rescinded their bingo call", entry.player.Name),
+		Payload:      eventPayload(bingo.GameEventPayloadBingoClaimed{PlayerID: playerID}),
+		RecipientIDs: nil,
+	})
+
+	return g.resumeCallingIfNoCallers(playerID)
+}
+
+// validateCardLimit makes sure that a limit on the number of cards per player
+// is within the range that every game supports.
+func validateCardLimit(limit int) error {
+	if limit < bingo.MinCards || limit > bingo.MaxCards {
+		return fmt.Errorf("card limit must be between %d and %d", bingo.MinCards, bingo.MaxCards)
 	}
+	return nil
+}
 
-	game.mtx.Lock()
-	defer game.mtx.Unlock()
+// validateCardCount makes sure that a player is allowed to have the given
+// number of cards. This method is NOT thread-safe.
+func (g *Game) validateCardCount(count int) error {
+	if count < bingo.MinCards || count > g.maxCardsPerPlayer {
+		return fmt.Errorf("players must have between %d and %d cards", bingo.MinCards, g.maxCardsPerPlayer)
+	}
+	return nil
+}
 
+// setDaubValue updates a single cell on one of a player's cards, returning
+// information about the updated cell.
+func setDaubValue(game *Game, command bingo.GameCommand, daubValue bool) (bingo.GameEventPayloadDaub, error) {
+	var payload bingo.GameEventPayloadDaub
+	phase := game.phase.value()
 	var player *bingo.Player
 	for _, e := range game.cardPlayers {
 		if e.player.ID == command.CommanderID {
@@ -149,16 +332,19 @@ func setDaubValue(game *Game, command bingo.GameCommand, daubValue bool) error {
 		}
 	}
 	if player == nil {
-		return fmt.Errorf("user with ID %q is not in game", command.CommanderID)
+		return payload, fmt.Errorf("user with ID %q is not in game", command.CommanderID)
+	}
+	if phase == bingo.GamePhaseTiebreaker && !game.tiebreaker.canDaub(player.ID) {
+		return payload, errors.New("only players in a next line tiebreaker can change daubs during the tiebreaker")
 	}
 
 	parsed := &bingo.GameCommandPayloadPlayerDaub{}
 	if err := json.Unmarshal(command.Payload, parsed); err != nil {
-		return fmt.Errorf("unable to parse daub payload: %v", err)
+		return payload, fmt.Errorf("unable to parse daub payload: %v", err)
 	}
-	ball, err := bingo.ParseBall(parsed.Cell)
-	if err != nil {
-		return fmt.Errorf("%d is not a valid bingo ball", parsed.Cell)
+	ball, err := game.variant.ParseBall(parsed.Cell)
+	if err != nil || (ball == bingo.FreeSpace && !game.variant.FreeSpace) {
+		return payload, fmt.Errorf("%d is not a valid bingo ball", parsed.Cell)
 	}
 
 	var card *bingo.Card
@@ -169,19 +355,23 @@ func setDaubValue(game *Game, command bingo.GameCommand, daubValue bool) error {
 		}
 	}
 	if card == nil {
-		return fmt.Errorf("player %q does not have card with ID %q", player.Name, parsed.CardID)
+		return payload, fmt.Errorf("player %q does not have card with ID %q", player.Name, parsed.CardID)
 	}
 
 	// Actually daub the card - have to treat free space separately because it's
-	// the one value that doesn't work with colIndex's math formula
+	// the one value that doesn't belong to any column's range
 	if ball == bingo.FreeSpace {
-		cell := card.Cells[2][2]
+		cell := card.Cells[game.variant.Rows/2][game.variant.Cols()/2]
 		cell.Daubed = daubValue
-		return nil
+		payload.CardID = card.ID
+		payload.Ball = ball
+		return payload, nil
 	}
-	colIndex := (int(ball) - 1) / bingo.MaxBallValue
+	colIndex := slices.IndexFunc(game.variant.Columns, func(r bingo.BallRange) bool {
+		return r.Min <= ball && ball <= r.Max
+	})
 	var cell *bingo.Cell
-	for i := 0; i < 5; i++ {
+	for i := 0; colIndex != -1 && i < len(card.Cells); i++ {
 		c := card.Cells[i][colIndex]
 		if c.Number == ball {
 			cell = c
@@ -189,9 +379,11 @@ func setDaubValue(game *Game, command bingo.GameCommand, daubValue bool) error {
 		}
 	}
 	if cell == nil {
-		return fmt.Errorf("value %d does not exist in card %q", ball, card.ID)
+		return payload, fmt.Errorf("value %d does not exist in card %q", ball, card.ID)
 	}
 	cell.Daubed = daubValue
 
-	return nil
+	payload.CardID = card.ID
+	payload.Ball = ball
+	return payload, nil
 }
@@ -1,19 +1,12 @@
 package game
 
 import (
-	"fmt"
+	"errors"
 
 	"github.com/google/uuid"
 )
 
 func (g *Game) processSystemDispose(entityID uuid.UUID) error {
-	if entityID != g.systemID {
-		return fmt.Errorf("cannot fulfill system command for non-system. Received ID %q", entityID)
-	}
-
-	g.mtx.Lock()
-	defer g.mtx.Unlock()
-
 	var err error
 	if g.dispose != nil {
 		err = g.dispose()
@@ -22,12 +15,34 @@ func (g *Game) processSystemDispose(entityID uuid.UUID) error {
 }
 
 func (g *Game) processSystemBroadcastState(entityID uuid.UUID) error {
-	if entityID != g.systemID {
-		return fmt.Errorf("cannot fulfill system command for non-system. Received ID %q", entityID)
-	}
+	return errTodo
+}
 
-	g.mtx.Lock()
-	defer g.mtx.Unlock()
+func (g *Game) processSystemAutoCallBall(entityID uuid.UUID) error {
+	// The timer for a call can fire after the auto-caller has been paused or
+	// rescheduled, so every call has to be checked against the current
+	// schedule
+	nextCallAt := g.autoCaller.nextCallAt
+	if !g.autoCallerActive() || nextCallAt.IsZero() {
+		return errors.New("auto-caller is not running")
+	}
+	if g.now().Before(nextCallAt) {
+		return errors.New("auto-caller is not due to call a ball yet")
+	}
+	// Otherwise the auto-caller would keep saying it's running without ever
+	// calling another ball
+	if len(g.ballRegistry.getUncalledBalls()) == 0 {
+		g.pauseAutoCallerForEmptyDraw(entityID)
+		return nil
+	}
+	if err := g.prepareForBallCall(entityID); err != nil {
+		return err
+	}
 
-	return errTodo
+	ball, err := g.ballRegistry.nextAutomaticCall()
+	if err != nil {
+		return err
+	}
+	g.dispatchBallCalled(ball, entityID)
+	return nil
 }
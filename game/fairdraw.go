@@ -0,0 +1,107 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// drawCommitmentPrefix is hashed in front of every draw commitment, so that
+// the format can be changed later without any ambiguity
+const drawCommitmentPrefix = "bingo-draw:v1"
+
+// shuffleDraw produces every ball for a single round (from 1 to maxBall),
+// shuffled with the given seed. The balls are in the order that the ball
+// registry stores them in, which means that the last ball is drawn first.
+func shuffleDraw(seed int64, maxBall int) []bingo.Ball {
+	balls := generateBingoBallsForRange(1, maxBall)
+	newShuffler(NewSeededRNG(seed)).shuffleBalls(balls)
+	return balls
+}
+
+// drawOrderFor turns the balls stored by the ball registry into the order they
+// would be drawn in.
+func drawOrderFor(uncalled []bingo.Ball) []bingo.Ball {
+	order := slices.Clone(uncalled)
+	slices.Reverse(order)
+	return order
+}
+
+// drawCommitment hashes a round's seed and ball order. The commitment is the
+// hex-encoded SHA-256 hash of a string in the following format (without any
+// whitespace):
+//
+//	bingo-draw:v1:<seed>:<ball 1>,<ball 2>,...,<ball N>
+//
+// The seed and balls are written as base-10 integers, and the balls are listed
+// in draw order.
+func drawCommitment(seed int64, order []bingo.Ball) string {
+	values := make([]string, 0, len(order))
+	for _, b := range order {
+		values = append(values, strconv.Itoa(int(b)))
+	}
+	content := fmt.Sprintf("%s:%d:%s", drawCommitmentPrefix, seed, strings.Join(values, ","))
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// VerifyDraw makes sure that a revealed draw matches the commitment that was
+// published before the round started. It re-runs the shuffler from the
+// revealed seed, so a draw can only pass if:
+//
+//  1. The seed produces the revealed order (skipped for scripted draws)
+//  2. The seed and order hash to the commitment
+//  3. Every ball that wasn't called manually was the next ball in the order
+//     when it was called
+//
+// The commitment should come from the round's draw_committed event, rather
+// than from the reveal itself.
+func VerifyDraw(commitment string, reveal bingo.GameEventPayloadDrawRevealed) error {
+	variant, ok := bingo.LookupVariant(reveal.Variant)
+	if !ok {
+		return fmt.Errorf("draw is for unknown variant %q", reveal.Variant)
+	}
+	if reveal.Scripted {
+		if _, err := completeDrawOrder(reveal.Order, variant.MaxBall); err != nil || len(reveal.Order) != variant.MaxBall {
+			return errors.New("revealed order does not contain every ball exactly once")
+		}
+	} else {
+		expectedOrder := drawOrderFor(shuffleDraw(reveal.Seed, variant.MaxBall))
+		if !slices.Equal(expectedOrder, reveal.Order) {
+			return errors.New("revealed order does not match the order produced by the seed")
+		}
+	}
+	if actual := drawCommitment(reveal.Seed, reveal.Order); actual != commitment {
+		return fmt.Errorf("draw hashes to %s, but the commitment was %s", actual, commitment)
+	}
+	if reveal.Commitment != commitment {
+		return errors.New("reveal does not reference the published commitment")
+	}
+
+	// Manual calls are allowed to come from anywhere in the order, but every
+	// automatic call has to be whatever ball is next
+	remaining := slices.Clone(reveal.Order)
+	manualCalls := slices.Clone(reveal.ManualCalls)
+	for i, ball := range reveal.Called {
+		index := slices.Index(remaining, ball)
+		if index == -1 {
+			return fmt.Errorf("call %d (ball %d) was not in the order, or was called more than once", i+1, ball)
+		}
+		if manualIndex := slices.Index(manualCalls, ball); manualIndex != -1 {
+			manualCalls = slices.Delete(manualCalls, manualIndex, manualIndex+1)
+		} else if index != 0 {
+			return fmt.Errorf("call %d (ball %d) was drawn out of order; expected ball %d", i+1, ball, remaining[0])
+		}
+		remaining = slices.Delete(remaining, index, index+1)
+	}
+	if len(manualCalls) != 0 {
+		return fmt.Errorf("manual calls %v were never called", manualCalls)
+	}
+	return nil
+}
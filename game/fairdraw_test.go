@@ -0,0 +1,228 @@
+package game
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// revealFor produces the reveal for a seeded 75-ball draw, with the first
+// called balls drawn automatically.
+func revealFor(seed int64, called int) bingo.GameEventPayloadDrawRevealed {
+	order := drawOrderFor(shuffleDraw(seed, bingo.Variant75Ball.MaxBall))
+	return bingo.GameEventPayloadDrawRevealed{
+		Round:       1,
+		Variant:     bingo.VariantID75Ball,
+		Commitment:  drawCommitment(seed, order),
+		Seed:        seed,
+		Scripted:    false,
+		Order:       order,
+		Called:      slices.Clone(order[:called]),
+		ManualCalls: []bingo.Ball{},
+	}
+}
+
+func TestVerifyDraw(t *testing.T) {
+	t.Parallel()
+
+	scriptedOrder := make([]bingo.Ball, 0, bingo.Variant75Ball.MaxBall)
+	for b := bingo.Variant75Ball.MaxBall; b >= 1; b-- {
+		scriptedOrder = append(scriptedOrder, bingo.Ball(b))
+	}
+
+	testCases := []struct {
+		name string
+		// commitment is the commitment that was published before the round
+		// started. If it's empty, the reveal's commitment is used.
+		commitment string
+		reveal     func() bingo.GameEventPayloadDrawRevealed
+		// errContains is empty for draws that should pass
+		errContains string
+	}{
+		{
+			name:       "Untouched draw",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				return revealFor(42, 10)
+			},
+			errContains: "",
+		},
+		{
+			name:       "Draw with no calls",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				return revealFor(42, 0)
+			},
+			errContains: "",
+		},
+		{
+			name:       "Legacy draw without a variant",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 5)
+				r.Variant = ""
+				return r
+			},
+			errContains: "",
+		},
+		{
+			name:       "Unknown variant",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 5)
+				r.Variant = "12_ball"
+				return r
+			},
+			errContains: "unknown variant",
+		},
+		{
+			name:       "Order doesn't match the seed",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 5)
+				r.Order[0], r.Order[1] = r.Order[1], r.Order[0]
+				return r
+			},
+			errContains: "does not match the order produced by the seed",
+		},
+		{
+			name:       "Seed swapped for a different one",
+			commitment: revealFor(42, 0).Commitment,
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(7, 5)
+				r.Commitment = revealFor(42, 0).Commitment
+				return r
+			},
+			errContains: "but the commitment was",
+		},
+		{
+			name:       "Reveal references a different commitment",
+			commitment: revealFor(42, 0).Commitment,
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 5)
+				r.Commitment = strings.Repeat("0", 64)
+				return r
+			},
+			errContains: "does not reference the published commitment",
+		},
+		{
+			name:       "Automatic call out of order",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 5)
+				r.Called[3], r.Called[4] = r.Called[4], r.Called[3]
+				return r
+			},
+			errContains: "was drawn out of order",
+		},
+		{
+			name:       "Ball called twice",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 5)
+				r.Called = append(r.Called, r.Called[0])
+				return r
+			},
+			errContains: "called more than once",
+		},
+		{
+			name:       "Manual call out of order",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 2)
+				manual := r.Order[40]
+				r.Called = append(r.Called, manual, r.Order[2])
+				r.ManualCalls = []bingo.Ball{manual}
+				return r
+			},
+			errContains: "",
+		},
+		{
+			name:       "Manual call that never happened",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				r := revealFor(42, 2)
+				r.ManualCalls = []bingo.Ball{r.Order[40]}
+				return r
+			},
+			errContains: "were never called",
+		},
+		{
+			name:       "Scripted draw",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				return bingo.GameEventPayloadDrawRevealed{
+					Round:       1,
+					Variant:     bingo.VariantID75Ball,
+					Commitment:  drawCommitment(0, scriptedOrder),
+					Seed:        0,
+					Scripted:    true,
+					Order:       slices.Clone(scriptedOrder),
+					Called:      slices.Clone(scriptedOrder[:3]),
+					ManualCalls: []bingo.Ball{},
+				}
+			},
+			errContains: "",
+		},
+		{
+			name:       "Scripted draw missing a ball",
+			commitment: "",
+			reveal: func() bingo.GameEventPayloadDrawRevealed {
+				order := slices.Clone(scriptedOrder[1:])
+				return bingo.GameEventPayloadDrawRevealed{
+					Round:       1,
+					Variant:     bingo.VariantID75Ball,
+					Commitment:  drawCommitment(0, order),
+					Seed:        0,
+					Scripted:    true,
+					Order:       order,
+					Called:      []bingo.Ball{},
+					ManualCalls: []bingo.Ball{},
+				}
+			},
+			errContains: "every ball exactly once",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reveal := tc.reveal()
+			commitment := tc.commitment
+			if commitment == "" {
+				commitment = reveal.Commitment
+			}
+
+			err := VerifyDraw(commitment, reveal)
+			if tc.errContains == "" {
+				if err != nil {
+					t.Fatalf("expected draw to pass, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+				t.Fatalf("expected error containing %q, got %v", tc.errContains, err)
+			}
+		})
+	}
+}
+
+func TestVerifyDrawFromGame(t *testing.T) {
+	t.Parallel()
+
+	registry := newBallRegistry(NewSeededRNG(99), bingo.Variant75Ball.MaxBall)
+	registry.reset()
+	commitment, _ := registry.commitment()
+	for range 20 {
+		if _, err := registry.nextAutomaticCall(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := VerifyDraw(commitment, registry.reveal(1, bingo.VariantID75Ball)); err != nil {
+		t.Fatalf("expected draw from the registry to pass, got %v", err)
+	}
+}
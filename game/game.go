@@ -3,10 +3,12 @@
 package game
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/Parkreiner/bingo"
 	"github.com/google/uuid"
@@ -23,8 +25,9 @@ const (
 // game, as well as any state necessary for that player to perform actions
 // (including leaving the game)
 type playerEntry struct {
-	leaveGame func() error
-	player    *bingo.Player
+	leaveGame   func() error
+	unsubscribe func()
+	player      *bingo.Player
 }
 
 type commandSession struct {
@@ -39,20 +42,72 @@ type Game struct {
 	cardRegistry cardRegistry
 	ballRegistry ballRegistry
 	host         *bingo.Player
+	// hostUnsubscribe unsubscribes the current host from the game's events.
+	// It is used when the host hands the game over to someone else.
+	hostUnsubscribe func()
+	// coHosts contains every card player who has been given some of the
+	// host's permissions, in the order they were appointed
+	coHosts []*bingo.CoHost
 	// cardPlayers represents all the players currently in the game (minus the
 	// host)
 	cardPlayers []*playerEntry
 	// bingoCallerPlayerIDs refers to all players who are currently claiming to
 	// have bingo.
 	bingoCallerPlayerIDs []uuid.UUID
+	// acceptedCallerPlayerIDs contains every bingo caller whose call the host
+	// has accepted, and who can be made to play a tiebreaker. It is always a
+	// subset of bingoCallerPlayerIDs.
+	acceptedCallerPlayerIDs []uuid.UUID
 	// winningPlayers keeps track of which player(s) were responsible for
 	// winning a given round. The whole player is stored because it's possible
 	// for a player to leave the game, so there's no guarantee that an ID in
 	// winningPlayers would match with the cardPlayers field. This field cannot
 	// be used to derive the round count, because it's possible for multiple
 	// players to win in a single round.
-	winningPlayers     []*bingo.Player
-	suspensions        []*bingo.PlayerSuspension
+	winningPlayers []*bingo.Player
+	// winPattern is the pattern that players need to daub on a single card
+	// to win the current round
+	winPattern bingo.WinPattern
+	// maxCardsPerPlayer is the host's limit on how many cards each player is
+	// allowed to have
+	maxCardsPerPlayer int
+	// variant describes the balls, cards, and win patterns for the style of
+	// bingo being played. It can't be changed once the game is created.
+	variant bingo.Variant
+	// callingMode determines whether the host requests balls from the ball
+	// registry, or syncs them from a physical ball machine
+	callingMode bingo.CallingMode
+	autoCaller  autoCaller
+	// tiebreaker is the tiebreaker currently being played. It is nil outside
+	// of the tiebreaker phase.
+	tiebreaker  *tiebreaker
+	suspensions []*bingo.PlayerSuspension
+	// version increases every time the game state changes, so that clients
+	// can tell how far behind they are
+	version uint64
+	// sentSnapshots keeps track of the most recent snapshots handed out to
+	// the host and each card player, so that later updates can be sent as
+	// diffs
+	sentSnapshots map[uuid.UUID][]sentSnapshot
+	journal       Journal
+	// journalEvents contains every event dispatched while applying the
+	// current change, so that they can be recorded alongside it
+	journalEvents []bingo.GameEvent
+	// needsCheckpoint indicates that the current change did something that
+	// can't be replayed deterministically (usually because it involved
+	// randomness), so the journal needs a full checkpoint
+	needsCheckpoint bool
+	// uncheckpointed counts how many changes have been recorded since the
+	// last checkpoint
+	uncheckpointed int
+	ids            IDSource
+	clock          Clock
+	// changeTime is when the change currently being applied started. Every
+	// event dispatched during a change shares the same timestamp.
+	changeTime time.Time
+	// recording contains every input the game has received. It will be nil
+	// if the game can't be replayed from scratch.
+	recording          *Recording
 	bannedPlayerIDs    []uuid.UUID
 	phase              phase
 	systemID           uuid.UUID
@@ -61,6 +116,7 @@ type Game struct {
 	maxPlayers         int
 	dispose            func() error
 	commandChan        chan commandSession
+	terminated         chan struct{}
 	mtx                sync.Mutex
 	phaseSubscriptions subscriptionsManager
 }
@@ -69,86 +125,307 @@ var _ bingo.GameManager = &Game{}
 
 // Init is used to instantiate a Game instance via the New function
 type Init struct {
-	creatorID  uuid.UUID
-	hostID     uuid.UUID
-	hostName   string
-	rngSeed    int64
-	maxPlayers *int
-	maxRounds  *int
+	// CreatorID is the ID of the system that is creating the game. It is the
+	// only ID allowed to issue system commands.
+	CreatorID uuid.UUID
+	HostID    uuid.UUID
+	HostName  string
+	// RNGSeed is used to seed CardRNG when it is nil, along with IDSource
+	RNGSeed int64
+	// BallSeed is used to seed BallRNG when it is nil. It is kept separate
+	// from RNGSeed, because every player sees the IDs and cards that RNGSeed
+	// produces, and could use them to work out RNGSeed. If it is zero, a
+	// secure random seed is picked instead (which still ends up in the
+	// recording).
+	BallSeed int64
+	// BallRNG is the source used to shuffle each round's balls, and CardRNG
+	// is the source used to generate cards. Each game needs its own sources.
+	// A game that uses a custom source can't be replayed from its recording,
+	// since the recording only contains the seeds.
+	BallRNG RNG
+	CardRNG RNG
+	// MaxPlayers and MaxRounds will fall back to the package defaults if they
+	// are nil
+	MaxPlayers *int
+	MaxRounds  *int
+	// MaxCardsPerPlayer will fall back to bingo.MaxCards if it is nil
+	MaxCardsPerPlayer *int
+	// Variant will fall back to 75-ball bingo if it is empty
+	Variant bingo.VariantID
+	// WinPattern will fall back to the variant's default pattern if it is nil
+	WinPattern *bingo.WinPattern
+	// CallingMode will fall back to bingo.CallingModeAutomatic if it is nil
+	CallingMode *bingo.CallingMode
+	// BannedPlayerIDs lists players who are banned before the game even
+	// starts (e.g., players the host banned in one of their earlier games)
+	BannedPlayerIDs []uuid.UUID
+	// Journal is optional. If it is defined, every change made to the game
+	// will be recorded to it, so that the game can be restored later.
+	Journal Journal
+	// IDSource will fall back to a source seeded with RNGSeed if it is nil,
+	// which makes it possible to replay the game with only its seed and
+	// recording. Clock will fall back to the system clock if it is nil.
+	IDSource IDSource
+	Clock    Clock
 }
 
 // New creates a new instance of a Game
 func New(init Init) (*Game, error) {
+	game, err := newGame(init)
+	if err != nil {
+		return nil, err
+	}
+	if err := game.start(); err != nil {
+		return nil, err
+	}
+
+	// The journal always needs a checkpoint to replay entries on top of
+	game.mtx.Lock()
+	defer game.mtx.Unlock()
+	game.journal = init.Journal
+	if err := game.checkpoint(); err != nil {
+		_ = game.dispose()
+		return nil, fmt.Errorf("failed to initialize: %v", err)
+	}
+	return game, nil
+}
+
+// newGame produces a game that has been configured, but hasn't started any of
+// its background processes.
+func newGame(init Init) (*Game, error) {
+	variant, ok := bingo.LookupVariant(init.Variant)
+	if !ok {
+		return nil, fmt.Errorf("failed to initialize: unknown variant %q", init.Variant)
+	}
+
 	host := &bingo.Player{
 		Status:        bingo.PlayerStatusHost,
-		ID:            init.hostID,
-		Name:          init.hostName,
+		ID:            init.HostID,
+		Name:          init.HostName,
 		Cards:         nil,
 		EventReceiver: nil,
 	}
 
 	game := &Game{
-		systemID:           init.creatorID,
+		systemID:           init.CreatorID,
 		host:               host,
 		maxRounds:          defaultMaxRounds,
 		maxPlayers:         defaultMaxPlayers,
-		ballRegistry:       *newBallRegistry(init.rngSeed),
-		cardRegistry:       *newCardRegistry(init.rngSeed),
+		winPattern:         variant.DefaultWinPattern(),
+		maxCardsPerPlayer:  bingo.MaxCards,
+		variant:            variant,
+		callingMode:        bingo.CallingModeAutomatic,
+		autoCaller:         newAutoCaller(),
 		phaseSubscriptions: newSubscriptionsManager(),
+		ids:                init.IDSource,
+		clock:              init.Clock,
 
 		// Unbuffered to have synchronization guarantees
-		commandChan:          make(chan commandSession),
-		phase:                newPhase(),
-		currentRound:         0,
-		cardPlayers:          nil,
-		winningPlayers:       nil,
-		bingoCallerPlayerIDs: nil,
-		suspensions:          nil,
-		bannedPlayerIDs:      nil,
-		dispose:              nil,
+		commandChan:             make(chan commandSession),
+		terminated:              make(chan struct{}),
+		phase:                   newPhase(),
+		currentRound:            0,
+		cardPlayers:             nil,
+		winningPlayers:          nil,
+		bingoCallerPlayerIDs:    nil,
+		acceptedCallerPlayerIDs: nil,
+		coHosts:                 nil,
+		tiebreaker:              nil,
+		suspensions:             nil,
+		bannedPlayerIDs:         slices.Clone(init.BannedPlayerIDs),
+		dispose:                 nil,
+		version:                 0,
+		sentSnapshots:           map[uuid.UUID][]sentSnapshot{},
+		journal:                 nil,
+		journalEvents:           nil,
+		needsCheckpoint:         false,
+		uncheckpointed:          0,
+		changeTime:              time.Time{},
+		recording:               nil,
+	}
+	if game.ids == nil {
+		game.ids = NewSeededIDSource(init.RNGSeed + idSeedOffset)
+	}
+	if game.clock == nil {
+		game.clock = systemClock{}
+	}
+	ballSeed := init.BallSeed
+	if ballSeed == 0 {
+		ballSeed = NewCryptoRNG().Int63()
+	}
+	ballRNG := init.BallRNG
+	if ballRNG == nil {
+		ballRNG = NewSeededRNG(ballSeed)
+	}
+	cardRNG := init.CardRNG
+	if cardRNG == nil {
+		cardRNG = NewSeededRNG(init.RNGSeed)
 	}
-	if init.maxRounds != nil {
-		game.maxRounds = *init.maxRounds
+	game.ballRegistry = *newBallRegistry(ballRNG, variant.MaxBall)
+	game.cardRegistry = *newCardRegistry(cardRNG, variant, game.ids.NewID)
+	game.phaseSubscriptions.newID = game.ids.NewID
+	game.phaseSubscriptions.now = game.now
+
+	if init.MaxRounds != nil {
+		game.maxRounds = *init.MaxRounds
+	}
+	if init.MaxPlayers != nil {
+		game.maxPlayers = *init.MaxPlayers
+	}
+	if init.MaxCardsPerPlayer != nil {
+		if err := validateCardLimit(*init.MaxCardsPerPlayer); err != nil {
+			return nil, fmt.Errorf("failed to initialize: %v", err)
+		}
+		game.maxCardsPerPlayer = *init.MaxCardsPerPlayer
+	}
+	if init.WinPattern != nil {
+		if err := variant.ValidateWinPattern(*init.WinPattern); err != nil {
+			return nil, fmt.Errorf("failed to initialize: %v", err)
+		}
+		game.winPattern = *init.WinPattern
+	}
+	if init.CallingMode != nil {
+		if err := init.CallingMode.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to initialize: %v", err)
+		}
+		game.callingMode = *init.CallingMode
 	}
-	if init.maxPlayers != nil {
-		game.maxPlayers = *init.maxPlayers
+
+	// Every event dispatched while the game is locked belongs to whatever
+	// change is currently being applied
+	game.phaseSubscriptions.observer = func(event bingo.GameEvent) {
+		game.journalEvents = append(game.journalEvents, event)
+	}
+	game.recording = &Recording{
+		CreatorID:         init.CreatorID,
+		HostID:            init.HostID,
+		HostName:          init.HostName,
+		RNGSeed:           init.RNGSeed,
+		BallSeed:          ballSeed,
+		Variant:           variant.ID,
+		MaxPlayers:        game.maxPlayers,
+		MaxRounds:         game.maxRounds,
+		MaxCardsPerPlayer: game.maxCardsPerPlayer,
+		WinPattern:        game.winPattern,
+		CallingMode:       game.callingMode,
+		BannedPlayerIDs:   slices.Clone(init.BannedPlayerIDs),
+		Steps:             []RecordedStep{},
+	}
+	if init.BallRNG != nil || init.CardRNG != nil {
+		game.recording = nil
+	}
+	return game, nil
+}
+
+// changedState indicates whether the change currently being applied has
+// changed the game's state. Every change to the state dispatches at least
+// one event that isn't an error, so failed commands that didn't dispatch any
+// of those can be safely ignored. This method is NOT thread-safe.
+func (g *Game) changedState() bool {
+	return slices.ContainsFunc(g.journalEvents, func(event bingo.GameEvent) bool {
+		return event.Type != bingo.EventTypeError
+	})
+}
+
+// beginChange marks the start of a new change to the game. This method is NOT
+// thread-safe.
+func (g *Game) beginChange() {
+	g.changeTime = g.clock.Now()
+	g.journalEvents = nil
+}
+
+// now returns the timestamp for any events dispatched during the current
+// change. This method is NOT thread-safe.
+func (g *Game) now() time.Time {
+	if g.changeTime.IsZero() {
+		return g.clock.Now()
 	}
+	return g.changeTime
+}
 
+// start kicks off all of a game's background processes, and subscribes the
+// host to the game's events.
+func (g *Game) start() error {
 	// Make sure to do things that can fail first, before we get too far into
 	// the initialization
-	terminateCardRegistry, err := game.cardRegistry.Start()
+	terminateCardRegistry, err := g.cardRegistry.Start()
 	if err != nil {
-		game.phase.setValue(bingo.GamePhaseInitializationFailure)
-		return nil, fmt.Errorf("failed to initialize: %v", err)
+		g.phase.setValue(bingo.GamePhaseInitializationFailure)
+		return fmt.Errorf("failed to initialize: %v", err)
+	}
+
+	hostEventChan, hostUnsubscribe, err := g.phaseSubscriptions.subscribe(nil, []uuid.UUID{g.host.ID})
+	if err != nil {
+		terminateCardRegistry()
+		g.phase.setValue(bingo.GamePhaseInitializationFailure)
+		return fmt.Errorf("failed to subscribe host: %v", err)
 	}
+	g.host.EventReceiver = hostEventChan
+	g.hostUnsubscribe = hostUnsubscribe
 
 	disposed := false
-	game.dispose = func() error {
+	g.dispose = func() error {
 		if disposed {
 			return nil
 		}
 
-		close(game.commandChan)
+		// The command channel is never closed, because other goroutines
+		// might be in the middle of sending to it. Closing the terminated
+		// channel instead makes any pending sends bail out
+		_ = g.phase.setValue(bingo.GamePhaseGameOver)
+		close(g.terminated)
+		g.autoCaller.stop()
 		terminateCardRegistry()
-		err := game.phaseSubscriptions.dispose(game.systemID)
+		err := g.phaseSubscriptions.dispose(g.systemID)
 		disposed = true
 		return err
 	}
 
 	go func() {
-		for session := range game.commandChan {
-			err := game.routeCommand(session.command)
-			session.errorChan <- err
+		for {
+			select {
+			case <-g.terminated:
+				return
+			case session := <-g.commandChan:
+				// Each command gets applied in a single critical section, so
+				// that every state version describes exactly one state
+				g.mtx.Lock()
+				g.beginChange()
+				err := g.routeCommand(session.command)
+				g.syncAutoCaller()
+				g.recordStep(RecordedStep{
+					At:      g.changeTime,
+					Command: &session.command,
+				}, err)
+				// Some commands fail partway through, after they have already
+				// changed the game's state. Those changes still need their
+				// own version, or else they'd never reach the journal or any
+				// viewers.
+				if err == nil || g.changedState() {
+					g.version++
+					err = errors.Join(err, g.recordChange(&session.command, err))
+				}
+				g.journalEvents = nil
+				g.mtx.Unlock()
+				session.errorChan <- err
+			}
 		}
 	}()
 
-	return game, nil
+	return nil
 }
 
+// routeCommand passes a command to the correct handler, once it has been
+// checked against the command's policy (see commandPolicies). This method is
+// NOT thread-safe; the command loop locks the game's mutex for the entire
+// duration of the call, so none of the handlers should try locking it again.
 func (g *Game) routeCommand(command bingo.GameCommand) error {
 	if !g.phase.ok() {
 		return errors.New("cannot route command for terminated game")
 	}
+	if err := g.authorizeCommand(command); err != nil {
+		return err
+	}
 
 	switch command.Type {
 	// System commands
@@ -156,26 +433,50 @@ func (g *Game) routeCommand(command bingo.GameCommand) error {
 		return g.processSystemDispose(command.CommanderID)
 	case bingo.GameCommandSystemBroadcastState:
 		return g.processSystemBroadcastState(command.CommanderID)
+	case bingo.GameCommandSystemAutoCallBall:
+		return g.processSystemAutoCallBall(command.CommanderID)
 
 	// Host commands
 	case bingo.GameCommandHostStartGame:
-		return errTodo
+		return g.processStartGame(command.CommanderID)
 	case bingo.GameCommandHostTerminateGame:
-		return errTodo
+		return g.processTerminateGame(command.CommanderID)
 	case bingo.GameCommandHostBanPlayer:
-		return errTodo
+		return g.processBanPlayer(command)
+	case bingo.GameCommandHostTransferHostStatus:
+		return g.processTransferHostStatus(command)
+	case bingo.GameCommandHostAppointCoHost:
+		return g.processAppointCoHost(command)
+	case bingo.GameCommandHostDismissCoHost:
+		return g.processDismissCoHost(command)
 	case bingo.GameCommandHostSuspendPlayer:
-		return errTodo
+		return g.processSuspendPlayer(command)
 	case bingo.GameCommandHostRequestBall:
 		return g.processAutomaticBall(command.CommanderID)
 	case bingo.GameCommandHostSyncBall:
-		return errTodo
+		return g.processSyncBall(command)
 	case bingo.GameCommandHostAcknowledgeBingoCall:
-		return errTodo
+		return g.processAcknowledgeBingoCall(command)
+	case bingo.GameCommandHostSetCallingMode:
+		return g.processSetCallingMode(command)
+	case bingo.GameCommandHostUndoBall:
+		return g.processUndoBall(command)
+	case bingo.GameCommandHostEndRound:
+		return g.processEndRound(command.CommanderID)
+	case bingo.GameCommandHostStartAutoCaller:
+		return g.processStartAutoCaller(command)
+	case bingo.GameCommandHostPauseAutoCaller:
+		return g.processPauseAutoCaller(command.CommanderID)
+	case bingo.GameCommandHostSetAutoCallerSpeed:
+		return g.processSetAutoCallerSpeed(command)
 	case bingo.GameCommandHostStartTiebreakerRound:
-		return errTodo
+		return g.processStartTiebreakerRound(command)
+	case bingo.GameCommandHostSetWinPattern:
+		return g.processSetWinPattern(command)
+	case bingo.GameCommandHostSetCardLimit:
+		return g.processSetCardLimit(command)
 	case bingo.GameCommandHostAwardPlayers:
-		return errTodo
+		return g.processAwardPlayers(command)
 
 	// Player commands
 	case bingo.GameCommandPlayerDaub:
@@ -183,11 +484,15 @@ func (g *Game) routeCommand(command bingo.GameCommand) error {
 	case bingo.GameCommandPlayerUndoDaub:
 		return g.processPlayerUndoDaub(command)
 	case bingo.GameCommandPlayerCallBingo:
-		return errTodo
+		return g.processCallBingo(command.CommanderID)
 	case bingo.GameCommandPlayerRescindBingo:
-		return errTodo
+		return g.processRescindBingo(command.CommanderID)
 	case bingo.GameCommandPlayerReplaceCards:
-		return g.processHandReplacement(command.CommanderID)
+		return g.processHandReplacement(command)
+	case bingo.GameCommandPlayerCheckOutStrip:
+		return g.processStripCheckout(command.CommanderID)
+	case bingo.GameCommandPlayerReplaceSelectedCards:
+		return g.processSelectedCardReplacement(command)
 
 	default:
 		return fmt.Errorf("received unknown command %q", command.Type)
@@ -196,15 +501,32 @@ func (g *Game) routeCommand(command bingo.GameCommand) error {
 
 // JoinGame allows a player to join a game as a normal player. The method will
 // prevent a player with the same ID from joining a game multiple times. If the
-// join attempt is successful, the returned player will be given a full hand of
-// bingo cards, ready to use.
+// join attempt is successful, the returned player will be given cardCount
+// bingo cards, ready to use. A cardCount of zero gives the player as many cards
+// as the host allows.
 //
 // The returned callback lets a user leave the game. Calling the callback more
 // than once results in a no-op.
-func (g *Game) JoinGame(playerID uuid.UUID, playerName string) (*bingo.Player, func() error, error) {
+func (g *Game) JoinGame(playerID uuid.UUID, playerName string, cardCount int) (*bingo.Player, func() error, error) {
 	g.mtx.Lock()
 	defer g.mtx.Unlock()
 
+	g.beginChange()
+	player, leaveGame, err := g.joinGame(playerID, playerName, cardCount)
+	g.recordStep(RecordedStep{
+		At: g.changeTime,
+		Join: &RecordedJoin{
+			PlayerID:  playerID,
+			Name:      playerName,
+			CardCount: cardCount,
+		},
+	}, err)
+	return player, leaveGame, err
+}
+
+// joinGame handles the core logic for JoinGame. This method is NOT
+// thread-safe.
+func (g *Game) joinGame(playerID uuid.UUID, playerName string, cardCount int) (*bingo.Player, func() error, error) {
 	if !g.phase.ok() {
 		return nil, nil, errors.New("cannot join game that has been terminated")
 	}
@@ -219,16 +541,19 @@ func (g *Game) JoinGame(playerID uuid.UUID, playerName string) (*bingo.Player, f
 	}
 
 	// Only make a new entry if it doesn't exist in the game at all
-	var prevEntry *playerEntry
-	for _, e := range g.cardPlayers {
-		if e.player.ID == playerID {
-			prevEntry = e
-			break
-		}
-	}
+	prevEntry := g.findPlayerEntry(playerID)
 	if prevEntry != nil {
 		return prevEntry.player, prevEntry.leaveGame, nil
 	}
+	if len(g.cardPlayers) >= g.maxPlayers {
+		return nil, nil, fmt.Errorf("game is already at capacity of %d players", g.maxPlayers)
+	}
+	if cardCount == 0 {
+		cardCount = g.maxCardsPerPlayer
+	}
+	if err := g.validateCardCount(cardCount); err != nil {
+		return nil, nil, err
+	}
 
 	eventChan, unsub, err := g.phaseSubscriptions.subscribe(nil, []uuid.UUID{playerID})
 	if err != nil {
@@ -236,11 +561,17 @@ func (g *Game) JoinGame(playerID uuid.UUID, playerName string) (*bingo.Player, f
 	}
 
 	var cards []*bingo.Card
-	for i := 0; i < bingo.MaxCards; i++ {
+	for i := 0; i < cardCount; i++ {
 		card, err := g.cardRegistry.CheckOutCard(playerID)
 		if err != nil {
 			unsub()
-			return nil, nil, fmt.Errorf("unable to produce card %d for player %q (ID %s): %v", i+1, playerName, playerID, err)
+			err = fmt.Errorf("unable to produce card %d for player %q (ID %s): %v", i+1, playerName, playerID, err)
+			// The player is never created, so nobody else would ever return
+			// the cards they already got
+			for _, c := range cards {
+				err = errors.Join(err, g.cardRegistry.ReturnCard(c.ID))
+			}
+			return nil, nil, err
 		}
 		cards = append(cards, card)
 	}
@@ -248,6 +579,11 @@ func (g *Game) JoinGame(playerID uuid.UUID, playerName string) (*bingo.Player, f
 	if g.phase.value() == bingo.GamePhaseRoundStart {
 		status = bingo.PlayerStatusActive
 	}
+	// Leaving doesn't clear a suspension, so a player who rejoins has to sit
+	// out whatever is left of it
+	if g.findSuspension(playerID) != nil {
+		status = bingo.PlayerStatusSuspended
+	}
 	player := &bingo.Player{
 		Status:        status,
 		ID:            playerID,
@@ -256,50 +592,248 @@ func (g *Game) JoinGame(playerID uuid.UUID, playerName string) (*bingo.Player, f
 		EventReceiver: eventChan,
 	}
 
+	newEntry := g.newPlayerEntry(player, unsub)
+	g.cardPlayers = append(g.cardPlayers, newEntry)
+	g.needsCheckpoint = true
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypePlayerJoined,
+		CreatedByID: playerID,
+		Message:     fmt.Sprintf("player %q has joined the game", playerName),
+		Payload: eventPayload(bingo.GameEventPayloadPlayer{
+			PlayerID: playerID,
+			Name:     playerName,
+			Status:   status,
+		}),
+		RecipientIDs: nil,
+	})
+	g.version++
+	err = g.recordChange(nil, nil)
+	g.journalEvents = nil
+	return newEntry.player, newEntry.leaveGame, err
+}
+
+// newPlayerEntry wraps a player in a new entry, including the callback for
+// leaving the game. It does not add the entry to the game.
+func (g *Game) newPlayerEntry(player *bingo.Player, unsubscribe func()) *playerEntry {
 	leftGame := false
-	newEntry := &playerEntry{
-		player: player,
+	return &playerEntry{
+		player:      player,
+		unsubscribe: unsubscribe,
 		leaveGame: func() error {
-			if leftGame {
-				return nil
-			}
-
 			g.mtx.Lock()
 			defer g.mtx.Unlock()
 
-			var removedEntry *playerEntry
-			var remainder []*playerEntry
-			for _, e := range g.cardPlayers {
-				if e.player.ID == playerID {
-					removedEntry = e
-				} else {
-					remainder = append(remainder, e)
-				}
+			if leftGame {
+				return nil
 			}
-			if len(remainder) == len(g.cardPlayers) {
+			g.beginChange()
+			playerID := player.ID
+			isHost := playerID == g.host.ID
+			if !isHost && g.findPlayerEntry(playerID) == nil {
+				// The player was made the host, and has since handed the
+				// game over to someone else, so they're already gone
+				leftGame = true
 				return nil
 			}
 
-			g.cardPlayers = remainder
-			var cardReturnErr error
-			for _, card := range removedEntry.player.Cards {
-				// Don't stop at the first error found, because there's a chance
-				// that the other cards can still be returned/recycled for
-				// future rounds with other players
-				err := g.cardRegistry.ReturnCard(card.ID)
-				if err != nil {
-					cardReturnErr = err
-				}
+			var err error
+			if isHost {
+				err = g.leaveAsHost(playerID)
+			} else {
+				err = g.removePlayerEntry(playerID)
+				g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+					Phase:       g.phase.value(),
+					Type:        bingo.EventTypePlayerLeft,
+					CreatedByID: player.ID,
+					Message:     fmt.Sprintf("player %q has left the game", player.Name),
+					Payload: eventPayload(bingo.GameEventPayloadPlayer{
+						PlayerID: player.ID,
+						Name:     player.Name,
+						Status:   player.Status,
+					}),
+					RecipientIDs: nil,
+				})
+				// Nobody can win a tiebreaker after leaving the game
+				err = errors.Join(err, g.removeFromTiebreaker(playerID, playerID))
 			}
-
-			unsub()
 			leftGame = true
-			return cardReturnErr
+			g.syncAutoCaller()
+			g.recordStep(RecordedStep{
+				At:           g.changeTime,
+				LeftPlayerID: &playerID,
+			}, err)
+			g.version++
+			recordErr := g.recordChange(nil, nil)
+			g.journalEvents = nil
+			return errors.Join(err, recordErr)
 		},
 	}
+}
 
-	g.cardPlayers = append(g.cardPlayers, newEntry)
-	return newEntry.player, newEntry.leaveGame, nil
+// LeaveAsHost lets the host leave the game without ending it. The game is
+// handed over to one of its co-hosts or card players, and the old host stops
+// receiving events. If there is nobody left to take over, the game is
+// terminated instead.
+func (g *Game) LeaveAsHost(hostID uuid.UUID) error {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.beginChange()
+	if !g.phase.ok() {
+		return errors.New("cannot leave game that has been terminated")
+	}
+	if hostID != g.host.ID {
+		err := fmt.Errorf("provided ID %q does not match host ID %q", hostID, g.host.ID)
+		g.recordStep(RecordedStep{At: g.changeTime, LeftHostID: &hostID}, err)
+		return err
+	}
+
+	err := g.leaveAsHost(hostID)
+	g.syncAutoCaller()
+	g.recordStep(RecordedStep{
+		At:         g.changeTime,
+		LeftHostID: &hostID,
+	}, err)
+	g.version++
+	recordErr := g.recordChange(nil, nil)
+	g.journalEvents = nil
+	return errors.Join(err, recordErr)
+}
+
+// removePlayerEntry removes a player from the game, returning all of their
+// cards to the card registry, and unsubscribing them from all future events.
+// Removing a player that is not in the game results in a no-op.
+//
+// This method is NOT thread-safe; the game's mutex must already be locked
+// before calling it.
+func (g *Game) removePlayerEntry(playerID uuid.UUID) error {
+	removedEntry, err := g.detachPlayerEntry(playerID)
+	if removedEntry != nil {
+		removedEntry.unsubscribe()
+	}
+	return err
+}
+
+// detachPlayerEntry works the same way as removePlayerEntry, but leaves the
+// player subscribed to events. It returns the detached entry, or nil if the
+// player is not in the game. This method is NOT thread-safe.
+func (g *Game) detachPlayerEntry(playerID uuid.UUID) (*playerEntry, error) {
+	var removedEntry *playerEntry
+	var remainder []*playerEntry
+	for _, e := range g.cardPlayers {
+		if e.player.ID == playerID {
+			removedEntry = e
+		} else {
+			remainder = append(remainder, e)
+		}
+	}
+	if removedEntry == nil {
+		return nil, nil
+	}
+
+	g.cardPlayers = remainder
+	g.needsCheckpoint = true
+	// Anything the player was sent while they had cards no longer matches what
+	// they can see, and would otherwise stick around for the rest of the game
+	delete(g.sentSnapshots, playerID)
+	g.dropBingoCaller(playerID)
+	// Only card players can be co-hosts
+	g.coHosts = slices.DeleteFunc(g.coHosts, func(c *bingo.CoHost) bool {
+		return c.PlayerID == playerID
+	})
+
+	var cardReturnErr error
+	for _, card := range removedEntry.player.Cards {
+		// Don't stop at the first error found, because there's a chance that
+		// the other cards can still be returned/recycled for future rounds
+		// with other players
+		err := g.cardRegistry.ReturnCard(card.ID)
+		if err != nil {
+			cardReturnErr = err
+		}
+	}
+	return removedEntry, cardReturnErr
+}
+
+// findPlayerEntry returns the entry for the card player with the matching ID,
+// or nil if the player is not in the game. This method is NOT thread-safe.
+func (g *Game) findPlayerEntry(playerID uuid.UUID) *playerEntry {
+	for _, e := range g.cardPlayers {
+		if e.player.ID == playerID {
+			return e
+		}
+	}
+	return nil
+}
+
+// dropBingoCaller forgets a player's bingo call, along with the host's
+// acceptance of it. This method is NOT thread-safe.
+func (g *Game) dropBingoCaller(playerID uuid.UUID) {
+	isPlayer := func(id uuid.UUID) bool {
+		return id == playerID
+	}
+	g.bingoCallerPlayerIDs = slices.DeleteFunc(g.bingoCallerPlayerIDs, isPlayer)
+	g.acceptedCallerPlayerIDs = slices.DeleteFunc(g.acceptedCallerPlayerIDs, isPlayer)
+}
+
+// changePhase updates the game's phase and notifies all subscribers of the
+// change. This method is NOT thread-safe.
+func (g *Game) changePhase(newPhase bingo.GamePhase, commanderID uuid.UUID) error {
+	prevPhase := g.phase.value()
+	if err := g.phase.setValue(newPhase); err != nil {
+		return fmt.Errorf("unable to change phase to %q: %v", newPhase, err)
+	}
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       newPhase,
+		Type:        bingo.EventTypePhaseChanged,
+		CreatedByID: commanderID,
+		Message:     fmt.Sprintf("phase changed from %s to %s (round %d/%d)", prevPhase, newPhase, g.currentRound, g.maxRounds),
+		Payload: eventPayload(bingo.GameEventPayloadPhaseChanged{
+			PreviousPhase: prevPhase,
+			Phase:         newPhase,
+			Round:         g.currentRound,
+			MaxRounds:     g.maxRounds,
+		}),
+		RecipientIDs: nil,
+	})
+	return nil
+}
+
+// eventPayload serializes the payload for a game event. Every payload type is
+// a plain struct, so serialization should never fail in practice; if it does,
+// the event is still dispatched, just without a payload.
+func eventPayload(payload any) json.RawMessage {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// errorEvent produces an event for notifying a single commander that their
+// command could not be processed.
+func (g *Game) errorEvent(command bingo.GameCommand, err error) bingo.GameEvent {
+	return bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypeError,
+		CreatedByID: command.CommanderID,
+		Message:     err.Error(),
+		Payload: eventPayload(bingo.GameEventPayloadError{
+			CommandType: command.Type,
+			Reason:      err.Error(),
+		}),
+		RecipientIDs: []uuid.UUID{command.CommanderID},
+	}
+}
+
+// Host returns the player currently hosting the game. The host's
+// EventReceiver will receive every event targeted at the host.
+func (g *Game) Host() *bingo.Player {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.host
 }
 
 // Subscribe lets an external system subscribe to all events emitted during
@@ -320,23 +854,15 @@ func (g *Game) IssueCommand(command bingo.GameCommand) error {
 	}
 
 	channel := make(chan error)
-	defer close(channel)
-	g.commandChan <- commandSession{
+	select {
+	case g.commandChan <- commandSession{
 		command:   command,
 		errorChan: channel,
+	}:
+	case <-g.terminated:
+		return errors.New("game is not able to accept new commands")
 	}
 
 	err := <-channel
 	return err
 }
-
-// Snapshot produces an immutable snapshot of the entire public game state.
-func (g *Game) Snapshot() bingo.GameSnapshot {
-	g.mtx.Lock()
-	defer g.mtx.Unlock()
-
-	return bingo.GameSnapshot{
-		Phase:  g.phase.value(),
-		Called: g.ballRegistry.getCalledBalls(),
-	}
-}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+var (
+	testSystemID = uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	testHostID   = uuid.MustParse("00000000-0000-4000-8000-000000000002")
+	testStart    = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// newTestGame creates a game with fixed IDs, seeds, and a manual clock, so
+// that every run produces the same cards and balls. Anything left out of init
+// falls back to those defaults. The game is disposed once the test finishes.
+func newTestGame(t *testing.T, init Init) *Game {
+	t.Helper()
+
+	if init.CreatorID == uuid.Nil {
+		init.CreatorID = testSystemID
+	}
+	if init.HostID == uuid.Nil {
+		init.HostID = testHostID
+	}
+	if init.HostName == "" {
+		init.HostName = "host"
+	}
+	if init.RNGSeed == 0 {
+		init.RNGSeed = 1
+	}
+	if init.BallSeed == 0 {
+		init.BallSeed = 2
+	}
+	if init.Clock == nil {
+		init.Clock = NewManualClock(testStart)
+	}
+
+	g, err := New(init)
+	if err != nil {
+		t.Fatalf("unable to create game: %v", err)
+	}
+	drainEvents(g.Host().EventReceiver)
+	t.Cleanup(func() {
+		_ = g.IssueCommand(bingo.GameCommand{
+			Type:        bingo.GameCommandSystemDispose,
+			CommanderID: init.CreatorID,
+			Payload:     nil,
+		})
+	})
+	return g
+}
+
+// drainEvents reads every event sent to a receiver until it is closed, since
+// the game blocks on anyone who isn't reading their events.
+func drainEvents(receiver <-chan bingo.GameEvent) {
+	go func() {
+		for range receiver {
+		}
+	}()
+}
+
+// testPlayerID produces a stable ID for a test player's name.
+func testPlayerID(name string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name))
+}
+
+// joinTestPlayer adds a player to a game, and returns their ID along with the
+// function for leaving the game.
+func joinTestPlayer(t *testing.T, g *Game, name string, cardCount int) (uuid.UUID, func() error) {
+	t.Helper()
+
+	player, leave, err := g.JoinGame(testPlayerID(name), name, cardCount)
+	if err != nil {
+		t.Fatalf("unable to add player %q: %v", name, err)
+	}
+	drainEvents(player.EventReceiver)
+	return player.ID, leave
+}
+
+// issueTestCommand issues a command with a JSON-encoded payload. A nil
+// payload is sent as a nil payload.
+func issueTestCommand(t *testing.T, g *Game, commanderID uuid.UUID, commandType bingo.GameCommandType, payload any) error {
+	t.Helper()
+
+	var encoded []byte
+	if payload != nil {
+		var err error
+		encoded, err = json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("unable to encode payload for %q: %v", commandType, err)
+		}
+	}
+	return g.IssueCommand(bingo.GameCommand{
+		Type:        commandType,
+		CommanderID: commanderID,
+		Payload:     encoded,
+	})
+}
+
+// mustIssueTestCommand works the same way as issueTestCommand, but fails the
+// test if the command is rejected.
+func mustIssueTestCommand(t *testing.T, g *Game, commanderID uuid.UUID, commandType bingo.GameCommandType, payload any) {
+	t.Helper()
+
+	if err := issueTestCommand(t, g, commanderID, commandType, payload); err != nil {
+		t.Fatalf("%q failed: %v", commandType, err)
+	}
+}
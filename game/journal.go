@@ -0,0 +1,420 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// checkpointInterval is the maximum number of entries that can be recorded
+// between checkpoints. It keeps the number of entries that need to be replayed
+// during a restore from growing without bound.
+const checkpointInterval = 50
+
+// Journal durably records every change made to a game, in the exact order the
+// changes were applied. Both methods are called while the game is locked, so
+// implementations should never call back into the game.
+type Journal interface {
+	// Append records a single change. Every change is appended, even if it is
+	// immediately followed by a checkpoint.
+	Append(entry JournalEntry) error
+	// Checkpoint records the complete state of a game. Once a checkpoint has
+	// been recorded, every entry appended before it is no longer needed to
+	// restore the game.
+	Checkpoint(state State) error
+}
+
+// JournalEntry describes a single change made to a game.
+type JournalEntry struct {
+	// Version is the game's state version right after the change was applied
+	Version uint64 `json:"version"`
+	// Command is the command that caused the change. It will be nil for
+	// changes that didn't come from a command (e.g., players joining or
+	// leaving); those changes are always followed by a checkpoint, and never
+	// need to be replayed.
+	Command *bingo.GameCommand `json:"command"`
+	// Error is the error that the command produced, for commands that failed
+	// partway through, after they had already changed the game's state.
+	// Replaying the command must produce the exact same error.
+	Error string `json:"error,omitempty"`
+	// Events contains every event dispatched as a result of the change
+	Events []bingo.GameEvent `json:"events"`
+}
+
+// State is a complete, serializable copy of a game's internal state. It
+// contains private information for every player, so it should never be sent
+// to clients; use the snapshot methods for that.
+type State struct {
+	Version           uint64                   `json:"version"`
+	Phase             bingo.GamePhase          `json:"phase"`
+	Variant           bingo.VariantID          `json:"variant"`
+	CurrentRound      int                      `json:"currentRound"`
+	MaxRounds         int                      `json:"maxRounds"`
+	MaxPlayers        int                      `json:"maxPlayers"`
+	MaxCardsPerPlayer int                      `json:"maxCardsPerPlayer"`
+	WinPattern        bingo.WinPattern         `json:"winPattern"`
+	CallingMode       bingo.CallingMode        `json:"callingMode"`
+	AutoCaller        AutoCallerState          `json:"autoCaller"`
+	Host              PlayerState              `json:"host"`
+	CoHosts           []bingo.CoHost           `json:"coHosts"`
+	Players           []PlayerState            `json:"players"`
+	BingoCallerIDs    []uuid.UUID              `json:"bingoCallerIds"`
+	AcceptedCallerIDs []uuid.UUID              `json:"acceptedCallerIds"`
+	WinningPlayers    []PlayerState            `json:"winningPlayers"`
+	Suspensions       []bingo.PlayerSuspension `json:"suspensions"`
+	BannedPlayerIDs   []uuid.UUID              `json:"bannedPlayerIds"`
+	CalledBalls       []bingo.Ball             `json:"calledBalls"`
+	// UncalledBalls is stored in draw order, so that a restored game keeps
+	// drawing the exact same balls
+	UncalledBalls []bingo.Ball `json:"uncalledBalls"`
+	ManualCalls   []bingo.Ball `json:"manualCalls"`
+	// DrawSeed is the seed that the current round's balls were shuffled with.
+	// It must stay secret until the round is over.
+	DrawSeed int64 `json:"drawSeed"`
+	// DrawOrder is the order that the current round's balls would be drawn
+	// in, and DrawScripted indicates that it came from a script instead of
+	// DrawSeed
+	DrawOrder    []bingo.Ball `json:"drawOrder"`
+	DrawScripted bool         `json:"drawScripted"`
+	// Tiebreaker will be nil if no tiebreaker is being played
+	Tiebreaker *TiebreakerState `json:"tiebreaker,omitempty"`
+	// Cards contains every card the card registry knows about, including the
+	// ones that players currently have checked out
+	Cards []CardState `json:"cards"`
+}
+
+// PlayerState is the serializable state for a single player, including all
+// of their cards (and every daub on them).
+type PlayerState struct {
+	ID     uuid.UUID          `json:"id"`
+	Name   string             `json:"name"`
+	Status bingo.PlayerStatus `json:"status"`
+	Cards  []bingo.Card       `json:"cards"`
+}
+
+// CardState is the serializable state for a single card in the card
+// registry.
+type CardState struct {
+	ID    uuid.UUID      `json:"id"`
+	Cells [][]bingo.Ball `json:"cells"`
+	// PrevPlayerIDs contains every player who has ever had the card, so that
+	// nobody gets given the same card twice
+	PrevPlayerIDs []uuid.UUID `json:"prevPlayerIds"`
+	// StripID is shared by every card in the same strip. It is nil for cards
+	// that aren't part of a strip.
+	StripID *uuid.UUID `json:"stripId,omitempty"`
+}
+
+// TiebreakerState is the serializable state for a tiebreaker, including its
+// draw. The draw fields work the same way as the ones in State.
+type TiebreakerState struct {
+	Rule      bingo.TiebreakerRule   `json:"rule"`
+	PlayerIDs []uuid.UUID            `json:"playerIds"`
+	HighBalls []bingo.TiebreakerDraw `json:"highBalls"`
+	// HasDraw indicates that the tiebreaker's rule draws balls. Every other
+	// field is empty if it is false.
+	HasDraw       bool         `json:"hasDraw"`
+	CalledBalls   []bingo.Ball `json:"calledBalls"`
+	UncalledBalls []bingo.Ball `json:"uncalledBalls"`
+	ManualCalls   []bingo.Ball `json:"manualCalls"`
+	DrawSeed      int64        `json:"drawSeed"`
+	DrawOrder     []bingo.Ball `json:"drawOrder"`
+	DrawScripted  bool         `json:"drawScripted"`
+}
+
+// ErrGameEnded is returned by Restore when the game being restored has already
+// ended (or never started successfully), so there is nothing left to play.
+var ErrGameEnded = errors.New("game has already ended")
+
+// Restore rebuilds a game from a checkpoint, and then replays every journal
+// entry recorded after it. Only the CreatorID, RNGSeed, BallSeed, BallRNG,
+// CardRNG, Journal, IDSource, and Clock fields are used from init; everything
+// else comes from the checkpoint. Randomness can't be restored, so any cards or
+// ball orders generated after the restore will use init's sources. For the
+// same reason, restored games don't have a recording, and IDSource falls back
+// to fully random IDs.
+//
+// Replay stops at the first entry that can't be applied (or that fails
+// differently than it did originally), since every entry after it might
+// depend on it. The restored game immediately records a new
+// checkpoint to its journal.
+func Restore(init Init, state State, entries []JournalEntry) (*Game, error) {
+	if state.Phase == bingo.GamePhaseGameOver || state.Phase == bingo.GamePhaseInitializationFailure {
+		return nil, ErrGameEnded
+	}
+
+	if init.IDSource == nil {
+		init.IDSource = randomIDSource{}
+	}
+	game, err := newGame(Init{
+		CreatorID:         init.CreatorID,
+		HostID:            state.Host.ID,
+		HostName:          state.Host.Name,
+		RNGSeed:           init.RNGSeed,
+		BallSeed:          init.BallSeed,
+		Variant:           state.Variant,
+		BallRNG:           init.BallRNG,
+		CardRNG:           init.CardRNG,
+		MaxPlayers:        &state.MaxPlayers,
+		MaxRounds:         &state.MaxRounds,
+		MaxCardsPerPlayer: optionalCardLimit(state.MaxCardsPerPlayer),
+		WinPattern:        &state.WinPattern,
+		CallingMode:       optionalCallingMode(state.CallingMode),
+		Journal:           nil,
+		IDSource:          init.IDSource,
+		Clock:             init.Clock,
+	})
+	if err != nil {
+		return nil, err
+	}
+	game.recording = nil
+	if err := game.applyState(state); err != nil {
+		return nil, fmt.Errorf("unable to restore state: %v", err)
+	}
+	if err := game.start(); err != nil {
+		return nil, err
+	}
+
+	game.mtx.Lock()
+	defer game.mtx.Unlock()
+
+	// Everyone has already seen the events for the replayed entries, and
+	// nobody is listening for them yet anyway
+	game.phaseSubscriptions.setMuted(true)
+	for _, entry := range entries {
+		if entry.Version <= state.Version {
+			continue
+		}
+		if entry.Command == nil {
+			break
+		}
+		// Commands that failed after changing the game's state were still
+		// journaled, so they have to fail the same way again
+		actual := ""
+		if err := game.routeCommand(*entry.Command); err != nil {
+			actual = err.Error()
+		}
+		if actual != entry.Error {
+			break
+		}
+		game.version = entry.Version
+	}
+	game.phaseSubscriptions.setMuted(false)
+	// Only start the auto-caller's timer once the game is fully caught up
+	game.syncAutoCaller()
+	if !game.phase.ok() {
+		return nil, ErrGameEnded
+	}
+
+	game.journalEvents = nil
+	game.journal = init.Journal
+	if err := game.checkpoint(); err != nil {
+		_ = game.dispose()
+		return nil, fmt.Errorf("unable to checkpoint restored game: %v", err)
+	}
+	return game, nil
+}
+
+// optionalCallingMode turns a saved calling mode into an Init field. Games
+// saved before calling modes existed don't have one, so they fall back to the
+// default.
+func optionalCallingMode(mode bingo.CallingMode) *bingo.CallingMode {
+	if mode == "" {
+		return nil
+	}
+	return &mode
+}
+
+// optionalCardLimit turns a saved card limit into an Init field. Games saved
+// before hosts could limit cards won't have one, so they fall back to the
+// default.
+func optionalCardLimit(limit int) *int {
+	if limit == 0 {
+		return nil
+	}
+	return &limit
+}
+
+// applyState overwrites a freshly-created game with a saved state. It must be
+// called before the game is started.
+func (g *Game) applyState(state State) error {
+	var checkedOutIDs []uuid.UUID
+	for _, p := range state.Players {
+		for _, c := range p.Cards {
+			checkedOutIDs = append(checkedOutIDs, c.ID)
+		}
+	}
+	if err := g.cardRegistry.restoreEntries(state.Cards, checkedOutIDs); err != nil {
+		return err
+	}
+	g.ballRegistry.restore(state)
+	g.autoCaller.restore(state.AutoCaller)
+
+	for _, p := range state.Players {
+		eventChan, unsub, err := g.phaseSubscriptions.subscribe(nil, []uuid.UUID{p.ID})
+		if err != nil {
+			return fmt.Errorf("unable to subscribe player %q: %v", p.Name, err)
+		}
+
+		var cards []*bingo.Card
+		for _, c := range p.Cards {
+			copied := copyCard(&c)
+			cards = append(cards, &copied)
+		}
+		player := &bingo.Player{
+			Status:        p.Status,
+			ID:            p.ID,
+			Name:          p.Name,
+			Cards:         cards,
+			EventReceiver: eventChan,
+		}
+		g.cardPlayers = append(g.cardPlayers, g.newPlayerEntry(player, unsub))
+	}
+
+	for _, w := range state.WinningPlayers {
+		g.winningPlayers = append(g.winningPlayers, &bingo.Player{
+			Status:        w.Status,
+			ID:            w.ID,
+			Name:          w.Name,
+			Cards:         nil,
+			EventReceiver: nil,
+		})
+	}
+	for _, s := range state.Suspensions {
+		copied := s
+		g.suspensions = append(g.suspensions, &copied)
+	}
+	for _, c := range state.CoHosts {
+		g.coHosts = append(g.coHosts, &bingo.CoHost{
+			PlayerID:    c.PlayerID,
+			Permissions: slices.Clone(c.Permissions),
+		})
+	}
+
+	if state.Tiebreaker != nil {
+		g.tiebreaker = restoreTiebreaker(*state.Tiebreaker, g.ballRegistry.source, g.variant.MaxBall)
+	}
+
+	g.phase._value = state.Phase
+	g.currentRound = state.CurrentRound
+	g.version = state.Version
+	g.bingoCallerPlayerIDs = append([]uuid.UUID(nil), state.BingoCallerIDs...)
+	g.acceptedCallerPlayerIDs = append([]uuid.UUID(nil), state.AcceptedCallerIDs...)
+	g.bannedPlayerIDs = append([]uuid.UUID(nil), state.BannedPlayerIDs...)
+	return nil
+}
+
+// captureState produces a deep copy of the game's entire internal state. This
+// method is NOT thread-safe.
+func (g *Game) captureState() State {
+	players := []PlayerState{}
+	for _, e := range g.cardPlayers {
+		players = append(players, capturePlayer(e.player))
+	}
+	winners := []PlayerState{}
+	for _, w := range g.winningPlayers {
+		winner := capturePlayer(w)
+		winner.Cards = []bingo.Card{}
+		winners = append(winners, winner)
+	}
+	suspensions := []bingo.PlayerSuspension{}
+	for _, s := range g.suspensions {
+		suspensions = append(suspensions, *s)
+	}
+	coHosts := []bingo.CoHost{}
+	for _, c := range g.coHosts {
+		coHosts = append(coHosts, bingo.CoHost{
+			PlayerID:    c.PlayerID,
+			Permissions: slices.Clone(c.Permissions),
+		})
+	}
+
+	state := State{
+		Version:           g.version,
+		Phase:             g.phase.value(),
+		Variant:           g.variant.ID,
+		CurrentRound:      g.currentRound,
+		MaxRounds:         g.maxRounds,
+		MaxPlayers:        g.maxPlayers,
+		MaxCardsPerPlayer: g.maxCardsPerPlayer,
+		WinPattern:        g.winPattern,
+		CallingMode:       g.callingMode,
+		AutoCaller:        g.autoCaller.export(),
+		Host:              capturePlayer(g.host),
+		CoHosts:           coHosts,
+		Players:           players,
+		BingoCallerIDs:    slices.Clone(g.bingoCallerPlayerIDs),
+		AcceptedCallerIDs: slices.Clone(g.acceptedCallerPlayerIDs),
+		WinningPlayers:    winners,
+		Suspensions:       suspensions,
+		BannedPlayerIDs:   slices.Clone(g.bannedPlayerIDs),
+		Cards:             g.cardRegistry.exportEntries(),
+	}
+	g.ballRegistry.exportDraw(&state)
+	if g.tiebreaker != nil {
+		state.Tiebreaker = g.tiebreaker.export()
+	}
+	return state
+}
+
+func capturePlayer(player *bingo.Player) PlayerState {
+	cards := []bingo.Card{}
+	for _, c := range player.Cards {
+		cards = append(cards, copyCard(c))
+	}
+	return PlayerState{
+		ID:     player.ID,
+		Name:   player.Name,
+		Status: player.Status,
+		Cards:  cards,
+	}
+}
+
+// recordChange records the change that was just applied to the journal,
+// following it up with a checkpoint if the change can't be replayed (or if
+// it's been a while since the last checkpoint). A nil command indicates that
+// the change didn't come from a command, and commandErr is the error from a
+// command that failed after changing the game's state. This method is NOT
+// thread-safe.
+func (g *Game) recordChange(command *bingo.GameCommand, commandErr error) error {
+	if g.journal == nil {
+		return nil
+	}
+
+	entry := JournalEntry{
+		Version: g.version,
+		Command: command,
+		Error:   "",
+		Events:  slices.Clone(g.journalEvents),
+	}
+	if commandErr != nil {
+		entry.Error = commandErr.Error()
+	}
+	err := g.journal.Append(entry)
+	if err != nil {
+		return fmt.Errorf("change was applied, but could not be recorded: %v", err)
+	}
+
+	g.uncheckpointed++
+	if command != nil && !g.needsCheckpoint && g.uncheckpointed < checkpointInterval {
+		return nil
+	}
+	return g.checkpoint()
+}
+
+// checkpoint records the game's entire state to the journal. This method is
+// NOT thread-safe.
+func (g *Game) checkpoint() error {
+	if g.journal == nil {
+		return nil
+	}
+	if err := g.journal.Checkpoint(g.captureState()); err != nil {
+		return fmt.Errorf("unable to record checkpoint: %v", err)
+	}
+	g.needsCheckpoint = false
+	g.uncheckpointed = 0
+	return nil
+}
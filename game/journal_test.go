@@ -0,0 +1,226 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// memoryJournal keeps the most recent checkpoint in memory, along with every
+// entry appended since then.
+type memoryJournal struct {
+	checkpoint *State
+	entries    []JournalEntry
+	mtx        sync.Mutex
+}
+
+var _ Journal = &memoryJournal{}
+
+func (j *memoryJournal) Append(entry JournalEntry) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.entries = append(j.entries, entry)
+	return nil
+}
+
+func (j *memoryJournal) Checkpoint(state State) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.checkpoint = &state
+	j.entries = nil
+	return nil
+}
+
+// contents returns copies of the latest checkpoint and every entry after it.
+func (j *memoryJournal) contents(t *testing.T) (State, []JournalEntry) {
+	t.Helper()
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	if j.checkpoint == nil {
+		t.Fatal("journal does not have a checkpoint")
+	}
+	return *j.checkpoint, slices.Clone(j.entries)
+}
+
+// encodeState turns a state into JSON, so that states can be compared without
+// worrying about nil and empty slices.
+func encodeState(t *testing.T, state State) string {
+	t.Helper()
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("unable to encode state: %v", err)
+	}
+	return string(b)
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	// Joining always checkpoints the original game, so starting the round
+	// and everything after it only exists in the journal's entries
+	journal := &memoryJournal{}
+	clock := NewManualClock(testStart)
+	g := newTestGame(t, Init{Journal: journal, Clock: clock})
+	playerID, _ := joinTestPlayer(t, g, "player", 2)
+	checkpointed, _ := journal.contents(t)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+
+	for range 4 {
+		clock.Advance(time.Second)
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	}
+	cards := g.SnapshotFor(playerID).Player.Cards
+	mustIssueTestCommand(t, g, playerID, bingo.GameCommandPlayerDaub, bingo.GameCommandPayloadPlayerDaub{
+		CardID: cards[1].ID,
+		Cell:   int(bingo.FreeSpace),
+	})
+	if err := issueTestCommand(t, g, playerID, bingo.GameCommandPlayerDaub, bingo.GameCommandPayloadPlayerDaub{
+		CardID: cards[1].ID,
+		Cell:   999,
+	}); err == nil {
+		t.Fatal("daubing a ball that doesn't exist should fail")
+	}
+
+	state, entries := journal.contents(t)
+	if state.Version != checkpointed.Version {
+		t.Fatalf("expected the checkpoint to stay at version %d, got %d", checkpointed.Version, state.Version)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 entries after the checkpoint, got %d", len(entries))
+	}
+	g.mtx.Lock()
+	final := g.captureState()
+	g.mtx.Unlock()
+
+	testCases := []struct {
+		name string
+		// prepare turns the original journal's contents into what Restore
+		// receives
+		prepare         func(state State, entries []JournalEntry) (State, []JournalEntry)
+		expectedErr     error
+		expectedVersion uint64
+		// expectedState is only checked if it isn't empty
+		expectedState string
+	}{
+		{
+			name: "Checkpoint and every entry",
+			prepare: func(state State, entries []JournalEntry) (State, []JournalEntry) {
+				return state, entries
+			},
+			expectedVersion: final.Version,
+			expectedState:   encodeState(t, final),
+		},
+		{
+			name: "Checkpoint without entries",
+			prepare: func(state State, entries []JournalEntry) (State, []JournalEntry) {
+				return state, nil
+			},
+			expectedVersion: checkpointed.Version,
+			expectedState:   encodeState(t, checkpointed),
+		},
+		{
+			name: "Entries from before the checkpoint are skipped",
+			prepare: func(state State, entries []JournalEntry) (State, []JournalEntry) {
+				stale := entries[0]
+				stale.Version = state.Version
+				return state, append([]JournalEntry{stale}, entries...)
+			},
+			expectedVersion: final.Version,
+			expectedState:   encodeState(t, final),
+		},
+		{
+			name: "Entry that fails differently stops the replay",
+			prepare: func(state State, entries []JournalEntry) (State, []JournalEntry) {
+				entries[2].Error = "ball could not be called"
+				return state, entries
+			},
+			expectedVersion: entries[1].Version,
+		},
+		{
+			name: "Entry without a command stops the replay",
+			prepare: func(state State, entries []JournalEntry) (State, []JournalEntry) {
+				entries[3].Command = nil
+				return state, entries
+			},
+			expectedVersion: entries[2].Version,
+		},
+		{
+			name: "Game that already ended",
+			prepare: func(state State, entries []JournalEntry) (State, []JournalEntry) {
+				state.Phase = bingo.GamePhaseGameOver
+				return state, entries
+			},
+			expectedErr: ErrGameEnded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Every case gets its own copy, since restoring a game takes
+			// ownership of the state it's given
+			var stateCopy State
+			if err := json.Unmarshal([]byte(encodeState(t, state)), &stateCopy); err != nil {
+				t.Fatalf("unable to copy state: %v", err)
+			}
+			entriesCopy := make([]JournalEntry, len(entries))
+			for i, e := range entries {
+				entriesCopy[i] = e
+				command := *e.Command
+				entriesCopy[i].Command = &command
+			}
+			restoreState, restoreEntries := tc.prepare(stateCopy, entriesCopy)
+
+			restoredJournal := &memoryJournal{}
+			restored, err := Restore(Init{
+				CreatorID: testSystemID,
+				Journal:   restoredJournal,
+				Clock:     NewManualClock(clock.Now()),
+			}, restoreState, restoreEntries)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to restore game: %v", err)
+			}
+			t.Cleanup(func() {
+				_ = restored.IssueCommand(bingo.GameCommand{
+					Type:        bingo.GameCommandSystemDispose,
+					CommanderID: testSystemID,
+					Payload:     nil,
+				})
+			})
+			drainEvents(restored.Host().EventReceiver)
+
+			restored.mtx.Lock()
+			actual := restored.captureState()
+			restored.mtx.Unlock()
+			if actual.Version != tc.expectedVersion {
+				t.Fatalf("expected restored game to be at version %d, got %d", tc.expectedVersion, actual.Version)
+			}
+			if tc.expectedState != "" {
+				if encoded := encodeState(t, actual); encoded != tc.expectedState {
+					t.Fatalf("restored state does not match.\nexpected: %s\nactual:   %s", tc.expectedState, encoded)
+				}
+			}
+
+			// The restored game should be able to pick up right where it
+			// left off, without needing the old entries again
+			checkpoint, newEntries := restoredJournal.contents(t)
+			if checkpoint.Version != actual.Version || len(newEntries) != 0 {
+				t.Fatalf("expected a fresh checkpoint at version %d, got version %d with %d entries", actual.Version, checkpoint.Version, len(newEntries))
+			}
+		})
+	}
+}
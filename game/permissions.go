@@ -0,0 +1,328 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// CommanderRole describes how a commander is related to a game, as far as
+// issuing commands goes. A card player who is also a co-host has both roles.
+type CommanderRole string
+
+const (
+	CommanderRoleSystem       CommanderRole = "system"
+	CommanderRoleHost         CommanderRole = "host"
+	CommanderRoleCoHost       CommanderRole = "co_host"
+	CommanderRoleActivePlayer CommanderRole = "active_player"
+	CommanderRoleWaitlisted   CommanderRole = "waitlisted"
+	CommanderRoleSuspended    CommanderRole = "suspended"
+	// CommanderRoleSpectator is anyone who isn't part of the game. They are
+	// never allowed to issue any commands.
+	CommanderRoleSpectator CommanderRole = "spectator"
+)
+
+// CommandRoleError is returned when a command is issued by someone whose
+// roles don't allow it.
+type CommandRoleError struct {
+	CommandType bingo.GameCommandType
+	CommanderID uuid.UUID
+	// Roles contains every role the commander had when the command was
+	// rejected
+	Roles []CommanderRole
+	// MissingPermission is only defined for co-hosts who don't have the
+	// permission that the command needs
+	MissingPermission bingo.HostPermission
+}
+
+var _ error = &CommandRoleError{}
+
+func (e *CommandRoleError) Error() string {
+	if e.MissingPermission != "" {
+		return fmt.Sprintf("co-host %q does not have the %q permission needed for %q", e.CommanderID, e.MissingPermission, e.CommandType)
+	}
+	roles := make([]string, 0, len(e.Roles))
+	for _, r := range e.Roles {
+		roles = append(roles, string(r))
+	}
+	return fmt.Sprintf("commander %q (%s) is not allowed to issue %q", e.CommanderID, strings.Join(roles, ", "), e.CommandType)
+}
+
+// CommandPhaseError is returned when a command is issued during a phase that
+// doesn't allow it.
+type CommandPhaseError struct {
+	CommandType   bingo.GameCommandType
+	Phase         bingo.GamePhase
+	AllowedPhases []bingo.GamePhase
+}
+
+var _ error = &CommandPhaseError{}
+
+func (e *CommandPhaseError) Error() string {
+	phases := make([]string, 0, len(e.AllowedPhases))
+	for _, p := range e.AllowedPhases {
+		phases = append(phases, string(p))
+	}
+	return fmt.Sprintf("%q cannot be issued during the %s phase (allowed during: %s)", e.CommandType, e.Phase, strings.Join(phases, ", "))
+}
+
+// commandPolicy describes who is allowed to issue a command, and when.
+type commandPolicy struct {
+	roles []CommanderRole
+	// coHostPermission is the permission that co-hosts need to issue the
+	// command. It must be defined for every policy that allows co-hosts.
+	coHostPermission bingo.HostPermission
+	// phases will be nil for commands that can be issued during any phase
+	// (aside from the ones where the game has ended)
+	phases []bingo.GamePhase
+}
+
+var (
+	hostOnly       = []CommanderRole{CommanderRoleHost}
+	hostOrCoHost   = []CommanderRole{CommanderRoleHost, CommanderRoleCoHost}
+	anyCardPlayer  = []CommanderRole{CommanderRoleActivePlayer, CommanderRoleWaitlisted, CommanderRoleSuspended}
+	preRoundPhases = []bingo.GamePhase{bingo.GamePhaseInitialized, bingo.GamePhaseRoundStart}
+	ballPhases     = []bingo.GamePhase{bingo.GamePhaseRoundStart, bingo.GamePhaseCalling, bingo.GamePhaseTiebreaker}
+	// calledBallPhases are the phases where at least one ball has been
+	// called, so there's something for players to daub
+	calledBallPhases = []bingo.GamePhase{bingo.GamePhaseCalling, bingo.GamePhaseConfirmingBingo, bingo.GamePhaseTiebreaker}
+)
+
+// commandPolicies is the single source of truth for who can issue each
+// command, and during which phases. Handlers can assume that every command
+// they receive has already passed its policy, but still need to check
+// anything that depends on more than the commander's role and the phase
+// (e.g., the calling mode).
+var commandPolicies = map[bingo.GameCommandType]commandPolicy{
+	// System commands
+	bingo.GameCommandSystemDispose:        {roles: []CommanderRole{CommanderRoleSystem}},
+	bingo.GameCommandSystemBroadcastState: {roles: []CommanderRole{CommanderRoleSystem}},
+	bingo.GameCommandSystemAutoCallBall:   {roles: []CommanderRole{CommanderRoleSystem}},
+
+	// Host commands
+	bingo.GameCommandHostTerminateGame:      {roles: hostOnly},
+	bingo.GameCommandHostTransferHostStatus: {roles: hostOnly},
+	bingo.GameCommandHostAppointCoHost:      {roles: hostOnly},
+	bingo.GameCommandHostDismissCoHost:      {roles: hostOnly},
+	bingo.GameCommandHostStartGame: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionConfigureGame,
+		phases:           []bingo.GamePhase{bingo.GamePhaseInitialized},
+	},
+	bingo.GameCommandHostSetWinPattern: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionConfigureGame,
+		phases:           preRoundPhases,
+	},
+	bingo.GameCommandHostSetCardLimit: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionConfigureGame,
+		phases:           preRoundPhases,
+	},
+	bingo.GameCommandHostSetCallingMode: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionConfigureGame,
+		phases:           preRoundPhases,
+	},
+	bingo.GameCommandHostBanPlayer: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionModeratePlayers,
+	},
+	bingo.GameCommandHostSuspendPlayer: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionModeratePlayers,
+	},
+	bingo.GameCommandHostRequestBall: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+		phases:           ballPhases,
+	},
+	bingo.GameCommandHostSyncBall: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+		phases:           ballPhases,
+	},
+	bingo.GameCommandHostUndoBall: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+		phases:           []bingo.GamePhase{bingo.GamePhaseCalling},
+	},
+	bingo.GameCommandHostEndRound: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+		phases:           []bingo.GamePhase{bingo.GamePhaseCalling},
+	},
+	bingo.GameCommandHostStartAutoCaller: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+	},
+	bingo.GameCommandHostPauseAutoCaller: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+	},
+	bingo.GameCommandHostSetAutoCallerSpeed: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionCallBalls,
+	},
+	bingo.GameCommandHostAcknowledgeBingoCall: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionJudgeBingo,
+		phases:           []bingo.GamePhase{bingo.GamePhaseConfirmingBingo},
+	},
+	bingo.GameCommandHostStartTiebreakerRound: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionJudgeBingo,
+		phases:           []bingo.GamePhase{bingo.GamePhaseConfirmingBingo},
+	},
+	bingo.GameCommandHostAwardPlayers: {
+		roles:            hostOrCoHost,
+		coHostPermission: bingo.HostPermissionJudgeBingo,
+		phases:           []bingo.GamePhase{bingo.GamePhaseConfirmingBingo, bingo.GamePhaseTiebreaker},
+	},
+
+	// Player commands. Only players in a tiebreaker can daub or call bingo
+	// during it, but that's up to the tiebreaker to decide.
+	bingo.GameCommandPlayerDaub: {
+		roles:  []CommanderRole{CommanderRoleActivePlayer},
+		phases: calledBallPhases,
+	},
+	bingo.GameCommandPlayerUndoDaub: {
+		roles:  []CommanderRole{CommanderRoleActivePlayer},
+		phases: calledBallPhases,
+	},
+	bingo.GameCommandPlayerCallBingo: {
+		roles:  []CommanderRole{CommanderRoleActivePlayer},
+		phases: calledBallPhases,
+	},
+	bingo.GameCommandPlayerRescindBingo: {
+		roles:  []CommanderRole{CommanderRoleActivePlayer},
+		phases: []bingo.GamePhase{bingo.GamePhaseConfirmingBingo},
+	},
+	bingo.GameCommandPlayerReplaceCards: {
+		roles:  anyCardPlayer,
+		phases: preRoundPhases,
+	},
+	bingo.GameCommandPlayerCheckOutStrip: {
+		roles:  anyCardPlayer,
+		phases: preRoundPhases,
+	},
+	bingo.GameCommandPlayerReplaceSelectedCards: {
+		roles:  anyCardPlayer,
+		phases: []bingo.GamePhase{bingo.GamePhaseRoundStart},
+	},
+}
+
+// authorizeCommand checks a command against its policy, before it is passed
+// to its handler. Rejections are always a *CommandRoleError or a
+// *CommandPhaseError. This method is NOT thread-safe.
+func (g *Game) authorizeCommand(command bingo.GameCommand) error {
+	policy, ok := commandPolicies[command.Type]
+	if !ok {
+		return fmt.Errorf("received unknown command %q", command.Type)
+	}
+
+	roles := g.commanderRoles(command.CommanderID)
+	allowed := false
+	var missingPermission bingo.HostPermission
+	for _, r := range roles {
+		if !slices.Contains(policy.roles, r) {
+			continue
+		}
+		if r == CommanderRoleCoHost && !slices.Contains(g.findCoHost(command.CommanderID).Permissions, policy.coHostPermission) {
+			missingPermission = policy.coHostPermission
+			continue
+		}
+		allowed = true
+		break
+	}
+	if !allowed {
+		return &CommandRoleError{
+			CommandType:       command.Type,
+			CommanderID:       command.CommanderID,
+			Roles:             roles,
+			MissingPermission: missingPermission,
+		}
+	}
+
+	phase := g.phase.value()
+	if policy.phases != nil && !slices.Contains(policy.phases, phase) {
+		return &CommandPhaseError{
+			CommandType:   command.Type,
+			Phase:         phase,
+			AllowedPhases: slices.Clone(policy.phases),
+		}
+	}
+	return nil
+}
+
+// commanderRoles produces every role that a commander currently has. This
+// method is NOT thread-safe.
+func (g *Game) commanderRoles(commanderID uuid.UUID) []CommanderRole {
+	switch commanderID {
+	case g.systemID:
+		return []CommanderRole{CommanderRoleSystem}
+	case g.host.ID:
+		return []CommanderRole{CommanderRoleHost}
+	}
+	entry := g.findPlayerEntry(commanderID)
+	if entry == nil {
+		return []CommanderRole{CommanderRoleSpectator}
+	}
+
+	var roles []CommanderRole
+	if g.findCoHost(commanderID) != nil {
+		roles = append(roles, CommanderRoleCoHost)
+	}
+	switch entry.player.Status {
+	case bingo.PlayerStatusActive:
+		roles = append(roles, CommanderRoleActivePlayer)
+	case bingo.PlayerStatusWaitlisted:
+		roles = append(roles, CommanderRoleWaitlisted)
+	case bingo.PlayerStatusSuspended:
+		roles = append(roles, CommanderRoleSuspended)
+	}
+	return roles
+}
+
+// validateCoHostTarget keeps co-hosts from using their permissions on
+// themselves, or on other co-hosts. Only the host is allowed to do that. This
+// method is NOT thread-safe.
+func (g *Game) validateCoHostTarget(commanderID uuid.UUID, targetID uuid.UUID) error {
+	if commanderID == g.host.ID || g.findCoHost(targetID) == nil {
+		return nil
+	}
+	return fmt.Errorf("only the host can do that to co-host %q", targetID)
+}
+
+// hostRecipients produces the recipients for an event that only the host
+// would normally see, so that co-hosts who need the event to do their job
+// also get it. Any extra IDs are added on the end. This method is NOT
+// thread-safe.
+func (g *Game) hostRecipients(permission bingo.HostPermission, extraIDs ...uuid.UUID) []uuid.UUID {
+	recipients := []uuid.UUID{g.host.ID}
+	for _, c := range g.coHosts {
+		if slices.Contains(c.Permissions, permission) {
+			recipients = append(recipients, c.PlayerID)
+		}
+	}
+	for _, id := range extraIDs {
+		if !slices.Contains(recipients, id) {
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients
+}
+
+// findCoHost returns the co-host with the matching player ID, or nil if the
+// player is not a co-host. This method is NOT thread-safe.
+func (g *Game) findCoHost(playerID uuid.UUID) *bingo.CoHost {
+	for _, c := range g.coHosts {
+		if c.PlayerID == playerID {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// allCommandTypes lists every command that a game can receive.
+var allCommandTypes = []bingo.GameCommandType{
+	bingo.GameCommandSystemBroadcastState,
+	bingo.GameCommandSystemDispose,
+	bingo.GameCommandSystemAutoCallBall,
+	bingo.GameCommandHostStartGame,
+	bingo.GameCommandHostTerminateGame,
+	bingo.GameCommandHostBanPlayer,
+	bingo.GameCommandHostTransferHostStatus,
+	bingo.GameCommandHostAppointCoHost,
+	bingo.GameCommandHostDismissCoHost,
+	bingo.GameCommandHostSuspendPlayer,
+	bingo.GameCommandHostRequestBall,
+	bingo.GameCommandHostSyncBall,
+	bingo.GameCommandHostAcknowledgeBingoCall,
+	bingo.GameCommandHostStartTiebreakerRound,
+	bingo.GameCommandHostSetCallingMode,
+	bingo.GameCommandHostUndoBall,
+	bingo.GameCommandHostEndRound,
+	bingo.GameCommandHostStartAutoCaller,
+	bingo.GameCommandHostPauseAutoCaller,
+	bingo.GameCommandHostSetAutoCallerSpeed,
+	bingo.GameCommandHostSetWinPattern,
+	bingo.GameCommandHostSetCardLimit,
+	bingo.GameCommandHostAwardPlayers,
+	bingo.GameCommandPlayerDaub,
+	bingo.GameCommandPlayerUndoDaub,
+	bingo.GameCommandPlayerCallBingo,
+	bingo.GameCommandPlayerRescindBingo,
+	bingo.GameCommandPlayerReplaceCards,
+	bingo.GameCommandPlayerCheckOutStrip,
+	bingo.GameCommandPlayerReplaceSelectedCards,
+}
+
+func TestCommandPolicies(t *testing.T) {
+	t.Parallel()
+
+	if len(commandPolicies) != len(allCommandTypes) {
+		t.Errorf("expected %d policies, got %d", len(allCommandTypes), len(commandPolicies))
+	}
+	for _, commandType := range allCommandTypes {
+		policy, ok := commandPolicies[commandType]
+		if !ok {
+			t.Errorf("%q does not have a policy", commandType)
+			continue
+		}
+		if len(policy.roles) == 0 {
+			t.Errorf("%q can't be issued by anyone", commandType)
+		}
+		if slices.Contains(policy.roles, CommanderRoleSpectator) {
+			t.Errorf("%q can be issued by spectators", commandType)
+		}
+		allowsCoHosts := slices.Contains(policy.roles, CommanderRoleCoHost)
+		if allowsCoHosts && policy.coHostPermission == "" {
+			t.Errorf("%q allows co-hosts without requiring a permission", commandType)
+		}
+		if !allowsCoHosts && policy.coHostPermission != "" {
+			t.Errorf("%q requires a co-host permission, but doesn't allow co-hosts", commandType)
+		}
+		if policy.phases != nil && len(policy.phases) == 0 {
+			t.Errorf("%q can't be issued during any phase", commandType)
+		}
+	}
+}
+
+func TestAuthorizeCommand(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+	playerID, _ := joinTestPlayer(t, g, "player", 1)
+	coHostID, _ := joinTestPlayer(t, g, "co-host", 1)
+	suspendedID, _ := joinTestPlayer(t, g, "suspended", 1)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostAppointCoHost, bingo.GameCommandPayloadHostAppointCoHost{
+		PlayerID:    coHostID,
+		Permissions: []bingo.HostPermission{bingo.HostPermissionCallBalls},
+	})
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostSuspendPlayer, bingo.GameCommandPayloadHostSuspendPlayer{
+		PlayerID:      suspendedID,
+		RoundDuration: 1,
+	})
+	spectatorID := testPlayerID("spectator")
+
+	type outcome int
+	const (
+		allowed outcome = iota
+		roleError
+		phaseError
+		otherError
+	)
+
+	// Every case runs during the round start phase
+	testCases := []struct {
+		name              string
+		commanderID       uuid.UUID
+		commandType       bingo.GameCommandType
+		expected          outcome
+		missingPermission bingo.HostPermission
+	}{
+		{
+			name:        "Host starts the auto-caller",
+			commanderID: testHostID,
+			commandType: bingo.GameCommandHostStartAutoCaller,
+			expected:    allowed,
+		},
+		{
+			name:        "Host starts a game that has already started",
+			commanderID: testHostID,
+			commandType: bingo.GameCommandHostStartGame,
+			expected:    phaseError,
+		},
+		{
+			name:        "Host undoes a ball before any have been called",
+			commanderID: testHostID,
+			commandType: bingo.GameCommandHostUndoBall,
+			expected:    phaseError,
+		},
+		{
+			name:        "Host ends a round before any balls have been called",
+			commanderID: testHostID,
+			commandType: bingo.GameCommandHostEndRound,
+			expected:    phaseError,
+		},
+		{
+			name:        "Host disposes of the game",
+			commanderID: testHostID,
+			commandType: bingo.GameCommandSystemDispose,
+			expected:    roleError,
+		},
+		{
+			name:        "Host daubs without any cards",
+			commanderID: testHostID,
+			commandType: bingo.GameCommandPlayerDaub,
+			expected:    roleError,
+		},
+		{
+			name:        "Co-host with permission requests a ball",
+			commanderID: coHostID,
+			commandType: bingo.GameCommandHostRequestBall,
+			expected:    allowed,
+		},
+		{
+			name:              "Co-host without permission changes the win pattern",
+			commanderID:       coHostID,
+			commandType:       bingo.GameCommandHostSetWinPattern,
+			expected:          roleError,
+			missingPermission: bingo.HostPermissionConfigureGame,
+		},
+		{
+			name:              "Co-host without permission bans a player",
+			commanderID:       coHostID,
+			commandType:       bingo.GameCommandHostBanPlayer,
+			expected:          roleError,
+			missingPermission: bingo.HostPermissionModeratePlayers,
+		},
+		{
+			name:        "Co-host appoints another co-host",
+			commanderID: coHostID,
+			commandType: bingo.GameCommandHostAppointCoHost,
+			expected:    roleError,
+		},
+		{
+			name:        "Co-host replaces their cards as a player",
+			commanderID: coHostID,
+			commandType: bingo.GameCommandPlayerReplaceSelectedCards,
+			expected:    allowed,
+		},
+		{
+			name:        "Player replaces their cards",
+			commanderID: playerID,
+			commandType: bingo.GameCommandPlayerReplaceCards,
+			expected:    allowed,
+		},
+		{
+			name:        "Player requests a ball",
+			commanderID: playerID,
+			commandType: bingo.GameCommandHostRequestBall,
+			expected:    roleError,
+		},
+		{
+			name:        "Player calls bingo before any balls",
+			commanderID: playerID,
+			commandType: bingo.GameCommandPlayerCallBingo,
+			expected:    phaseError,
+		},
+		{
+			name:        "Player daubs before any balls",
+			commanderID: playerID,
+			commandType: bingo.GameCommandPlayerDaub,
+			expected:    phaseError,
+		},
+		{
+			name:        "Player rescinds a bingo call that doesn't exist",
+			commanderID: playerID,
+			commandType: bingo.GameCommandPlayerRescindBingo,
+			expected:    phaseError,
+		},
+		{
+			name:        "Suspended player replaces their cards",
+			commanderID: suspendedID,
+			commandType: bingo.GameCommandPlayerReplaceCards,
+			expected:    allowed,
+		},
+		{
+			name:        "Suspended player calls bingo",
+			commanderID: suspendedID,
+			commandType: bingo.GameCommandPlayerCallBingo,
+			expected:    roleError,
+		},
+		{
+			name:        "Spectator replaces cards",
+			commanderID: spectatorID,
+			commandType: bingo.GameCommandPlayerReplaceCards,
+			expected:    roleError,
+		},
+		{
+			name:        "System calls a ball",
+			commanderID: testSystemID,
+			commandType: bingo.GameCommandSystemAutoCallBall,
+			expected:    allowed,
+		},
+		{
+			name:        "System starts the game",
+			commanderID: testSystemID,
+			commandType: bingo.GameCommandHostStartGame,
+			expected:    roleError,
+		},
+		{
+			name:        "Unknown command",
+			commanderID: testHostID,
+			commandType: "host_do_something_new",
+			expected:    otherError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Authorizing doesn't change anything, but every case still
+			// needs to see the same state
+			g.mtx.Lock()
+			err := g.authorizeCommand(bingo.GameCommand{
+				Type:        tc.commandType,
+				CommanderID: tc.commanderID,
+				Payload:     nil,
+			})
+			g.mtx.Unlock()
+
+			var roleErr *CommandRoleError
+			var phaseErr *CommandPhaseError
+			isRoleErr := errors.As(err, &roleErr)
+			isPhaseErr := errors.As(err, &phaseErr)
+
+			switch tc.expected {
+			case allowed:
+				if err != nil {
+					t.Fatalf("expected command to be allowed, got %v", err)
+				}
+			case roleError:
+				if !isRoleErr {
+					t.Fatalf("expected a role error, got %v", err)
+				}
+				if roleErr.MissingPermission != tc.missingPermission {
+					t.Fatalf("expected missing permission %q, got %q", tc.missingPermission, roleErr.MissingPermission)
+				}
+				if roleErr.CommanderID != tc.commanderID || roleErr.CommandType != tc.commandType {
+					t.Fatalf("role error describes the wrong command: %v", err)
+				}
+			case phaseError:
+				if !isPhaseErr {
+					t.Fatalf("expected a phase error, got %v", err)
+				}
+				if phaseErr.Phase != bingo.GamePhaseRoundStart {
+					t.Fatalf("expected phase %q, got %q", bingo.GamePhaseRoundStart, phaseErr.Phase)
+				}
+			case otherError:
+				if err == nil || isRoleErr || isPhaseErr {
+					t.Fatalf("expected an error unrelated to the policy, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthorizeDaubs(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+	activeID, _ := joinTestPlayer(t, g, "active", 1)
+	suspendedID, _ := joinTestPlayer(t, g, "suspended", 1)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostSuspendPlayer, bingo.GameCommandPayloadHostSuspendPlayer{
+		PlayerID:      suspendedID,
+		RoundDuration: 1,
+	})
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	// Anybody who joins after the first ball has to wait for the next round
+	waitlistedID, _ := joinTestPlayer(t, g, "waitlisted", 1)
+
+	testCases := []struct {
+		name        string
+		commanderID uuid.UUID
+		allowed     bool
+	}{
+		{
+			name:        "Active player",
+			commanderID: activeID,
+			allowed:     true,
+		},
+		{
+			name:        "Waitlisted player",
+			commanderID: waitlistedID,
+			allowed:     false,
+		},
+		{
+			name:        "Suspended player",
+			commanderID: suspendedID,
+			allowed:     false,
+		},
+		{
+			name:        "Host",
+			commanderID: testHostID,
+			allowed:     false,
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, commandType := range []bingo.GameCommandType{bingo.GameCommandPlayerDaub, bingo.GameCommandPlayerUndoDaub} {
+			t.Run(tc.name+" "+commandType, func(t *testing.T) {
+				g.mtx.Lock()
+				err := g.authorizeCommand(bingo.GameCommand{
+					Type:        commandType,
+					CommanderID: tc.commanderID,
+					Payload:     nil,
+				})
+				g.mtx.Unlock()
+
+				if tc.allowed {
+					if err != nil {
+						t.Fatalf("expected command to be allowed, got %v", err)
+					}
+					return
+				}
+				var roleErr *CommandRoleError
+				if !errors.As(err, &roleErr) {
+					t.Fatalf("expected a role error, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthorizeCommandThroughCommandLoop(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	playerID, _ := joinTestPlayer(t, g, "player", 1)
+
+	err := issueTestCommand(t, g, playerID, bingo.GameCommandHostStartGame, nil)
+	var roleErr *CommandRoleError
+	if !errors.As(err, &roleErr) {
+		t.Fatalf("expected a role error, got %v", err)
+	}
+	if !slices.Equal(roleErr.Roles, []CommanderRole{CommanderRoleWaitlisted}) {
+		t.Fatalf("expected player to only be waitlisted, got %v", roleErr.Roles)
+	}
+	if phase := g.Snapshot().Phase; phase != bingo.GamePhaseInitialized {
+		t.Fatalf("rejected command changed the phase to %q", phase)
+	}
+}
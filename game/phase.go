@@ -2,6 +2,7 @@ package game
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/Parkreiner/bingo"
@@ -44,8 +45,11 @@ func (p *phase) setValue(newValue bingo.GamePhase) error {
 		return errors.New("game is over")
 	case bingo.GamePhaseInitializationFailure:
 		return errors.New("game failed to initialize")
-	default:
-		p._value = newValue
-		return nil
 	}
+	if !p._value.CanTransitionTo(newValue) {
+		return fmt.Errorf("cannot transition from phase %q to phase %q", p._value, newValue)
+	}
+
+	p._value = newValue
+	return nil
 }
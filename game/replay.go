@@ -0,0 +1,244 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// Recording contains every input that a game has received, along with
+// everything needed to create the game from scratch. Replaying a recording
+// reproduces every card, ball, and event from the original game.
+type Recording struct {
+	CreatorID  uuid.UUID        `json:"creatorId"`
+	HostID     uuid.UUID        `json:"hostId"`
+	HostName   string           `json:"hostName"`
+	RNGSeed    int64            `json:"rngSeed"`
+	MaxPlayers int              `json:"maxPlayers"`
+	MaxRounds  int              `json:"maxRounds"`
+	WinPattern bingo.WinPattern `json:"winPattern"`
+	// CallingMode will be empty for recordings made before calling modes
+	// existed, which all used automatic calling
+	CallingMode bingo.CallingMode `json:"callingMode,omitempty"`
+	// Variant will be empty for recordings made before variants existed,
+	// which were all 75-ball
+	Variant bingo.VariantID `json:"variant,omitempty"`
+	// MaxCardsPerPlayer will be zero for recordings made before hosts could
+	// limit how many cards each player gets
+	MaxCardsPerPlayer int `json:"maxCardsPerPlayer,omitempty"`
+	// BallSeed will be zero for recordings made before balls had their own
+	// seed, which shuffled balls with RNGSeed
+	BallSeed int64 `json:"ballSeed,omitempty"`
+	// BannedPlayerIDs contains every player who was banned before the game
+	// started
+	BannedPlayerIDs []uuid.UUID    `json:"bannedPlayerIds,omitempty"`
+	Steps           []RecordedStep `json:"steps"`
+}
+
+// RecordedStep is a single input to a game. Exactly one of Command, Join,
+// LeftPlayerID, and LeftHostID will be defined.
+type RecordedStep struct {
+	// At is when the game started processing the input. Every event
+	// dispatched because of the input uses it as its timestamp.
+	At           time.Time          `json:"at"`
+	Command      *bingo.GameCommand `json:"command,omitempty"`
+	Join         *RecordedJoin      `json:"join,omitempty"`
+	LeftPlayerID *uuid.UUID         `json:"leftPlayerId,omitempty"`
+	// LeftHostID is only defined for hosts who left via LeaveAsHost. Players
+	// who were made the host after joining use LeftPlayerID instead.
+	LeftHostID *uuid.UUID `json:"leftHostId,omitempty"`
+	// Error is the error produced by the input, or an empty string if the
+	// input was processed successfully
+	Error string `json:"error,omitempty"`
+}
+
+// RecordedJoin is an attempt to join a game via JoinGame.
+type RecordedJoin struct {
+	PlayerID uuid.UUID `json:"playerId"`
+	Name     string    `json:"name"`
+	// CardCount will be zero for joins recorded before players could pick how
+	// many cards they wanted, which always gave the player bingo.MaxCards
+	CardCount int `json:"cardCount,omitempty"`
+}
+
+// ReplayResult is everything produced by replaying a recording.
+type ReplayResult struct {
+	// Events contains every event dispatched during the replay, in the order
+	// they were dispatched
+	Events []bingo.GameEvent `json:"events"`
+	// Final is the game's state after the last step was replayed
+	Final State `json:"final"`
+	// Divergences lists every step whose outcome didn't match the recording
+	Divergences []ReplayDivergence `json:"divergences"`
+}
+
+// ReplayDivergence describes a step that produced a different error during a
+// replay than it did originally.
+type ReplayDivergence struct {
+	// Step is the index of the step within the recording
+	Step     int    `json:"step"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// replayClock always reports the time of the step currently being replayed.
+type replayClock struct {
+	now time.Time
+}
+
+var _ Clock = &replayClock{}
+
+func (c *replayClock) Now() time.Time {
+	return c.now
+}
+
+// AfterFunc never calls f, because everything the original game did on its
+// own is already part of the recording.
+func (c *replayClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return func() bool { return true }
+}
+
+// Recording returns a copy of every input the game has received so far. Games
+// produced by Restore, or that use a custom BallRNG or CardRNG, can't be
+// replayed from scratch, so they don't have a recording.
+func (g *Game) Recording() (Recording, error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.recording == nil {
+		return Recording{}, errors.New("game was restored from a checkpoint or uses custom randomness, and cannot be replayed")
+	}
+	copied := *g.recording
+	copied.Steps = slices.Clone(g.recording.Steps)
+	return copied, nil
+}
+
+// recordStep adds an input to the game's recording, along with the error it
+// produced. This method is NOT thread-safe.
+func (g *Game) recordStep(step RecordedStep, err error) {
+	if g.recording == nil {
+		return
+	}
+	if step.Command != nil {
+		// The caller is still free to reuse the command's payload
+		copied := *step.Command
+		copied.Payload = slices.Clone(copied.Payload)
+		step.Command = &copied
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	g.recording.Steps = append(g.recording.Steps, step)
+}
+
+// Replay creates a brand new game from a recording, and feeds it every
+// recorded input in order. The game is disposed once the replay finishes, and
+// none of its events are sent to any subscribers.
+//
+// The IDs from the original game can only be reproduced if ids produces the
+// same sequence of IDs as the original game's IDSource. If ids is nil, it
+// falls back to the same source New uses by default.
+//
+// Steps that fail don't stop the replay, because they failed in the original
+// game, too. Any step that fails differently than it did originally is
+// reported as a divergence instead.
+func Replay(recording Recording, ids IDSource) (ReplayResult, error) {
+	result := ReplayResult{
+		Events:      []bingo.GameEvent{},
+		Final:       State{},
+		Divergences: []ReplayDivergence{},
+	}
+
+	// The ball source is passed in directly, so that the zero seed from an
+	// older recording isn't swapped out for a random one
+	ballSeed := recording.BallSeed
+	if ballSeed == 0 {
+		ballSeed = recording.RNGSeed
+	}
+	clock := &replayClock{now: time.Time{}}
+	g, err := newGame(Init{
+		CreatorID:         recording.CreatorID,
+		HostID:            recording.HostID,
+		HostName:          recording.HostName,
+		RNGSeed:           recording.RNGSeed,
+		BallRNG:           NewSeededRNG(ballSeed),
+		Variant:           recording.Variant,
+		MaxPlayers:        &recording.MaxPlayers,
+		MaxRounds:         &recording.MaxRounds,
+		MaxCardsPerPlayer: optionalCardLimit(recording.MaxCardsPerPlayer),
+		WinPattern:        &recording.WinPattern,
+		CallingMode:       optionalCallingMode(recording.CallingMode),
+		BannedPlayerIDs:   recording.BannedPlayerIDs,
+		Journal:           nil,
+		IDSource:          ids,
+		Clock:             clock,
+	})
+	if err != nil {
+		return result, fmt.Errorf("unable to create game for replay: %v", err)
+	}
+
+	// Nobody is reading from any of the game's subscriptions, so dispatching
+	// to them would just block
+	g.phaseSubscriptions.observer = func(event bingo.GameEvent) {
+		result.Events = append(result.Events, event)
+	}
+	g.phaseSubscriptions.muted = true
+	if err := g.start(); err != nil {
+		return result, fmt.Errorf("unable to start game for replay: %v", err)
+	}
+
+	leaveFuncs := map[uuid.UUID]func() error{}
+	for i, step := range recording.Steps {
+		clock.now = step.At
+
+		var err error
+		switch {
+		case step.Command != nil:
+			err = g.IssueCommand(*step.Command)
+		case step.Join != nil:
+			var leaveGame func() error
+			_, leaveGame, err = g.JoinGame(step.Join.PlayerID, step.Join.Name, step.Join.CardCount)
+			if leaveGame != nil {
+				leaveFuncs[step.Join.PlayerID] = leaveGame
+			}
+		case step.LeftPlayerID != nil:
+			leaveGame, ok := leaveFuncs[*step.LeftPlayerID]
+			if !ok {
+				err = fmt.Errorf("player %q never joined the game", *step.LeftPlayerID)
+				break
+			}
+			err = leaveGame()
+		case step.LeftHostID != nil:
+			err = g.LeaveAsHost(*step.LeftHostID)
+		default:
+			return result, fmt.Errorf("step %d does not have any input", i)
+		}
+
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != step.Error {
+			result.Divergences = append(result.Divergences, ReplayDivergence{
+				Step:     i,
+				Expected: step.Error,
+				Actual:   actual,
+			})
+		}
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	result.Final = g.captureState()
+	// Cleaning up isn't part of the original game, so it shouldn't show up in
+	// the results
+	g.phaseSubscriptions.observer = nil
+	if g.phase.ok() {
+		_ = g.dispose()
+	}
+	return result, nil
+}
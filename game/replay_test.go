@@ -0,0 +1,139 @@
+package game
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// playTestScenario plays part of a round, including a few inputs that are
+// expected to fail, so that there's something interesting to record.
+func playTestScenario(t *testing.T, g *Game, clock *ManualClock) {
+	t.Helper()
+
+	aliceID, _ := joinTestPlayer(t, g, "alice", 2)
+	bobID, _ := joinTestPlayer(t, g, "bob", 1)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+	mustIssueTestCommand(t, g, aliceID, bingo.GameCommandPlayerReplaceCards, nil)
+	_, leaveCarol := joinTestPlayer(t, g, "carol", 3)
+	if err := leaveCarol(); err != nil {
+		t.Fatalf("carol was unable to leave: %v", err)
+	}
+
+	for range 5 {
+		clock.Advance(time.Second)
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	}
+	if err := issueTestCommand(t, g, testHostID, bingo.GameCommandHostUndoBall, nil); err == nil {
+		t.Fatal("undoing a ball in automatic mode should fail")
+	}
+
+	cards := g.SnapshotFor(bobID).Player.Cards
+	mustIssueTestCommand(t, g, bobID, bingo.GameCommandPlayerDaub, bingo.GameCommandPayloadPlayerDaub{
+		CardID: cards[0].ID,
+		Cell:   int(bingo.FreeSpace),
+	})
+	if err := issueTestCommand(t, g, bobID, bingo.GameCommandPlayerDaub, bingo.GameCommandPayloadPlayerDaub{
+		CardID: cards[0].ID,
+		Cell:   999,
+	}); err == nil {
+		t.Fatal("daubing a ball that doesn't exist should fail")
+	}
+	if err := issueTestCommand(t, g, aliceID, bingo.GameCommandPlayerRescindBingo, nil); err == nil {
+		t.Fatal("rescinding a bingo call that was never made should fail")
+	}
+	if _, _, err := g.JoinGame(testHostID, "host", 1); err == nil {
+		t.Fatal("the host should not be able to join their own game")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testStart)
+	g := newTestGame(t, Init{Clock: clock})
+	playTestScenario(t, g, clock)
+
+	recording, err := g.Recording()
+	if err != nil {
+		t.Fatalf("unable to get recording: %v", err)
+	}
+	g.mtx.Lock()
+	expected := g.captureState()
+	g.mtx.Unlock()
+
+	// Recordings are meant to be stored, so the replay should only depend on
+	// what survives being serialized
+	encoded, err := json.Marshal(recording)
+	if err != nil {
+		t.Fatalf("unable to encode recording: %v", err)
+	}
+	var decoded Recording
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unable to decode recording: %v", err)
+	}
+
+	result, err := Replay(decoded, nil)
+	if err != nil {
+		t.Fatalf("unable to replay recording: %v", err)
+	}
+	if len(result.Divergences) != 0 {
+		t.Fatalf("expected replay to match, got divergences %+v", result.Divergences)
+	}
+	if !reflect.DeepEqual(result.Final, expected) {
+		actualJSON, _ := json.Marshal(result.Final)
+		expectedJSON, _ := json.Marshal(expected)
+		t.Fatalf("replayed state does not match.\nexpected: %s\nactual:   %s", expectedJSON, actualJSON)
+	}
+
+	// The replay should be just as deterministic as the original game
+	again, err := Replay(decoded, nil)
+	if err != nil {
+		t.Fatalf("unable to replay recording a second time: %v", err)
+	}
+	if !reflect.DeepEqual(again.Events, result.Events) {
+		t.Fatal("replaying the same recording twice produced different events")
+	}
+}
+
+func TestReplayReportsDivergences(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testStart)
+	g := newTestGame(t, Init{Clock: clock})
+	playTestScenario(t, g, clock)
+
+	recording, err := g.Recording()
+	if err != nil {
+		t.Fatalf("unable to get recording: %v", err)
+	}
+
+	// Pretend that the first step originally failed
+	tampered := recording
+	tampered.Steps = append([]RecordedStep{}, recording.Steps...)
+	tampered.Steps[0].Error = "player could not join"
+
+	result, err := Replay(tampered, nil)
+	if err != nil {
+		t.Fatalf("unable to replay recording: %v", err)
+	}
+	if len(result.Divergences) != 1 {
+		t.Fatalf("expected exactly one divergence, got %+v", result.Divergences)
+	}
+	divergence := result.Divergences[0]
+	if divergence.Step != 0 || divergence.Expected != "player could not join" || divergence.Actual != "" {
+		t.Fatalf("divergence does not describe the tampered step: %+v", divergence)
+	}
+}
+
+func TestRecordingUnavailableWithCustomRNG(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{BallRNG: NewSeededRNG(5)})
+	if _, err := g.Recording(); err == nil {
+		t.Fatal("games with a custom ball source should not have a recording")
+	}
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"slices"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// RNG is a source of randomness for shuffling balls and generating cards.
+// Implementations don't need to be thread-safe; each source is only ever used
+// by one part of a game at a time.
+type RNG interface {
+	// Int63 returns a non-negative random 63-bit integer.
+	Int63() int64
+	// Intn returns a random integer in the range [0, n). n will always be
+	// positive.
+	Intn(n int) int
+}
+
+// NewSeededRNG creates a pseudo-random source whose output is fully
+// determined by the seed. Useful for tests, and for replaying games.
+func NewSeededRNG(seed int64) RNG {
+	return mathrand.New(mathrand.NewSource(seed))
+}
+
+// cryptoRNG reads every value from the operating system's cryptographically
+// secure random number generator.
+type cryptoRNG struct{}
+
+var _ RNG = cryptoRNG{}
+
+// NewCryptoRNG creates a source that is impossible to predict, even for
+// someone who knows how the game was set up. Games that use it can't be
+// replayed.
+func NewCryptoRNG() RNG {
+	return cryptoRNG{}
+}
+
+func (cryptoRNG) uint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// There's no way to keep a game fair without randomness, and the
+		// operating system failing to provide it means something is very
+		// wrong
+		panic(fmt.Sprintf("unable to read secure random bytes: %v", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (r cryptoRNG) Int63() int64 {
+	return int64(r.uint64() >> 1)
+}
+
+func (r cryptoRNG) Intn(n int) int {
+	// Values past the last full multiple of n get rejected, so that every
+	// result is equally likely
+	bound := uint64(n)
+	limit := ^uint64(0) - (^uint64(0) % bound)
+	for {
+		v := r.uint64()
+		if v < limit {
+			return int(v % bound)
+		}
+	}
+}
+
+// ScriptedRNG is a source that returns predetermined values, for demos and QA
+// sessions where everything needs to be known in advance. It can also dictate
+// the exact order that a ball registry draws balls in.
+type ScriptedRNG struct {
+	values     []int
+	nextValue  int
+	drawOrders [][]bingo.Ball
+	nextOrder  int
+}
+
+var _ RNG = &ScriptedRNG{}
+
+// drawScript is implemented by sources that dictate each round's ball order,
+// rather than providing randomness for shuffling
+type drawScript interface {
+	// nextDrawOrder returns the order that every ball should be drawn in for
+	// the next round, or false if the source doesn't have an order
+	nextDrawOrder() ([]bingo.Ball, bool)
+}
+
+var _ drawScript = &ScriptedRNG{}
+
+// NewScriptedRNG creates a source that returns the given values in order,
+// starting over from the beginning once it runs out. Intn reduces each value
+// modulo n, so any script is valid. A source without any values always
+// returns 0.
+func NewScriptedRNG(values ...int) *ScriptedRNG {
+	return &ScriptedRNG{
+		values:     slices.Clone(values),
+		nextValue:  0,
+		drawOrders: nil,
+		nextOrder:  0,
+	}
+}
+
+// NewScriptedDraws creates a source for a ball registry that draws balls in
+// the given orders, with one order per round (starting over once it runs
+// out). Each order lists balls in the order they should be drawn; any balls
+// that are left out are drawn afterwards in ascending order.
+//
+// The orders only cover the balls for the given variant. A game for any other
+// variant ignores them, and shuffles its balls normally.
+//
+// Scripted draws are still committed to and revealed, but they are flagged as
+// scripted, because there is no seed to verify them against.
+func NewScriptedDraws(variant bingo.Variant, orders ...[]bingo.Ball) (*ScriptedRNG, error) {
+	if len(orders) == 0 {
+		return nil, errors.New("must provide at least one ball order")
+	}
+
+	var completed [][]bingo.Ball
+	for i, order := range orders {
+		full, err := completeDrawOrder(order, variant.MaxBall)
+		if err != nil {
+			return nil, fmt.Errorf("order %d is not valid: %v", i+1, err)
+		}
+		completed = append(completed, full)
+	}
+
+	source := NewScriptedRNG()
+	source.drawOrders = completed
+	return source, nil
+}
+
+// completeDrawOrder adds every ball from 1 to maxBall that is missing from a
+// partial draw order to the end of it, in ascending order.
+func completeDrawOrder(order []bingo.Ball, maxBall int) ([]bingo.Ball, error) {
+	full := make([]bingo.Ball, 0, maxBall)
+	for _, b := range order {
+		if b == bingo.FreeSpace || int(b) > maxBall {
+			return nil, fmt.Errorf("%d is not a valid bingo ball", b)
+		}
+		if slices.Contains(full, b) {
+			return nil, fmt.Errorf("ball %d is listed more than once", b)
+		}
+		full = append(full, b)
+	}
+	for _, b := range generateBingoBallsForRange(1, maxBall) {
+		if !slices.Contains(full, b) {
+			full = append(full, b)
+		}
+	}
+	return full, nil
+}
+
+func (s *ScriptedRNG) next() int {
+	if len(s.values) == 0 {
+		return 0
+	}
+	v := s.values[s.nextValue]
+	s.nextValue = (s.nextValue + 1) % len(s.values)
+	return v
+}
+
+// Int63 returns the next value in the script. Negative values are flipped to
+// be positive.
+func (s *ScriptedRNG) Int63() int64 {
+	v := int64(s.next())
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Intn returns the next value in the script, reduced modulo n.
+func (s *ScriptedRNG) Intn(n int) int {
+	v := s.next() % n
+	if v < 0 {
+		v += n
+	}
+	return v
+}
+
+func (s *ScriptedRNG) nextDrawOrder() ([]bingo.Ball, bool) {
+	if len(s.drawOrders) == 0 {
+		return nil, false
+	}
+	order := s.drawOrders[s.nextOrder]
+	s.nextOrder = (s.nextOrder + 1) % len(s.drawOrders)
+	return slices.Clone(order), true
+}
@@ -0,0 +1,188 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// endRound moves the game into the round end phase, and then immediately runs
+// all the upkeep needed to start the next round. If the final round just
+// ended, the game will end instead. This method is NOT thread-safe.
+func (g *Game) endRound(commanderID uuid.UUID, winners []*bingo.Player) error {
+	if err := g.changePhase(bingo.GamePhaseRoundEnd, commanderID); err != nil {
+		return err
+	}
+
+	winnerIDs := []uuid.UUID{}
+	var names []string
+	for _, w := range winners {
+		winnerIDs = append(winnerIDs, w.ID)
+		names = append(names, w.Name)
+	}
+	// A tiebreaker can end without any winners if every tied player leaves
+	message := fmt.Sprintf("round %d won by %s", g.currentRound, strings.Join(names, ", "))
+	if len(names) == 0 {
+		message = fmt.Sprintf("round %d ended without a winner", g.currentRound)
+	}
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       bingo.GamePhaseRoundEnd,
+		Type:        bingo.EventTypeRoundEnded,
+		CreatedByID: commanderID,
+		Message:     message,
+		Payload: eventPayload(bingo.GameEventPayloadRoundEnded{
+			Round:     g.currentRound,
+			WinnerIDs: winnerIDs,
+		}),
+		RecipientIDs: nil,
+	})
+	g.dispatchDrawRevealed(commanderID)
+
+	g.bingoCallerPlayerIDs = nil
+	g.acceptedCallerPlayerIDs = nil
+	g.pauseAutoCallerForRoundEnd(commanderID)
+	g.ageSuspensions(commanderID)
+	if g.currentRound >= g.maxRounds {
+		return g.endGame(commanderID)
+	}
+
+	return g.startNextRound(commanderID)
+}
+
+// startNextRound handles all upkeep that needs to happen at the start of a
+// round, and then moves the game into the round start phase. This method is
+// NOT thread-safe.
+func (g *Game) startNextRound(commanderID uuid.UUID) error {
+	g.ballRegistry.reset()
+	g.needsCheckpoint = true
+	for _, e := range g.cardPlayers {
+		for _, card := range e.player.Cards {
+			clearDaubs(card)
+		}
+	}
+	g.promoteWaitlistedPlayers(commanderID)
+
+	g.currentRound++
+	if err := g.changePhase(bingo.GamePhaseRoundStart, commanderID); err != nil {
+		return err
+	}
+	g.dispatchDrawCommitted(commanderID)
+	return nil
+}
+
+// dispatchDrawCommitted publishes the commitment for the current round's
+// draw, so that players can verify the draw once the round is over. This
+// method is NOT thread-safe.
+func (g *Game) dispatchDrawCommitted(commanderID uuid.UUID) {
+	commitment, scripted := g.ballRegistry.commitment()
+	message := fmt.Sprintf("committed to draw for round %d: %s", g.currentRound, commitment)
+	if scripted {
+		message += " (scripted)"
+	}
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:       g.phase.value(),
+		Type:        bingo.EventTypeDrawCommitted,
+		CreatedByID: commanderID,
+		Message:     message,
+		Payload: eventPayload(bingo.GameEventPayloadDrawCommitted{
+			Round:      g.currentRound,
+			Commitment: commitment,
+			Scripted:   scripted,
+		}),
+		RecipientIDs: nil,
+	})
+}
+
+// dispatchDrawRevealed reveals everything needed to verify the current
+// round's draw. It must only be called once no more balls can be called for
+// the round. This method is NOT thread-safe.
+func (g *Game) dispatchDrawRevealed(commanderID uuid.UUID) {
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        g.phase.value(),
+		Type:         bingo.EventTypeDrawRevealed,
+		CreatedByID:  commanderID,
+		Message:      fmt.Sprintf("revealed draw for round %d", g.currentRound),
+		Payload:      eventPayload(g.ballRegistry.reveal(g.currentRound, g.variant.ID)),
+		RecipientIDs: nil,
+	})
+}
+
+// promoteWaitlistedPlayers makes every waitlisted player active, so that they
+// can participate in the upcoming round. This method is NOT thread-safe.
+func (g *Game) promoteWaitlistedPlayers(commanderID uuid.UUID) {
+	for _, e := range g.cardPlayers {
+		if e.player.Status != bingo.PlayerStatusWaitlisted {
+			continue
+		}
+
+		e.player.Status = bingo.PlayerStatusActive
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Phase:       g.phase.value(),
+			Type:        bingo.EventTypePlayerPromoted,
+			CreatedByID: commanderID,
+			Message:     fmt.Sprintf("player %q has been moved off the waitlist", e.player.Name),
+			Payload: eventPayload(bingo.GameEventPayloadPlayer{
+				PlayerID: e.player.ID,
+				Name:     e.player.Name,
+				Status:   e.player.Status,
+			}),
+			RecipientIDs: g.hostRecipients(bingo.HostPermissionModeratePlayers, e.player.ID),
+		})
+	}
+}
+
+// ageSuspensions counts the round that just ended towards every active
+// suspension. Any players whose suspensions have fully elapsed are reinstated.
+// This method is NOT thread-safe.
+func (g *Game) ageSuspensions(commanderID uuid.UUID) {
+	var remaining []*bingo.PlayerSuspension
+	for _, s := range g.suspensions {
+		s.RoundsPassed++
+		if s.RoundsPassed < s.RoundDuration {
+			remaining = append(remaining, s)
+			continue
+		}
+
+		entry := g.findPlayerEntry(s.PlayerID)
+		if entry == nil || entry.player.Status != bingo.PlayerStatusSuspended {
+			continue
+		}
+		entry.player.Status = bingo.PlayerStatusActive
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Phase:       g.phase.value(),
+			Type:        bingo.EventTypePlayerReinstated,
+			CreatedByID: commanderID,
+			Message:     fmt.Sprintf("suspension for player %q has ended", entry.player.Name),
+			Payload: eventPayload(bingo.GameEventPayloadPlayer{
+				PlayerID: entry.player.ID,
+				Name:     entry.player.Name,
+				Status:   entry.player.Status,
+			}),
+			RecipientIDs: g.hostRecipients(bingo.HostPermissionModeratePlayers, entry.player.ID),
+		})
+	}
+	g.suspensions = remaining
+}
+
+// findSuspension returns the pending suspension for a player, or nil if they
+// aren't suspended. This method is NOT thread-safe.
+func (g *Game) findSuspension(playerID uuid.UUID) *bingo.PlayerSuspension {
+	for _, s := range g.suspensions {
+		if s.PlayerID == playerID {
+			return s
+		}
+	}
+	return nil
+}
+
+// clearDaubs removes every daub from a card, so that it can be reused in a new
+// round.
+func clearDaubs(card *bingo.Card) {
+	for _, row := range card.Cells {
+		for _, cell := range row {
+			cell.Daubed = false
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// subscribeTestEvents collects every public event the game dispatches. The
+// returned function blocks until the game has been disposed, and then returns
+// everything that was collected.
+func subscribeTestEvents(t *testing.T, g *Game) func() []bingo.GameEvent {
+	t.Helper()
+
+	receiver, _, err := g.Subscribe(nil)
+	if err != nil {
+		t.Fatalf("unable to subscribe to game: %v", err)
+	}
+	var events []bingo.GameEvent
+	done := make(chan struct{})
+	go func() {
+		for event := range receiver {
+			events = append(events, event)
+		}
+		close(done)
+	}()
+	return func() []bingo.GameEvent {
+		<-done
+		return events
+	}
+}
+
+// findPhaseChange returns the payload of the event that moved the game into
+// the given phase, or nil if the game never moved into it.
+func findPhaseChange(t *testing.T, events []bingo.GameEvent, phase bingo.GamePhase) *bingo.GameEventPayloadPhaseChanged {
+	t.Helper()
+
+	for _, event := range events {
+		if event.Type != bingo.EventTypePhaseChanged {
+			continue
+		}
+		var payload bingo.GameEventPayloadPhaseChanged
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			t.Fatalf("unable to parse phase change: %v", err)
+		}
+		if payload.Phase == phase {
+			return &payload
+		}
+	}
+	return nil
+}
+
+// callEveryTestBall has the host call balls until there are none left.
+func callEveryTestBall(t *testing.T, g *Game) {
+	t.Helper()
+
+	for range g.variant.MaxBall {
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	}
+}
+
+func TestGameOverIsAnnounced(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// play runs right after the first round starts, and should end the
+		// game
+		play          func(t *testing.T, g *Game)
+		previousPhase bingo.GamePhase
+	}{
+		{
+			name: "Host terminates the game",
+			play: func(t *testing.T, g *Game) {
+				mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+				mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostTerminateGame, nil)
+			},
+			previousPhase: bingo.GamePhaseCalling,
+		},
+		{
+			name: "Final round ends without a winner",
+			play: func(t *testing.T, g *Game) {
+				callEveryTestBall(t, g)
+				mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostEndRound, nil)
+			},
+			previousPhase: bingo.GamePhaseRoundEnd,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			maxRounds := 1
+			g := newTestGame(t, Init{MaxRounds: &maxRounds})
+			joinTestPlayer(t, g, "player", 1)
+			collect := subscribeTestEvents(t, g)
+			mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+			tc.play(t, g)
+
+			change := findPhaseChange(t, collect(), bingo.GamePhaseGameOver)
+			if change == nil {
+				t.Fatal("expected the move to game over to be announced")
+			}
+			if change.PreviousPhase != tc.previousPhase {
+				t.Fatalf("expected game to end from phase %q, got %q", tc.previousPhase, change.PreviousPhase)
+			}
+		})
+	}
+}
+
+func TestEndRoundWithoutWinner(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	joinTestPlayer(t, g, "player", 1)
+	collect := subscribeTestEvents(t, g)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	if err := issueTestCommand(t, g, testHostID, bingo.GameCommandHostEndRound, nil); err == nil {
+		t.Fatal("rounds should not be able to end early while there are balls left")
+	}
+	for range g.variant.MaxBall - 1 {
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	}
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostEndRound, nil)
+
+	snapshot := g.Snapshot()
+	if snapshot.Phase != bingo.GamePhaseRoundStart {
+		t.Fatalf("expected the next round to be ready to start, got phase %q", snapshot.Phase)
+	}
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostTerminateGame, nil)
+
+	var ended *bingo.GameEventPayloadRoundEnded
+	for _, event := range collect() {
+		if event.Type != bingo.EventTypeRoundEnded {
+			continue
+		}
+		ended = &bingo.GameEventPayloadRoundEnded{}
+		if err := json.Unmarshal(event.Payload, ended); err != nil {
+			t.Fatalf("unable to parse round end: %v", err)
+		}
+	}
+	if ended == nil {
+		t.Fatal("expected the end of the round to be announced")
+	}
+	if ended.Round != 1 || len(ended.WinnerIDs) != 0 {
+		t.Fatalf("expected round 1 to end without any winners, got %+v", ended)
+	}
+}
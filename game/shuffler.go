@@ -1,21 +1,19 @@
 package game
 
 import (
-	"math/rand"
-
 	"github.com/Parkreiner/bingo"
 )
 
-// shuffler provides methods for shuffling bingo calls using seed-based random
-// logic.
+// shuffler provides methods for shuffling bingo calls using whatever source
+// of randomness it was given.
 type shuffler struct {
-	rng *rand.Rand
+	rng RNG
 }
 
 // newShuffler creates a new instance of a Shuffler
-func newShuffler(rngSeed int64) *shuffler {
+func newShuffler(rng RNG) *shuffler {
 	return &shuffler{
-		rng: rand.New(rand.NewSource(rngSeed)),
+		rng: rng,
 	}
 }
 
@@ -0,0 +1,191 @@
+package game
+
+import (
+	"slices"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// Snapshot produces an immutable snapshot of the entire public game state.
+func (g *Game) Snapshot() bingo.GameSnapshot {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.publicSnapshot()
+}
+
+// SnapshotFor produces an immutable snapshot of the game state, projected for
+// a specific viewer. The host can see the claim status for every player, card
+// players can see their own cards, and everyone else only gets public state.
+// Co-hosts get to see both what the host sees and their own cards.
+func (g *Game) SnapshotFor(viewerID uuid.UUID) bingo.ViewerSnapshot {
+	// Everything needs to be read in a single critical section, so that the
+	// public and private parts of the snapshot can't disagree with each other
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	snapshot := g.viewerSnapshot(viewerID)
+	// The viewer is expected to ask for diffs against this snapshot later
+	if doc, err := toJSONDocument(&snapshot); err == nil {
+		g.rememberSentSnapshot(viewerID, snapshot.Game.Version, doc)
+	}
+	return snapshot
+}
+
+// viewerSnapshot produces a snapshot projected for a specific viewer. This
+// method is NOT thread-safe.
+func (g *Game) viewerSnapshot(viewerID uuid.UUID) bingo.ViewerSnapshot {
+	snapshot := bingo.ViewerSnapshot{
+		ViewerID: viewerID,
+		Role:     bingo.ViewerRoleSpectator,
+		Game:     g.publicSnapshot(),
+		Player:   nil,
+		Host:     nil,
+	}
+
+	if viewerID == g.host.ID {
+		snapshot.Role = bingo.ViewerRoleHost
+		snapshot.Host = g.hostView()
+		return snapshot
+	}
+	if entry := g.findPlayerEntry(viewerID); entry != nil {
+		snapshot.Role = bingo.ViewerRolePlayer
+		snapshot.Player = g.playerView(entry.player)
+		if g.findCoHost(viewerID) != nil {
+			snapshot.Role = bingo.ViewerRoleCoHost
+			snapshot.Host = g.hostView()
+		}
+	}
+	return snapshot
+}
+
+// publicSnapshot produces the part of a snapshot that anyone is allowed to
+// see. This method is NOT thread-safe.
+func (g *Game) publicSnapshot() bingo.GameSnapshot {
+	players := []bingo.PlayerSummary{}
+	for _, e := range g.cardPlayers {
+		players = append(players, g.summarizePlayer(e.player))
+	}
+	winners := []bingo.PlayerSummary{}
+	for _, p := range g.winningPlayers {
+		winners = append(winners, g.summarizePlayer(p))
+	}
+	coHosts := []bingo.CoHost{}
+	for _, c := range g.coHosts {
+		coHosts = append(coHosts, bingo.CoHost{
+			PlayerID:    c.PlayerID,
+			Permissions: slices.Clone(c.Permissions),
+		})
+	}
+	commitment, _ := g.ballRegistry.commitment()
+	var tiebreaker *bingo.TiebreakerStatus
+	if g.tiebreaker != nil {
+		tiebreaker = g.tiebreaker.status()
+	}
+
+	return bingo.GameSnapshot{
+		Version:           g.version,
+		Phase:             g.phase.value(),
+		Called:            g.ballRegistry.getCalledBalls(),
+		WinPattern:        g.winPattern,
+		CallingMode:       g.callingMode,
+		Variant:           g.variant.ID,
+		MaxCardsPerPlayer: g.maxCardsPerPlayer,
+		AutoCaller:        g.autoCaller.status(),
+		Round:             g.currentRound,
+		MaxRounds:         g.maxRounds,
+		DrawCommitment:    commitment,
+		HostID:            g.host.ID,
+		HostName:          g.host.Name,
+		CoHosts:           coHosts,
+		Players:           players,
+		WinningPlayers:    winners,
+		Tiebreaker:        tiebreaker,
+	}
+}
+
+// playerView produces the private state for a single card player. This method
+// is NOT thread-safe.
+func (g *Game) playerView(player *bingo.Player) *bingo.PlayerView {
+	cards := []bingo.Card{}
+	for _, c := range player.Cards {
+		cards = append(cards, copyCard(c))
+	}
+
+	var suspension *bingo.PlayerSuspension
+	for _, s := range g.suspensions {
+		if s.PlayerID == player.ID {
+			copied := *s
+			suspension = &copied
+			break
+		}
+	}
+
+	return &bingo.PlayerView{
+		Player:     g.summarizePlayer(player),
+		Cards:      cards,
+		Suspension: suspension,
+	}
+}
+
+// hostView produces the private state that only the host can see. This method
+// is NOT thread-safe.
+func (g *Game) hostView() *bingo.HostView {
+	called := g.ballRegistry.getCalledBalls()
+	claims := []bingo.PlayerClaimStatus{}
+	for _, e := range g.cardPlayers {
+		status := bingo.PlayerClaimStatus{
+			PlayerID:    e.player.ID,
+			CalledBingo: slices.Contains(g.bingoCallerPlayerIDs, e.player.ID),
+			Validation:  nil,
+		}
+		if status.CalledBingo {
+			validation := validateClaim(e.player, called, g.winPattern).export()
+			status.Validation = &validation
+		}
+		claims = append(claims, status)
+	}
+
+	suspensions := []bingo.PlayerSuspension{}
+	for _, s := range g.suspensions {
+		suspensions = append(suspensions, *s)
+	}
+
+	return &bingo.HostView{
+		Claims:          claims,
+		Suspensions:     suspensions,
+		BannedPlayerIDs: append([]uuid.UUID{}, g.bannedPlayerIDs...),
+	}
+}
+
+// summarizePlayer produces the public information for a player. This method
+// is NOT thread-safe.
+func (g *Game) summarizePlayer(player *bingo.Player) bingo.PlayerSummary {
+	return bingo.PlayerSummary{
+		ID:          player.ID,
+		Name:        player.Name,
+		Status:      player.Status,
+		CardCount:   len(player.Cards),
+		CalledBingo: slices.Contains(g.bingoCallerPlayerIDs, player.ID),
+	}
+}
+
+// copyCard makes a deep copy of a card, so that a snapshot can't be affected
+// by any daubs made after it was taken.
+func copyCard(card *bingo.Card) bingo.Card {
+	var cells [][]*bingo.Cell
+	for _, row := range card.Cells {
+		var copiedRow []*bingo.Cell
+		for _, cell := range row {
+			copied := *cell
+			copiedRow = append(copiedRow, &copied)
+		}
+		cells = append(cells, copiedRow)
+	}
+
+	return bingo.Card{
+		Cells:    cells,
+		ID:       card.ID,
+		PlayerID: card.PlayerID,
+	}
+}
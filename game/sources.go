@@ -0,0 +1,160 @@
+package game
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// idSeedOffset keeps a game's IDs from being derived from the exact same
+// random sequence as its cards and balls
+const idSeedOffset int64 = 0x5eed1d5
+
+// IDSource produces every ID that a game generates on its own (e.g., card and
+// event IDs).
+type IDSource interface {
+	NewID() uuid.UUID
+}
+
+// Clock produces the timestamps for every event that a game dispatches, and
+// schedules everything that a game does on its own (e.g., auto-calling
+// balls).
+type Clock interface {
+	Now() time.Time
+	// AfterFunc waits for the duration to elapse, and then calls f in its own
+	// goroutine. The returned function cancels the call, and reports whether
+	// it stopped f from being called.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// seededIDSource produces UUIDs from a pseudo-random sequence, so that the
+// same seed always produces the same IDs in the same order.
+type seededIDSource struct {
+	rng *rand.Rand
+}
+
+var _ IDSource = &seededIDSource{}
+
+// NewSeededIDSource creates an IDSource whose IDs are fully determined by the
+// seed. The IDs are formatted as version 4 UUIDs, but they are NOT safe to use
+// anywhere that IDs need to be unguessable.
+func NewSeededIDSource(seed int64) IDSource {
+	return &seededIDSource{
+		rng: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *seededIDSource) NewID() uuid.UUID {
+	var id uuid.UUID
+	_, _ = s.rng.Read(id[:])
+	// Set the version and variant bits the same way uuid.New does
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return id
+}
+
+// randomIDSource produces fully random IDs.
+type randomIDSource struct{}
+
+var _ IDSource = randomIDSource{}
+
+// NewRandomIDSource creates an IDSource whose IDs are impossible to predict.
+// Games that use it can't be replayed, but nobody can learn anything about
+// the game's other sources from its IDs.
+func NewRandomIDSource() IDSource {
+	return randomIDSource{}
+}
+
+func (randomIDSource) NewID() uuid.UUID {
+	return uuid.New()
+}
+
+// systemClock reads the time from the system.
+type systemClock struct{}
+
+var _ Clock = systemClock{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// ManualClock is a Clock that only moves forward when it is told to, for
+// testing anything that depends on timing. It is safe to use from multiple
+// goroutines.
+type ManualClock struct {
+	now    time.Time
+	timers []*manualTimer
+	mtx    sync.Mutex
+}
+
+var _ Clock = &ManualClock{}
+
+type manualTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+// NewManualClock creates a clock that starts at the given time.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{
+		now:    start,
+		timers: nil,
+		mtx:    sync.Mutex{},
+	}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	timer := &manualTimer{
+		at:      c.now.Add(d),
+		f:       f,
+		stopped: false,
+	}
+	c.timers = append(c.timers, timer)
+	return func() bool {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		if timer.stopped {
+			return false
+		}
+		timer.stopped = true
+		c.timers = slices.DeleteFunc(c.timers, func(t *manualTimer) bool {
+			return t == timer
+		})
+		return true
+	}
+}
+
+// Advance moves the clock forward, and calls every function that has come
+// due. Just like with time.AfterFunc, each function runs in its own
+// goroutine, so Advance doesn't wait for any of them to finish.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.now = c.now.Add(d)
+	c.timers = slices.DeleteFunc(c.timers, func(t *manualTimer) bool {
+		if t.at.After(c.now) {
+			return false
+		}
+		t.stopped = true
+		go t.f()
+		return true
+	})
+}
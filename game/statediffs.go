@@ -0,0 +1,213 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxSentSnapshots is how many previously-sent snapshots are remembered
+	// for each viewer. Viewers generally only ask for diffs against the most
+	// recent snapshot they received, so this can stay small.
+	maxSentSnapshots = 8
+	// maxDiffVersionGap is the largest version gap that can be bridged with a
+	// patch. Past that point, the patch would likely end up being bigger than
+	// a full snapshot anyway.
+	maxDiffVersionGap = 64
+)
+
+// sentSnapshot is a viewer snapshot that was handed out at a specific state
+// version. The snapshot is stored as a decoded JSON document, so that it can
+// be diffed directly against newer snapshots.
+type sentSnapshot struct {
+	version  uint64
+	document any
+}
+
+// DiffSince produces a JSON Patch that brings a viewer from the last version
+// they received up to the current version. The game falls back to a full
+// snapshot if the gap between versions is too large, or if it no longer
+// remembers what the viewer was sent at that version. Spectators always get a
+// full snapshot.
+func (g *Game) DiffSince(viewerID uuid.UUID, sinceVersion uint64) bingo.StateDiff {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	snapshot := g.viewerSnapshot(viewerID)
+	diff := bingo.StateDiff{
+		ViewerID:    viewerID,
+		FromVersion: sinceVersion,
+		ToVersion:   g.version,
+		Patch:       nil,
+		Snapshot:    nil,
+	}
+
+	current, err := toJSONDocument(&snapshot)
+	if err != nil {
+		diff.Snapshot = &snapshot
+		return diff
+	}
+	g.rememberSentSnapshot(viewerID, g.version, current)
+
+	canPatch := sinceVersion <= g.version && g.version-sinceVersion <= maxDiffVersionGap
+	if canPatch {
+		if base, ok := g.findSentSnapshot(viewerID, sinceVersion); ok {
+			diff.Patch = diffJSONDocuments("", base.document, current, []bingo.PatchOperation{})
+			return diff
+		}
+	}
+
+	diff.Snapshot = &snapshot
+	return diff
+}
+
+// rememberSentSnapshot stores a snapshot that was just handed out to a viewer,
+// evicting the oldest snapshot if the viewer is over the limit. Storing a
+// snapshot for a version that has already been stored replaces the old one.
+// Only snapshots for the host and card players are stored, since those are
+// the only viewers that get cleaned up once they leave. This method is NOT
+// thread-safe.
+func (g *Game) rememberSentSnapshot(viewerID uuid.UUID, version uint64, document any) {
+	// Anybody can ask for a snapshot, so remembering snapshots for every ID
+	// would let the map grow without limit
+	if viewerID != g.host.ID && g.findPlayerEntry(viewerID) == nil {
+		return
+	}
+	history := slices.DeleteFunc(g.sentSnapshots[viewerID], func(s sentSnapshot) bool {
+		return s.version == version
+	})
+	history = append(history, sentSnapshot{version: version, document: document})
+	if len(history) > maxSentSnapshots {
+		history = history[len(history)-maxSentSnapshots:]
+	}
+	g.sentSnapshots[viewerID] = history
+}
+
+// findSentSnapshot finds the snapshot that a viewer was sent at a specific
+// version. This method is NOT thread-safe.
+func (g *Game) findSentSnapshot(viewerID uuid.UUID, version uint64) (sentSnapshot, bool) {
+	for _, s := range g.sentSnapshots[viewerID] {
+		if s.version == version {
+			return s, true
+		}
+	}
+	return sentSnapshot{}, false
+}
+
+// toJSONDocument serializes a value, and then decodes it into generic maps and
+// slices so that it can be diffed. Numbers are kept as json.Number to avoid
+// any precision loss from going through float64.
+func toJSONDocument(value any) (any, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(b)))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("unable to decode document: %v", err)
+	}
+	return document, nil
+}
+
+// diffJSONDocuments appends all the operations needed to turn the before
+// document into the after document. Objects are diffed key by key, and arrays
+// are diffed index by index; anything else that has changed gets replaced
+// wholesale.
+func diffJSONDocuments(path string, before any, after any, ops []bingo.PatchOperation) []bingo.PatchOperation {
+	if reflect.DeepEqual(before, after) {
+		return ops
+	}
+
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		// Keys are sorted so that the same two documents always produce the
+		// same patch
+		for _, key := range sortedKeys(b) {
+			if _, ok := a[key]; !ok {
+				ops = append(ops, bingo.PatchOperation{
+					Op:    bingo.PatchOperationRemove,
+					Path:  path + "/" + escapePointerToken(key),
+					Value: nil,
+				})
+			}
+		}
+		for _, key := range sortedKeys(a) {
+			childPath := path + "/" + escapePointerToken(key)
+			if prev, ok := b[key]; ok {
+				ops = diffJSONDocuments(childPath, prev, a[key], ops)
+				continue
+			}
+			ops = append(ops, patchWithValue(bingo.PatchOperationAdd, childPath, a[key]))
+		}
+		return ops
+
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			break
+		}
+		shared := min(len(b), len(a))
+		for i := 0; i < shared; i++ {
+			ops = diffJSONDocuments(fmt.Sprintf("%s/%d", path, i), b[i], a[i], ops)
+		}
+		// Removals have to go back to front, so that removing one element
+		// doesn't shift the indices of the ones after it
+		for i := len(b) - 1; i >= shared; i-- {
+			ops = append(ops, bingo.PatchOperation{
+				Op:    bingo.PatchOperationRemove,
+				Path:  fmt.Sprintf("%s/%d", path, i),
+				Value: nil,
+			})
+		}
+		for i := shared; i < len(a); i++ {
+			ops = append(ops, patchWithValue(bingo.PatchOperationAdd, fmt.Sprintf("%s/%d", path, i), a[i]))
+		}
+		return ops
+	}
+
+	return append(ops, patchWithValue(bingo.PatchOperationReplace, path, after))
+}
+
+// patchWithValue produces an operation that carries a value. Every value comes
+// from a decoded JSON document, so re-encoding it should never fail.
+func patchWithValue(op bingo.PatchOperationType, path string, value any) bingo.PatchOperation {
+	b, err := json.Marshal(value)
+	if err != nil {
+		b = []byte("null")
+	}
+	return bingo.PatchOperation{
+		Op:    op,
+		Path:  path,
+		Value: b,
+	}
+}
+
+// sortedKeys returns all the keys of a JSON object in sorted order.
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointerToken escapes a single key so that it can be used in a JSON
+// Pointer.
+func escapePointerToken(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,190 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+)
+
+// parseTestDocument decodes JSON the same way as toJSONDocument.
+func parseTestDocument(t *testing.T, raw string) any {
+	t.Helper()
+
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		t.Fatalf("unable to decode %s: %v", raw, err)
+	}
+	return document
+}
+
+func TestDiffJSONDocuments(t *testing.T) {
+	t.Parallel()
+
+	add := func(path string, value string) bingo.PatchOperation {
+		return bingo.PatchOperation{Op: bingo.PatchOperationAdd, Path: path, Value: json.RawMessage(value)}
+	}
+	remove := func(path string) bingo.PatchOperation {
+		return bingo.PatchOperation{Op: bingo.PatchOperationRemove, Path: path, Value: nil}
+	}
+	replace := func(path string, value string) bingo.PatchOperation {
+		return bingo.PatchOperation{Op: bingo.PatchOperationReplace, Path: path, Value: json.RawMessage(value)}
+	}
+
+	testCases := []struct {
+		name     string
+		before   string
+		after    string
+		expected []bingo.PatchOperation
+	}{
+		{
+			name:     "Identical documents",
+			before:   `{"a":[1,{"b":null}],"c":"d"}`,
+			after:    `{"a":[1,{"b":null}],"c":"d"}`,
+			expected: []bingo.PatchOperation{},
+		},
+		{
+			name:     "Different root values",
+			before:   `1`,
+			after:    `"one"`,
+			expected: []bingo.PatchOperation{replace("", `"one"`)},
+		},
+		{
+			name:     "Object gains a key",
+			before:   `{"a":1}`,
+			after:    `{"a":1,"b":{"c":true}}`,
+			expected: []bingo.PatchOperation{add("/b", `{"c":true}`)},
+		},
+		{
+			name:     "Object loses a key",
+			before:   `{"a":1,"b":2}`,
+			after:    `{"b":2}`,
+			expected: []bingo.PatchOperation{remove("/a")},
+		},
+		{
+			name:     "Nested value changes",
+			before:   `{"a":{"b":1,"c":2}}`,
+			after:    `{"a":{"b":1,"c":3}}`,
+			expected: []bingo.PatchOperation{replace("/a/c", `3`)},
+		},
+		{
+			name:   "Removals come before everything else, and keys are sorted",
+			before: `{"z":1,"m":1,"a":1}`,
+			after:  `{"y":2,"m":2,"b":2}`,
+			expected: []bingo.PatchOperation{
+				remove("/a"),
+				remove("/z"),
+				add("/b", `2`),
+				replace("/m", `2`),
+				add("/y", `2`),
+			},
+		},
+		{
+			name:   "Array grows",
+			before: `[1,2]`,
+			after:  `[1,2,3,4]`,
+			expected: []bingo.PatchOperation{
+				add("/2", `3`),
+				add("/3", `4`),
+			},
+		},
+		{
+			name:   "Array shrinks from the back",
+			before: `{"a":[1,2,3,4]}`,
+			after:  `{"a":[1]}`,
+			expected: []bingo.PatchOperation{
+				remove("/a/3"),
+				remove("/a/2"),
+				remove("/a/1"),
+			},
+		},
+		{
+			name:     "Array element changes",
+			before:   `[{"id":1,"daubed":false},{"id":2,"daubed":false}]`,
+			after:    `[{"id":1,"daubed":false},{"id":2,"daubed":true}]`,
+			expected: []bingo.PatchOperation{replace("/1/daubed", `true`)},
+		},
+		{
+			name:     "Null becomes an empty array",
+			before:   `{"a":null}`,
+			after:    `{"a":[]}`,
+			expected: []bingo.PatchOperation{replace("/a", `[]`)},
+		},
+		{
+			name:     "Empty array becomes null",
+			before:   `{"a":[]}`,
+			after:    `{"a":null}`,
+			expected: []bingo.PatchOperation{replace("/a", `null`)},
+		},
+		{
+			name:     "Object becomes an array",
+			before:   `{"a":{"0":1}}`,
+			after:    `{"a":[1]}`,
+			expected: []bingo.PatchOperation{replace("/a", `[1]`)},
+		},
+		{
+			name:   "Keys with pointer characters are escaped",
+			before: `{"a/b":1,"m~n":{"x":1}}`,
+			after:  `{"a/b":2,"m~n":{"x":1,"~/":true}}`,
+			expected: []bingo.PatchOperation{
+				replace("/a~1b", `2`),
+				add("/m~0n/~0~1", `true`),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			before := parseTestDocument(t, tc.before)
+			after := parseTestDocument(t, tc.after)
+			actual := diffJSONDocuments("", before, after, []bingo.PatchOperation{})
+
+			actualJSON, err := json.Marshal(actual)
+			if err != nil {
+				t.Fatalf("unable to encode patch: %v", err)
+			}
+			expectedJSON, err := json.Marshal(tc.expected)
+			if err != nil {
+				t.Fatalf("unable to encode expected patch: %v", err)
+			}
+			if string(actualJSON) != string(expectedJSON) {
+				t.Fatalf("patches do not match.\nexpected: %s\nactual:   %s", expectedJSON, actualJSON)
+			}
+		})
+	}
+}
+
+func TestSentSnapshotsOnlyKeptForParticipants(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	playerID, _ := joinTestPlayer(t, g, "player", 1)
+	spectatorID := testPlayerID("spectator")
+
+	hostSnapshot := g.SnapshotFor(testHostID)
+	playerSnapshot := g.SnapshotFor(playerID)
+	spectatorSnapshot := g.SnapshotFor(spectatorID)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+
+	if diff := g.DiffSince(testHostID, hostSnapshot.Game.Version); diff.Snapshot != nil {
+		t.Fatal("expected the host to get a patch")
+	}
+	if diff := g.DiffSince(playerID, playerSnapshot.Game.Version); diff.Snapshot != nil {
+		t.Fatal("expected the player to get a patch")
+	}
+	if diff := g.DiffSince(spectatorID, spectatorSnapshot.Game.Version); diff.Snapshot == nil {
+		t.Fatal("expected the spectator to get a full snapshot")
+	}
+
+	g.mtx.Lock()
+	_, remembered := g.sentSnapshots[spectatorID]
+	g.mtx.Unlock()
+	if remembered {
+		t.Fatal("snapshots sent to the spectator should not be remembered")
+	}
+}
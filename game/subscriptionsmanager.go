@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Parkreiner/bingo"
@@ -28,7 +29,19 @@ type subscriptionsManager struct {
 	routineBuffer chan struct{}
 	// Should always be unbuffered
 	disposedChan chan struct{}
-	mtx          *sync.Mutex
+	// observer is notified about every event right before it is dispatched,
+	// after all its fields have been backfilled. It is called with the
+	// manager's mutex held, so it should never try to dispatch anything.
+	observer func(event bingo.GameEvent)
+	// muted managers still notify the observer about new events, but don't
+	// send them to any subscribers. Useful for replaying changes that every
+	// subscriber has already seen.
+	muted bool
+	// newID and now are used to backfill any events that are missing their
+	// IDs or timestamps
+	newID func() uuid.UUID
+	now   func() time.Time
+	mtx   *sync.Mutex
 }
 
 func newSubscriptionsManager() subscriptionsManager {
@@ -43,6 +56,10 @@ func newSubscriptionsManager() subscriptionsManager {
 		routineBufferSize: maxSubscriberGoroutines,
 		mtx:               &sync.Mutex{},
 		disposedChan:      make(chan struct{}),
+		observer:          nil,
+		muted:             false,
+		newID:             uuid.New,
+		now:               time.Now,
 	}
 }
 
@@ -63,8 +80,8 @@ func (sm *subscriptionsManager) disposed() bool {
 // thread-safe; it is the rest of the struct's responsibility to call the method
 // with any necessary thread protections.
 func (sm *subscriptionsManager) dispatchUnsafe(event bingo.GameEvent) error {
-	maxBroadcasts := len(sm.subs)
-	successfulBroadcasts := 0
+	maxBroadcasts := 0
+	var successfulBroadcasts atomic.Int64
 	wg := sync.WaitGroup{}
 
 	for _, s := range sm.subs {
@@ -72,6 +89,7 @@ func (sm *subscriptionsManager) dispatchUnsafe(event bingo.GameEvent) error {
 			continue
 		}
 
+		maxBroadcasts++
 		wg.Add(1)
 		<-sm.routineBuffer
 		go func() {
@@ -82,14 +100,14 @@ func (sm *subscriptionsManager) dispatchUnsafe(event bingo.GameEvent) error {
 
 			select {
 			case s.eventChan <- event:
-				successfulBroadcasts++
+				successfulBroadcasts.Add(1)
 			case <-time.After(2 * time.Second):
 			}
 		}()
 	}
 	wg.Wait()
 
-	unfulfilled := maxBroadcasts - successfulBroadcasts
+	unfulfilled := maxBroadcasts - int(successfulBroadcasts.Load())
 	if unfulfilled != 0 {
 		return fmt.Errorf("dispatch failed for %d/%d subscribers", unfulfilled, maxBroadcasts)
 	}
@@ -101,8 +119,8 @@ func (sm *subscriptionsManager) dispatchUnsafe(event bingo.GameEvent) error {
 //
 // It is safe to call this method without fully filling out an event. The
 // following fields will be backfilled with data if they are a zero value:
-// 1. Created - Backfilled with current time
-// 2. ID - Backfilled with fresh UUID
+// 1. Created - Backfilled with the manager's current time
+// 2. ID - Backfilled with a fresh ID from the manager
 //
 // All other fields are assumed to be filled out with the correct data (which
 // also means that the RecipientIDs field should only be nil if an event should
@@ -118,18 +136,25 @@ func (sm *subscriptionsManager) dispatchEvent(event bingo.GameEvent) error {
 		CreatedByID:  event.CreatedByID,
 		Phase:        event.Phase,
 		Message:      event.Message,
+		Payload:      event.Payload,
 		Type:         event.Type,
 		RecipientIDs: event.RecipientIDs,
 	}
 	if eventToDispatch.Created.IsZero() {
-		eventToDispatch.Created = time.Now()
+		eventToDispatch.Created = sm.now()
 	}
 	if eventToDispatch.ID == uuid.Nil {
-		eventToDispatch.ID = uuid.New()
+		eventToDispatch.ID = sm.newID()
 	}
 
 	sm.mtx.Lock()
 	defer sm.mtx.Unlock()
+	if sm.observer != nil {
+		sm.observer(eventToDispatch)
+	}
+	if sm.muted {
+		return nil
+	}
 	return sm.dispatchUnsafe(eventToDispatch)
 }
 
@@ -159,6 +184,8 @@ func (sm *subscriptionsManager) subscribe(phases []bingo.GamePhase, recipientIDs
 	sm.mtx.Lock()
 	defer sm.mtx.Unlock()
 
+	// Subscription IDs never leave the manager, and subscriptions can happen
+	// at any time, so they shouldn't come from newID
 	subID := uuid.New()
 	eventChan := make(chan bingo.GameEvent, 1)
 	subscribed := true
@@ -200,6 +227,13 @@ func (sm *subscriptionsManager) subscribe(phases []bingo.GamePhase, recipientIDs
 	return eventChan, safeUnsub, nil
 }
 
+// setMuted changes whether the manager sends events to its subscribers.
+func (sm *subscriptionsManager) setMuted(muted bool) {
+	sm.mtx.Lock()
+	defer sm.mtx.Unlock()
+	sm.muted = muted
+}
+
 // dispose cleans up a subscriptionsManager and renders it inert for any further
 // event dispatches or subscription attempts. Calling it more than once results
 // in a no-op.
@@ -210,15 +244,22 @@ func (sm *subscriptionsManager) dispose(systemID uuid.UUID) error {
 
 	sm.mtx.Lock()
 	defer sm.mtx.Unlock()
-	err := sm.dispatchUnsafe(bingo.GameEvent{
-		ID:           uuid.New(),
-		Type:         bingo.EventTypeUpdate,
+	terminated := bingo.GameEvent{
+		ID:           sm.newID(),
+		Type:         bingo.EventTypeGameTerminated,
 		Phase:        bingo.GamePhaseGameOver,
 		CreatedByID:  systemID,
-		Created:      time.Now(),
+		Created:      sm.now(),
 		RecipientIDs: nil,
 		Message:      "Game has been terminated",
-	})
+	}
+	if sm.observer != nil {
+		sm.observer(terminated)
+	}
+	var err error
+	if !sm.muted {
+		err = sm.dispatchUnsafe(terminated)
+	}
 
 	for _, s := range sm.subs {
 		s.unsubscribe()
@@ -240,7 +281,7 @@ func (sm *subscriptionsManager) dispose(systemID uuid.UUID) error {
 }
 
 func isEligibleForDispatch(subscription subscriptionEntry, event bingo.GameEvent) bool {
-	matchesPhaseFilters := len(subscription.recipientIDs) == 0
+	matchesPhaseFilters := len(subscription.filteredPhases) == 0
 	for _, p := range subscription.filteredPhases {
 		if p == event.Phase {
 			matchesPhaseFilters = true
@@ -251,7 +292,9 @@ func isEligibleForDispatch(subscription subscriptionEntry, event bingo.GameEvent
 		return false
 	}
 
-	matchesRecipients := len(event.RecipientIDs) == 0
+	// Subscriptions without any recipients are treated as system-level
+	// listeners, and are notified of every event
+	matchesRecipients := len(event.RecipientIDs) == 0 || len(subscription.recipientIDs) == 0
 	for _, id := range event.RecipientIDs {
 		if slices.Contains(subscription.recipientIDs, id) {
 			matchesRecipients = true
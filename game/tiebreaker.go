@@ -0,0 +1,325 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// tiebreaker keeps track of a tiebreaker between players who called bingo in
+// the same round. A game can only have one tiebreaker at a time, and it only
+// lasts for the duration of the tiebreaker phase.
+type tiebreaker struct {
+	rule bingo.TiebreakerRule
+	// playerIDs contains every player still taking part in the tiebreaker,
+	// in the order that they called bingo
+	playerIDs []uuid.UUID
+	// draw is kept separate from the round's ball registry, so that the
+	// round's draw can still be revealed as-is once the round is over. It is
+	// nil for rules that don't draw any balls.
+	draw *ballRegistry
+	// highBalls contains the ball drawn for each player so far, for
+	// high-ball tiebreakers
+	highBalls []bingo.TiebreakerDraw
+}
+
+// newTiebreaker creates a tiebreaker between the given players. Rules that
+// draw balls get a freshly-shuffled draw from source.
+func newTiebreaker(rule bingo.TiebreakerRule, playerIDs []uuid.UUID, source RNG, maxBall int) *tiebreaker {
+	tb := &tiebreaker{
+		rule:      rule,
+		playerIDs: slices.Clone(playerIDs),
+		draw:      nil,
+		highBalls: nil,
+	}
+	if rule != bingo.TiebreakerRuleSplitPrize {
+		tb.draw = newBallRegistry(source, maxBall)
+	}
+	return tb
+}
+
+// canDaub indicates whether a player is allowed to change their daubs while
+// the tiebreaker is going on. Only players racing in a next line tiebreaker
+// have any reason to.
+func (tb *tiebreaker) canDaub(playerID uuid.UUID) bool {
+	return tb != nil && tb.rule == bingo.TiebreakerRuleNextLine && slices.Contains(tb.playerIDs, playerID)
+}
+
+// nextHighBallPlayer returns the next player who needs a ball drawn for them
+// in a high-ball tiebreaker, or false if every player already has one.
+func (tb *tiebreaker) nextHighBallPlayer() (uuid.UUID, bool) {
+	for _, id := range tb.playerIDs {
+		drawn := slices.ContainsFunc(tb.highBalls, func(d bingo.TiebreakerDraw) bool {
+			return d.PlayerID == id
+		})
+		if !drawn {
+			return id, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// decidedWinners returns the winners of the tiebreaker, or false if the
+// tiebreaker still needs to be played out. A tiebreaker with no players
+// left is decided with no winners.
+func (tb *tiebreaker) decidedWinners() ([]uuid.UUID, bool) {
+	if len(tb.playerIDs) <= 1 {
+		return slices.Clone(tb.playerIDs), true
+	}
+
+	switch tb.rule {
+	case bingo.TiebreakerRuleSplitPrize:
+		return slices.Clone(tb.playerIDs), true
+	case bingo.TiebreakerRuleHighBall:
+		if _, ok := tb.nextHighBallPlayer(); ok {
+			return nil, false
+		}
+		// Every ball in a draw is unique, so there can't be a tie
+		var best bingo.TiebreakerDraw
+		for _, d := range tb.highBalls {
+			if slices.Contains(tb.playerIDs, d.PlayerID) && d.Ball > best.Ball {
+				best = d
+			}
+		}
+		return []uuid.UUID{best.PlayerID}, true
+	default:
+		// Next line tiebreakers are only decided by a player calling bingo
+		return nil, false
+	}
+}
+
+// removePlayer takes a player out of the tiebreaker. Removing a player who
+// isn't part of the tiebreaker results in a no-op.
+func (tb *tiebreaker) removePlayer(playerID uuid.UUID) {
+	tb.playerIDs = slices.DeleteFunc(tb.playerIDs, func(id uuid.UUID) bool {
+		return id == playerID
+	})
+	tb.highBalls = slices.DeleteFunc(tb.highBalls, func(d bingo.TiebreakerDraw) bool {
+		return d.PlayerID == playerID
+	})
+}
+
+// status produces the public information for the tiebreaker.
+func (tb *tiebreaker) status() *bingo.TiebreakerStatus {
+	status := &bingo.TiebreakerStatus{
+		Rule:       tb.rule,
+		PlayerIDs:  slices.Clone(tb.playerIDs),
+		Called:     []bingo.Ball{},
+		HighBalls:  append([]bingo.TiebreakerDraw{}, tb.highBalls...),
+		Commitment: "",
+	}
+	if tb.draw != nil {
+		status.Called = append(status.Called, tb.draw.getCalledBalls()...)
+		status.Commitment, _ = tb.draw.commitment()
+	}
+	return status
+}
+
+// export copies the tiebreaker (including its draw) into a serializable
+// form.
+func (tb *tiebreaker) export() *TiebreakerState {
+	state := &TiebreakerState{
+		Rule:      tb.rule,
+		PlayerIDs: slices.Clone(tb.playerIDs),
+		HighBalls: append([]bingo.TiebreakerDraw{}, tb.highBalls...),
+		HasDraw:   tb.draw != nil,
+	}
+	if tb.draw != nil {
+		var draw State
+		tb.draw.exportDraw(&draw)
+		state.CalledBalls = draw.CalledBalls
+		state.UncalledBalls = draw.UncalledBalls
+		state.ManualCalls = draw.ManualCalls
+		state.DrawSeed = draw.DrawSeed
+		state.DrawOrder = draw.DrawOrder
+		state.DrawScripted = draw.DrawScripted
+	}
+	return state
+}
+
+// restoreTiebreaker rebuilds a tiebreaker from a saved state, without
+// shuffling a new draw.
+func restoreTiebreaker(state TiebreakerState, source RNG, maxBall int) *tiebreaker {
+	tb := &tiebreaker{
+		rule:      state.Rule,
+		playerIDs: slices.Clone(state.PlayerIDs),
+		draw:      nil,
+		highBalls: slices.Clone(state.HighBalls),
+	}
+	if state.HasDraw {
+		tb.draw = &ballRegistry{source: source, maxBall: maxBall, mtx: &sync.Mutex{}}
+		tb.draw.restore(State{
+			CalledBalls:   state.CalledBalls,
+			UncalledBalls: state.UncalledBalls,
+			ManualCalls:   state.ManualCalls,
+			DrawSeed:      state.DrawSeed,
+			DrawOrder:     state.DrawOrder,
+			DrawScripted:  state.DrawScripted,
+		})
+	}
+	return tb
+}
+
+// drawTiebreakerBall draws the next ball from the tiebreaker's draw. This
+// method is NOT thread-safe.
+func (g *Game) drawTiebreakerBall(commanderID uuid.UUID) error {
+	if g.tiebreaker.draw == nil {
+		return fmt.Errorf("%s tiebreakers don't use any balls", g.tiebreaker.rule)
+	}
+	if _, ok := g.tiebreaker.nextHighBallPlayer(); !ok && g.tiebreaker.rule == bingo.TiebreakerRuleHighBall {
+		return errors.New("every player in the tiebreaker already has a ball")
+	}
+
+	ball, err := g.tiebreaker.draw.nextAutomaticCall()
+	if err != nil {
+		return err
+	}
+	return g.applyTiebreakerBall(ball, commanderID)
+}
+
+// syncTiebreakerBall records a ball for the tiebreaker that was drawn from a
+// physical ball machine. This method is NOT thread-safe.
+func (g *Game) syncTiebreakerBall(ball bingo.Ball, commanderID uuid.UUID) error {
+	if g.tiebreaker.draw == nil {
+		return fmt.Errorf("%s tiebreakers don't use any balls", g.tiebreaker.rule)
+	}
+	if _, ok := g.tiebreaker.nextHighBallPlayer(); !ok && g.tiebreaker.rule == bingo.TiebreakerRuleHighBall {
+		return errors.New("every player in the tiebreaker already has a ball")
+	}
+
+	if err := g.tiebreaker.draw.syncManualCall(ball); err != nil {
+		return err
+	}
+	return g.applyTiebreakerBall(ball, commanderID)
+}
+
+// applyTiebreakerBall notifies every subscriber about a ball that was just
+// called for the tiebreaker, and settles the tiebreaker if the ball decided
+// it. This method is NOT thread-safe.
+func (g *Game) applyTiebreakerBall(ball bingo.Ball, commanderID uuid.UUID) error {
+	payload := bingo.GameEventPayloadTiebreakerBallCalled{
+		Ball:        ball,
+		CalledCount: len(g.tiebreaker.draw.getCalledBalls()),
+		PlayerID:    nil,
+	}
+	message := fmt.Sprintf("tiebreaker ball: %d", ball)
+	if g.tiebreaker.rule == bingo.TiebreakerRuleHighBall {
+		playerID, _ := g.tiebreaker.nextHighBallPlayer()
+		g.tiebreaker.highBalls = append(g.tiebreaker.highBalls, bingo.TiebreakerDraw{
+			PlayerID: playerID,
+			Ball:     ball,
+		})
+		payload.PlayerID = &playerID
+		if entry := g.findPlayerEntry(playerID); entry != nil {
+			message = fmt.Sprintf("tiebreaker ball for player %q: %d", entry.player.Name, ball)
+		}
+	}
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        bingo.GamePhaseTiebreaker,
+		Type:         bingo.EventTypeTiebreakerBallCalled,
+		CreatedByID:  commanderID,
+		Message:      message,
+		Payload:      eventPayload(payload),
+		RecipientIDs: nil,
+	})
+	return g.settleTiebreaker(commanderID)
+}
+
+// processTiebreakerClaim handles a bingo call made during a next line
+// tiebreaker. Claims are checked against the tiebreaker's draw, and the first
+// valid claim wins the round right away. This method is NOT thread-safe.
+func (g *Game) processTiebreakerClaim(entry *playerEntry) error {
+	if !slices.Contains(g.tiebreaker.playerIDs, entry.player.ID) {
+		return fmt.Errorf("player %q is not part of the tiebreaker", entry.player.Name)
+	}
+	if g.tiebreaker.rule != bingo.TiebreakerRuleNextLine {
+		return fmt.Errorf("bingo can't be called during %s tiebreakers", g.tiebreaker.rule)
+	}
+
+	validation := validateClaim(entry.player, g.tiebreaker.draw.getCalledBalls(), g.winPattern)
+	if !validation.valid() {
+		err := fmt.Errorf("tiebreaker bingo call from player %q is not valid: %s", entry.player.Name, validation.summary())
+		g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+			Phase:       bingo.GamePhaseTiebreaker,
+			Type:        bingo.EventTypeError,
+			CreatedByID: entry.player.ID,
+			Message:     err.Error(),
+			Payload: eventPayload(bingo.GameEventPayloadError{
+				CommandType: bingo.GameCommandPlayerCallBingo,
+				Reason:      err.Error(),
+			}),
+			RecipientIDs: []uuid.UUID{entry.player.ID},
+		})
+		return err
+	}
+
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        bingo.GamePhaseTiebreaker,
+		Type:         bingo.EventTypeBingoValidated,
+		CreatedByID:  entry.player.ID,
+		Message:      fmt.Sprintf("tiebreaker bingo call from player %q: %s", entry.player.Name, validation.summary()),
+		Payload:      eventPayload(bingo.GameEventPayloadBingoValidated{ClaimValidation: validation.export()}),
+		RecipientIDs: g.hostRecipients(bingo.HostPermissionJudgeBingo),
+	})
+	return g.resolveTiebreaker(entry.player.ID, []uuid.UUID{entry.player.ID})
+}
+
+// removeFromTiebreaker takes a player who can no longer play out of the
+// current tiebreaker (if there is one), and settles the tiebreaker if that
+// decided it. This method is NOT thread-safe.
+func (g *Game) removeFromTiebreaker(playerID uuid.UUID, commanderID uuid.UUID) error {
+	if g.tiebreaker == nil || !slices.Contains(g.tiebreaker.playerIDs, playerID) {
+		return nil
+	}
+	g.tiebreaker.removePlayer(playerID)
+	return g.settleTiebreaker(commanderID)
+}
+
+// settleTiebreaker ends the round if the current tiebreaker has been decided.
+// This method is NOT thread-safe.
+func (g *Game) settleTiebreaker(commanderID uuid.UUID) error {
+	winnerIDs, decided := g.tiebreaker.decidedWinners()
+	if !decided {
+		return nil
+	}
+	return g.resolveTiebreaker(commanderID, winnerIDs)
+}
+
+// resolveTiebreaker announces the winners of the current tiebreaker, and then
+// ends the round with them as the winners. This method is NOT thread-safe.
+func (g *Game) resolveTiebreaker(commanderID uuid.UUID, winnerIDs []uuid.UUID) error {
+	tb := g.tiebreaker
+	g.tiebreaker = nil
+
+	var winners []*bingo.Player
+	for _, id := range winnerIDs {
+		if entry := g.findPlayerEntry(id); entry != nil {
+			winners = append(winners, entry.player)
+		}
+	}
+	payload := bingo.GameEventPayloadTiebreakerResolved{
+		Rule:      tb.rule,
+		WinnerIDs: append([]uuid.UUID{}, winnerIDs...),
+		Draw:      nil,
+	}
+	if tb.draw != nil {
+		revealed := tb.draw.reveal(g.currentRound, g.variant.ID)
+		payload.Draw = &revealed
+	}
+	g.phaseSubscriptions.dispatchEvent(bingo.GameEvent{
+		Phase:        bingo.GamePhaseTiebreaker,
+		Type:         bingo.EventTypeTiebreakerResolved,
+		CreatedByID:  commanderID,
+		Message:      fmt.Sprintf("%s tiebreaker decided %d winner(s)", tb.rule, len(winners)),
+		Payload:      eventPayload(payload),
+		RecipientIDs: nil,
+	})
+
+	g.winningPlayers = append(g.winningPlayers, winners...)
+	return g.endRound(commanderID, winners)
+}
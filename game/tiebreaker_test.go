@@ -0,0 +1,281 @@
+package game
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+)
+
+// startTestTie starts a game with the given players, calls the first ball,
+// and has every player call bingo. Only the calls from the first accepted
+// players get accepted by the host, and the rest are left waiting.
+func startTestTie(t *testing.T, g *Game, names []string, accepted int) []uuid.UUID {
+	t.Helper()
+
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+	var playerIDs []uuid.UUID
+	for _, name := range names {
+		id, _ := joinTestPlayer(t, g, name, 1)
+		playerIDs = append(playerIDs, id)
+	}
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	for i, id := range playerIDs {
+		mustIssueTestCommand(t, g, id, bingo.GameCommandPlayerCallBingo, nil)
+		if i < accepted {
+			mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostAcknowledgeBingoCall, bingo.GameCommandPayloadHostAcknowledgeBingoCall{
+				PlayerID: id,
+				Accepted: true,
+			})
+		}
+	}
+	return playerIDs
+}
+
+// startTestTiebreaker has the host start a tiebreaker with the given rule.
+func startTestTiebreaker(t *testing.T, g *Game, rule bingo.TiebreakerRule) error {
+	t.Helper()
+
+	return issueTestCommand(t, g, testHostID, bingo.GameCommandHostStartTiebreakerRound, bingo.GameCommandPayloadHostStartTiebreakerRound{
+		Rule: rule,
+	})
+}
+
+// findTiebreakerResult returns the payload of the event that resolved the
+// tiebreaker, or nil if the tiebreaker was never resolved.
+func findTiebreakerResult(t *testing.T, events []bingo.GameEvent) *bingo.GameEventPayloadTiebreakerResolved {
+	t.Helper()
+
+	for _, event := range events {
+		if event.Type != bingo.EventTypeTiebreakerResolved {
+			continue
+		}
+		var payload bingo.GameEventPayloadTiebreakerResolved
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			t.Fatalf("unable to parse tiebreaker result: %v", err)
+		}
+		return &payload
+	}
+	return nil
+}
+
+// finishTestTiebreaker checks that the tiebreaker ended the round, and then
+// ends the game so that every collected event can be returned.
+func finishTestTiebreaker(t *testing.T, g *Game, collect func() []bingo.GameEvent) *bingo.GameEventPayloadTiebreakerResolved {
+	t.Helper()
+
+	snapshot := g.Snapshot()
+	if snapshot.Phase != bingo.GamePhaseRoundStart || snapshot.Tiebreaker != nil {
+		t.Fatalf("expected the tiebreaker to end the round, got phase %q", snapshot.Phase)
+	}
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostTerminateGame, nil)
+
+	result := findTiebreakerResult(t, collect())
+	if result == nil {
+		t.Fatal("expected the tiebreaker to be resolved")
+	}
+	return result
+}
+
+func TestSplitPrizeTiebreaker(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		names    []string
+		accepted int
+		// winners is nil if the tiebreaker shouldn't be able to start
+		winners []string
+	}{
+		{
+			name:     "Every caller accepted",
+			names:    []string{"alice", "bob"},
+			accepted: 2,
+			winners:  []string{"alice", "bob"},
+		},
+		{
+			name:     "Caller who hasn't been accepted is left out",
+			names:    []string{"alice", "bob", "carol"},
+			accepted: 2,
+			winners:  []string{"alice", "bob"},
+		},
+		{
+			name:     "Only one caller accepted",
+			names:    []string{"alice", "bob"},
+			accepted: 1,
+			winners:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := newTestGame(t, Init{})
+			collect := subscribeTestEvents(t, g)
+			startTestTie(t, g, tc.names, tc.accepted)
+
+			err := startTestTiebreaker(t, g, bingo.TiebreakerRuleSplitPrize)
+			if tc.winners == nil {
+				if err == nil {
+					t.Fatal("tiebreakers should need at least two accepted callers")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to start tiebreaker: %v", err)
+			}
+
+			var expected []uuid.UUID
+			for _, name := range tc.winners {
+				expected = append(expected, testPlayerID(name))
+			}
+			result := finishTestTiebreaker(t, g, collect)
+			if !slices.Equal(result.WinnerIDs, expected) {
+				t.Fatalf("expected winners %v, got %v", expected, result.WinnerIDs)
+			}
+		})
+	}
+}
+
+func TestHighBallTiebreaker(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	collect := subscribeTestEvents(t, g)
+	playerIDs := startTestTie(t, g, []string{"alice", "bob"}, 2)
+	if err := startTestTiebreaker(t, g, bingo.TiebreakerRuleHighBall); err != nil {
+		t.Fatalf("unable to start tiebreaker: %v", err)
+	}
+
+	for range playerIDs {
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	}
+	result := finishTestTiebreaker(t, g, collect)
+
+	// Every player gets one ball, in the order that they called bingo
+	var draws []bingo.TiebreakerDraw
+	for _, event := range collect() {
+		if event.Type != bingo.EventTypeTiebreakerBallCalled {
+			continue
+		}
+		var payload bingo.GameEventPayloadTiebreakerBallCalled
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			t.Fatalf("unable to parse tiebreaker ball: %v", err)
+		}
+		if payload.PlayerID == nil {
+			t.Fatal("high-ball tiebreaker balls should say who they were drawn for")
+		}
+		draws = append(draws, bingo.TiebreakerDraw{PlayerID: *payload.PlayerID, Ball: payload.Ball})
+	}
+	if len(draws) != 2 || draws[0].PlayerID != playerIDs[0] || draws[1].PlayerID != playerIDs[1] {
+		t.Fatalf("expected one ball for each player in calling order, got %+v", draws)
+	}
+
+	expected := draws[0].PlayerID
+	if draws[1].Ball > draws[0].Ball {
+		expected = draws[1].PlayerID
+	}
+	if !slices.Equal(result.WinnerIDs, []uuid.UUID{expected}) {
+		t.Fatalf("expected the player with the highest ball to win, got %v from %+v", result.WinnerIDs, draws)
+	}
+	if result.Draw == nil || len(result.Draw.Called) != 2 {
+		t.Fatal("expected the tiebreaker's draw to be revealed")
+	}
+}
+
+func TestPlayerLeavesTiebreaker(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	collect := subscribeTestEvents(t, g)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostStartGame, nil)
+	aliceID, _ := joinTestPlayer(t, g, "alice", 1)
+	bobID, leaveBob := joinTestPlayer(t, g, "bob", 1)
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	for _, id := range []uuid.UUID{aliceID, bobID} {
+		mustIssueTestCommand(t, g, id, bingo.GameCommandPlayerCallBingo, nil)
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostAcknowledgeBingoCall, bingo.GameCommandPayloadHostAcknowledgeBingoCall{
+			PlayerID: id,
+			Accepted: true,
+		})
+	}
+	if err := startTestTiebreaker(t, g, bingo.TiebreakerRuleHighBall); err != nil {
+		t.Fatalf("unable to start tiebreaker: %v", err)
+	}
+
+	// Bob leaves after alice's ball is drawn, but before getting a ball
+	mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+	if err := leaveBob(); err != nil {
+		t.Fatalf("bob was unable to leave: %v", err)
+	}
+
+	result := finishTestTiebreaker(t, g, collect)
+	if !slices.Equal(result.WinnerIDs, []uuid.UUID{aliceID}) {
+		t.Fatalf("expected the last player in the tiebreaker to win, got %v", result.WinnerIDs)
+	}
+}
+
+func TestNextLineTiebreaker(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGame(t, Init{})
+	collect := subscribeTestEvents(t, g)
+	playerIDs := startTestTie(t, g, []string{"alice", "bob"}, 2)
+	aliceID := playerIDs[0]
+
+	// Daubs from the round shouldn't carry over into the tiebreaker
+	card := g.SnapshotFor(aliceID).Player.Cards[0]
+	mustIssueTestCommand(t, g, aliceID, bingo.GameCommandPlayerDaub, bingo.GameCommandPayloadPlayerDaub{
+		CardID: card.ID,
+		Cell:   int(bingo.FreeSpace),
+	})
+	if err := startTestTiebreaker(t, g, bingo.TiebreakerRuleNextLine); err != nil {
+		t.Fatalf("unable to start tiebreaker: %v", err)
+	}
+	for _, row := range g.SnapshotFor(aliceID).Player.Cards[0].Cells {
+		for _, cell := range row {
+			if cell.Daubed {
+				t.Fatal("expected every daub to be cleared when the tiebreaker starts")
+			}
+		}
+	}
+
+	// Claims are only checked against the tiebreaker's draw, so the balls
+	// from the round can't count towards a line
+	if err := issueTestCommand(t, g, aliceID, bingo.GameCommandPlayerCallBingo, nil); err == nil {
+		t.Fatal("bingo calls should fail before the tiebreaker draw completes a line")
+	}
+
+	pattern := g.Snapshot().WinPattern
+	for {
+		mustIssueTestCommand(t, g, testHostID, bingo.GameCommandHostRequestBall, nil)
+		status := g.Snapshot().Tiebreaker
+		ball := status.Called[len(status.Called)-1]
+		card := g.SnapshotFor(aliceID).Player.Cards[0]
+		onCard := slices.ContainsFunc(card.Cells, func(row []*bingo.Cell) bool {
+			return slices.ContainsFunc(row, func(c *bingo.Cell) bool {
+				return c.Number == ball
+			})
+		})
+		if !onCard {
+			continue
+		}
+		mustIssueTestCommand(t, g, aliceID, bingo.GameCommandPlayerDaub, bingo.GameCommandPayloadPlayerDaub{
+			CardID: card.ID,
+			Cell:   int(ball),
+		})
+		card = g.SnapshotFor(aliceID).Player.Cards[0]
+		if validateCard(&card, status.Called, pattern).valid() {
+			break
+		}
+	}
+	mustIssueTestCommand(t, g, aliceID, bingo.GameCommandPlayerCallBingo, nil)
+
+	result := finishTestTiebreaker(t, g, collect)
+	if !slices.Equal(result.WinnerIDs, []uuid.UUID{aliceID}) {
+		t.Fatalf("expected the first player to complete a line to win, got %v", result.WinnerIDs)
+	}
+}
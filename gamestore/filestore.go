@@ -0,0 +1,320 @@
+package gamestore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Parkreiner/bingo/game"
+	"github.com/google/uuid"
+)
+
+const (
+	checkpointFileName = "checkpoint.json"
+	walFileName        = "wal.jsonl"
+	metadataFileName   = "metadata.json"
+	// banDirName is the directory that holds every host's ban list. It can't
+	// be mistaken for a game, because it isn't named after a UUID.
+	banDirName = "bans"
+	// maxEntryBytes is the largest journal entry that can be read back from
+	// the write-ahead log
+	maxEntryBytes = 16 << 20
+)
+
+// FileStore is a GameStore that keeps each game in its own directory. Every
+// game has a checkpoint file with its latest full state, and a write-ahead log
+// with every entry recorded since that checkpoint (one JSON object per line).
+// All writes are synced to disk before returning, and checkpoints are
+// replaced atomically, so a crash can lose at most the entry being written.
+//
+// FileStore is also a BanStore, and keeps each host's ban list in a separate
+// file.
+type FileStore struct {
+	dir      string
+	journals map[uuid.UUID]*fileJournal
+	mtx      sync.Mutex
+}
+
+var (
+	_ GameStore = &FileStore{}
+	_ BanStore  = &FileStore{}
+)
+
+// NewFileStore creates a FileStore rooted at the given directory, creating the
+// directory if it doesn't exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create store directory %q: %v", dir, err)
+	}
+	return &FileStore{
+		dir:      dir,
+		journals: map[uuid.UUID]*fileJournal{},
+	}, nil
+}
+
+func (fs *FileStore) gameDir(gameID uuid.UUID) string {
+	return filepath.Join(fs.dir, gameID.String())
+}
+
+// Open returns the journal for a game, creating its directory if needed.
+func (fs *FileStore) Open(gameID uuid.UUID) (Journal, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if j, ok := fs.journals[gameID]; ok && !j.isClosed() {
+		return nil, fmt.Errorf("game %s already has an open journal", gameID)
+	}
+
+	dir := fs.gameDir(gameID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create directory for game %s: %v", gameID, err)
+	}
+	wal, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open write-ahead log for game %s: %v", gameID, err)
+	}
+
+	journal := &fileJournal{
+		dir:    dir,
+		wal:    wal,
+		closed: false,
+	}
+	fs.journals[gameID] = journal
+	return journal, nil
+}
+
+// SaveMetadata atomically replaces the metadata for a game.
+func (fs *FileStore) SaveMetadata(gameID uuid.UUID, metadata []byte) error {
+	dir := fs.gameDir(gameID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create directory for game %s: %v", gameID, err)
+	}
+	return writeFileAtomic(filepath.Join(dir, metadataFileName), metadata)
+}
+
+// List returns the ID of every game that has at least one checkpoint.
+func (fs *FileStore) List() ([]uuid.UUID, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read store directory: %v", err)
+	}
+
+	var ids []uuid.UUID
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		id, err := uuid.Parse(e.Name())
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(fs.dir, e.Name(), checkpointFileName)); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Load reads the checkpoint, metadata, and write-ahead log for a game. If the
+// process crashed in the middle of writing an entry, that entry (and anything
+// after it) is ignored.
+func (fs *FileStore) Load(gameID uuid.UUID) (Record, error) {
+	dir := fs.gameDir(gameID)
+	record := Record{
+		GameID:     gameID,
+		Metadata:   nil,
+		Checkpoint: game.State{},
+		Entries:    nil,
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, checkpointFileName))
+	if err != nil {
+		return record, fmt.Errorf("unable to read checkpoint: %v", err)
+	}
+	if err := json.Unmarshal(b, &record.Checkpoint); err != nil {
+		return record, fmt.Errorf("unable to parse checkpoint: %v", err)
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(dir, metadataFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return record, fmt.Errorf("unable to read metadata: %v", err)
+	}
+	record.Metadata = metadata
+
+	wal, err := os.Open(filepath.Join(dir, walFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return record, nil
+	}
+	if err != nil {
+		return record, fmt.Errorf("unable to open write-ahead log: %v", err)
+	}
+	defer wal.Close()
+
+	scanner := bufio.NewScanner(wal)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntryBytes)
+	for scanner.Scan() {
+		var entry game.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			break
+		}
+		if entry.Version > record.Checkpoint.Version {
+			record.Entries = append(record.Entries, entry)
+		}
+	}
+	return record, nil
+}
+
+// Delete closes the game's journal (if it's open), and removes every file for
+// the game.
+func (fs *FileStore) Delete(gameID uuid.UUID) error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if j, ok := fs.journals[gameID]; ok {
+		_ = j.Close()
+		delete(fs.journals, gameID)
+	}
+	if err := os.RemoveAll(fs.gameDir(gameID)); err != nil {
+		return fmt.Errorf("unable to delete game %s: %v", gameID, err)
+	}
+	return nil
+}
+
+func (fs *FileStore) banFile(hostID uuid.UUID) string {
+	return filepath.Join(fs.dir, banDirName, hostID.String()+".json")
+}
+
+// LoadBans reads the host's ban list.
+func (fs *FileStore) LoadBans(hostID uuid.UUID) ([]uuid.UUID, error) {
+	b, err := os.ReadFile(fs.banFile(hostID))
+	if errors.Is(err, os.ErrNotExist) {
+		return []uuid.UUID{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ban list for host %s: %v", hostID, err)
+	}
+
+	var playerIDs []uuid.UUID
+	if err := json.Unmarshal(b, &playerIDs); err != nil {
+		return nil, fmt.Errorf("unable to parse ban list for host %s: %v", hostID, err)
+	}
+	return playerIDs, nil
+}
+
+// SaveBans atomically replaces the host's ban list.
+func (fs *FileStore) SaveBans(hostID uuid.UUID, playerIDs []uuid.UUID) error {
+	if err := os.MkdirAll(filepath.Join(fs.dir, banDirName), 0o755); err != nil {
+		return fmt.Errorf("unable to create ban list directory: %v", err)
+	}
+	b, err := json.Marshal(playerIDs)
+	if err != nil {
+		return fmt.Errorf("unable to serialize ban list for host %s: %v", hostID, err)
+	}
+	return writeFileAtomic(fs.banFile(hostID), b)
+}
+
+// fileJournal is the journal for a single game in a FileStore.
+type fileJournal struct {
+	dir    string
+	wal    *os.File
+	closed bool
+	mtx    sync.Mutex
+}
+
+var _ Journal = &fileJournal{}
+
+func (fj *fileJournal) isClosed() bool {
+	fj.mtx.Lock()
+	defer fj.mtx.Unlock()
+	return fj.closed
+}
+
+// Append writes an entry to the end of the write-ahead log, and waits for it
+// to be synced to disk.
+func (fj *fileJournal) Append(entry game.JournalEntry) error {
+	fj.mtx.Lock()
+	defer fj.mtx.Unlock()
+	if fj.closed {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("unable to serialize journal entry: %v", err)
+	}
+	if _, err := fj.wal.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("unable to write journal entry: %v", err)
+	}
+	return fj.wal.Sync()
+}
+
+// Checkpoint atomically replaces the game's checkpoint, and then clears out
+// the write-ahead log. If the process crashes before the log is cleared, the
+// stale entries are skipped on load because their versions are too old.
+func (fj *fileJournal) Checkpoint(state game.State) error {
+	fj.mtx.Lock()
+	defer fj.mtx.Unlock()
+	if fj.closed {
+		return nil
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("unable to serialize checkpoint: %v", err)
+	}
+	if err := writeFileAtomic(filepath.Join(fj.dir, checkpointFileName), b); err != nil {
+		return err
+	}
+	if err := fj.wal.Truncate(0); err != nil {
+		return fmt.Errorf("unable to clear write-ahead log: %v", err)
+	}
+	return fj.wal.Sync()
+}
+
+// Close stops the journal from recording anything else. Calling it more than
+// once results in a no-op.
+func (fj *fileJournal) Close() error {
+	fj.mtx.Lock()
+	defer fj.mtx.Unlock()
+	if fj.closed {
+		return nil
+	}
+	fj.closed = true
+	return fj.wal.Close()
+}
+
+// writeFileAtomic replaces a file by writing to a temporary file first and
+// renaming it, so that readers never see a partially-written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %v", err)
+	}
+	tmpPath := tmp.Name()
+
+	_, writeErr := tmp.Write(data)
+	syncErr := tmp.Sync()
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, syncErr, closeErr); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("unable to write %q: %v", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("unable to replace %q: %v", path, err)
+	}
+
+	// Syncing the directory makes the rename itself durable
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	defer dir.Close()
+	_ = dir.Sync()
+	return nil
+}
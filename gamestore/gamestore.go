@@ -0,0 +1,91 @@
+// Package gamestore persists games to durable storage, so that they can
+// survive the process restarting.
+package gamestore
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Parkreiner/bingo/game"
+	"github.com/google/uuid"
+)
+
+// GameStore persists games (along with any extra metadata needed to bring
+// them back) to durable storage.
+type GameStore interface {
+	// Open returns the journal for a game, creating storage for the game if it
+	// doesn't exist yet. A game can only have one open journal at a time.
+	Open(gameID uuid.UUID) (Journal, error)
+	// SaveMetadata replaces the metadata stored alongside a game. The store
+	// treats metadata as an opaque blob.
+	SaveMetadata(gameID uuid.UUID, metadata []byte) error
+	// List returns the IDs of every game that can be loaded.
+	List() ([]uuid.UUID, error)
+	// Load reads everything needed to recover a game.
+	Load(gameID uuid.UUID) (Record, error)
+	// Delete permanently removes a game from the store. If the game has an
+	// open journal, it is closed first.
+	Delete(gameID uuid.UUID) error
+}
+
+// BanStore persists the list of players that each host has banned, so that
+// the bans can carry over to every game the host runs in the future.
+type BanStore interface {
+	// LoadBans returns every player the host has banned. A host who has never
+	// banned anyone has an empty list.
+	LoadBans(hostID uuid.UUID) ([]uuid.UUID, error)
+	// SaveBans replaces the host's entire ban list.
+	SaveBans(hostID uuid.UUID, playerIDs []uuid.UUID) error
+}
+
+// Journal is a game.Journal that is backed by a GameStore. Once a journal has
+// been closed, it silently discards everything given to it, which makes it
+// possible to shut down a game without recording the shutdown.
+type Journal interface {
+	game.Journal
+	io.Closer
+}
+
+// Record contains everything persisted for a single game.
+type Record struct {
+	GameID     uuid.UUID
+	Metadata   []byte
+	Checkpoint game.State
+	// Entries contains every journal entry recorded after the checkpoint, in
+	// the order they were recorded
+	Entries []game.JournalEntry
+}
+
+// Recovered is a game that has been rebuilt from a GameStore.
+type Recovered struct {
+	Game     *game.Game
+	Journal  Journal
+	Metadata []byte
+}
+
+// Recover rebuilds a game from its latest checkpoint and all the journal
+// entries recorded after it. The recovered game keeps recording to the same
+// store. See game.Restore for which fields from init are used.
+func Recover(store GameStore, gameID uuid.UUID, init game.Init) (Recovered, error) {
+	record, err := store.Load(gameID)
+	if err != nil {
+		return Recovered{}, fmt.Errorf("unable to load game %s: %v", gameID, err)
+	}
+	journal, err := store.Open(gameID)
+	if err != nil {
+		return Recovered{}, fmt.Errorf("unable to open journal for game %s: %v", gameID, err)
+	}
+
+	init.Journal = journal
+	g, err := game.Restore(init, record.Checkpoint, record.Entries)
+	if err != nil {
+		_ = journal.Close()
+		// Wrapped so that callers can tell ended games apart from broken ones
+		return Recovered{}, fmt.Errorf("unable to restore game %s: %w", gameID, err)
+	}
+	return Recovered{
+		Game:     g,
+		Journal:  journal,
+		Metadata: record.Metadata,
+	}, nil
+}
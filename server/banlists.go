@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Parkreiner/bingo/gamestore"
+	"github.com/google/uuid"
+)
+
+const (
+	// minIdentityKeyLength and maxIdentityKeyLength bound the identity keys
+	// that clients can send back. Generated keys are always 64 characters.
+	minIdentityKeyLength = 32
+	maxIdentityKeyLength = 128
+)
+
+// identityNamespace is used to turn identity keys into IDs. It was generated
+// once, and must never change, or else every existing ban list would stop
+// matching anyone.
+var identityNamespace = uuid.MustParse("5b0f3e4e-2a53-4c1d-9a43-6d3b51f0c8e2")
+
+// resolveIdentity turns an identity key from a client into a stable ID. The
+// same key always produces the same ID, which lets a host keep their ban list
+// between games, and lets those bans follow players into the host's future
+// games. Since IDs can't be turned back into keys, knowing someone's public
+// ID isn't enough to take on their identity. If the key is empty, a brand new
+// key is generated.
+//
+// Keys are entirely up to the client, and the server has no way of tying them
+// to a real person. Anyone who drops their key (or makes up a new one) gets a
+// brand new ID, so bans only keep out players who keep using the same key.
+func resolveIdentity(key string) (uuid.UUID, string, error) {
+	if key == "" {
+		newKey, err := newSessionToken()
+		if err != nil {
+			return uuid.Nil, "", fmt.Errorf("unable to generate identity key: %v", err)
+		}
+		key = newKey
+	}
+	if len(key) < minIdentityKeyLength || len(key) > maxIdentityKeyLength {
+		return uuid.Nil, "", fmt.Errorf("identity key must be between %d and %d characters", minIdentityKeyLength, maxIdentityKeyLength)
+	}
+	return uuid.NewSHA1(identityNamespace, []byte(key)), key, nil
+}
+
+// banLists keeps track of every player that each host has banned, across all
+// of the host's games. Lists are loaded from the store the first time they
+// are needed, and every new ban is saved right away. Without a store, bans
+// only last until the server restarts.
+//
+// Both hosts and players are identified by IDs from resolveIdentity, so a ban
+// list can be dodged by joining with a different identity key. Bans are a way
+// to stop a disruptive player from coming straight back, not a guarantee that
+// they never will.
+type banLists struct {
+	// store will be nil if the server's store can't persist bans
+	store gamestore.BanStore
+	lists map[uuid.UUID][]uuid.UUID
+	mtx   sync.Mutex
+}
+
+func newBanLists(store gamestore.GameStore) *banLists {
+	banStore, _ := store.(gamestore.BanStore)
+	return &banLists{
+		store: banStore,
+		lists: map[uuid.UUID][]uuid.UUID{},
+	}
+}
+
+// get returns a copy of every player the host has banned.
+func (bl *banLists) get(hostID uuid.UUID) ([]uuid.UUID, error) {
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+	list, err := bl.load(hostID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(list), nil
+}
+
+// add puts a player on the host's ban list, and persists the updated list.
+// Adding a player who is already on the list results in a no-op.
+func (bl *banLists) add(hostID uuid.UUID, playerID uuid.UUID) error {
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+	list, err := bl.load(hostID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(list, playerID) {
+		return nil
+	}
+
+	updated := append(slices.Clone(list), playerID)
+	if bl.store != nil {
+		if err := bl.store.SaveBans(hostID, updated); err != nil {
+			return fmt.Errorf("unable to save ban list for host %s: %v", hostID, err)
+		}
+	}
+	bl.lists[hostID] = updated
+	return nil
+}
+
+// load returns the host's ban list, reading it from the store if it hasn't
+// been loaded yet. This method is NOT thread-safe.
+func (bl *banLists) load(hostID uuid.UUID) ([]uuid.UUID, error) {
+	if list, ok := bl.lists[hostID]; ok {
+		return list, nil
+	}
+	var list []uuid.UUID
+	if bl.store != nil {
+		loaded, err := bl.store.LoadBans(hostID)
+		if err != nil {
+			return nil, err
+		}
+		list = loaded
+	}
+	bl.lists[hostID] = list
+	return list, nil
+}
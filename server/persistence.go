@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/Parkreiner/bingo/game"
+	"github.com/Parkreiner/bingo/gamestore"
+	"github.com/google/uuid"
+)
+
+// roomMetadata is everything about a room that isn't part of its game, but
+// is still needed to bring the room back after the server restarts.
+type roomMetadata struct {
+	RoomID   uuid.UUID         `json:"roomId"`
+	JoinCode JoinCode          `json:"joinCode"`
+	Sessions []sessionMetadata `json:"sessions"`
+}
+
+type sessionMetadata struct {
+	Token    string           `json:"token"`
+	ViewerID uuid.UUID        `json:"viewerId"`
+	Role     bingo.ViewerRole `json:"role"`
+}
+
+// saveMetadata persists the room's sessions, so that everyone can keep using
+// their tokens after a restart. Saving is best-effort; if it fails, the game
+// itself is still recoverable, and people can always re-join.
+func (r *Room) saveMetadata() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.store == nil {
+		return
+	}
+
+	metadata := roomMetadata{
+		RoomID:   r.id,
+		JoinCode: r.joinCode,
+		Sessions: []sessionMetadata{},
+	}
+	for _, sess := range r.sessions {
+		metadata.Sessions = append(metadata.Sessions, sessionMetadata{
+			Token:    sess.token,
+			ViewerID: sess.viewerID,
+			Role:     sess.role,
+		})
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return
+	}
+	_ = r.store.SaveMetadata(r.id, b)
+}
+
+// detachStore stops the room from persisting anything else, and closes its
+// journal. It returns the store the room was attached to, or nil if the room
+// had already been detached.
+func (r *Room) detachStore() gamestore.GameStore {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	store := r.store
+	if r.journal != nil {
+		_ = r.journal.Close()
+	}
+	r.store = nil
+	r.journal = nil
+	return store
+}
+
+// RestoreRooms brings back every room persisted in the server's store, along
+// with all of their sessions. It should be called once, right after the
+// server has been created. Rooms that can't be restored are skipped, and
+// their errors are joined together in the result. Calling RestoreRooms on a
+// server without a store results in a no-op.
+func (s *Server) RestoreRooms() error {
+	store := s.options.Store
+	if store == nil {
+		return nil
+	}
+	roomIDs, err := store.List()
+	if err != nil {
+		return fmt.Errorf("unable to list stored rooms: %v", err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return errors.New("server is shutting down")
+	}
+
+	var errs []error
+	for _, roomID := range roomIDs {
+		if err := s.restoreRoom(roomID); err != nil {
+			errs = append(errs, fmt.Errorf("room %s: %v", roomID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreRoom brings back a single room from the server's store. This method
+// is NOT thread-safe.
+func (s *Server) restoreRoom(roomID uuid.UUID) error {
+	store := s.options.Store
+	ballRNG, cardRNG, ids := s.newSources()
+	recovered, err := gamestore.Recover(store, roomID, game.Init{
+		CreatorID: s.systemID,
+		RNGSeed:   s.nextSeed(),
+		BallRNG:   ballRNG,
+		CardRNG:   cardRNG,
+		IDSource:  ids,
+	})
+	if errors.Is(err, game.ErrGameEnded) {
+		// The server went down before it could clean up after the game
+		return store.Delete(roomID)
+	}
+	if err != nil {
+		return err
+	}
+
+	var metadata roomMetadata
+	if err := json.Unmarshal(recovered.Metadata, &metadata); err != nil {
+		_ = recovered.Journal.Close()
+		return fmt.Errorf("unable to parse room metadata: %v", err)
+	}
+	joinCode := metadata.JoinCode
+	if _, taken := s.rooms[joinCode]; taken || joinCode == "" {
+		joinCode, err = s.generateJoinCode()
+		if err != nil {
+			_ = recovered.Journal.Close()
+			return err
+		}
+	}
+
+	g := recovered.Game
+	room, err := s.openRoom(roomID, joinCode, g, recovered.Journal)
+	if err != nil {
+		return err
+	}
+
+	snapshot := g.Snapshot()
+	host := g.Host()
+	for _, sm := range metadata.Sessions {
+		switch {
+		// Players can be made the host after joining, so the session's role
+		// might be out of date
+		case sm.ViewerID == host.ID:
+			room.addSession(sm.Token, host.ID, bingo.ViewerRoleHost, host.EventReceiver, func() error {
+				return g.LeaveAsHost(host.ID)
+			})
+
+		case sm.Role == bingo.ViewerRolePlayer:
+			// Only players that are still in the game get their sessions back.
+			// Joining with an existing ID hands back the player's current
+			// state, rather than making a new player
+			inGame := slices.ContainsFunc(snapshot.Players, func(p bingo.PlayerSummary) bool {
+				return p.ID == sm.ViewerID
+			})
+			if !inGame {
+				continue
+			}
+			player, leaveGame, err := g.JoinGame(sm.ViewerID, "", 0)
+			if err != nil {
+				continue
+			}
+			room.addSession(sm.Token, player.ID, bingo.ViewerRolePlayer, player.EventReceiver, leaveGame)
+		}
+	}
+	return nil
+}
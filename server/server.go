@@ -1,8 +1,50 @@
+// Package server exposes bingo games over HTTP and WebSockets, so that hosts
+// and players can play from any browser.
 package server
 
 import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
 	"github.com/Parkreiner/bingo"
+	"github.com/Parkreiner/bingo/eventlogger"
+	"github.com/Parkreiner/bingo/game"
+	"github.com/Parkreiner/bingo/gamestore"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// joinCodeLength is the number of letters in every join code
+	joinCodeLength = 4
+	// maxJoinCodeAttempts is how many times the server will try generating a
+	// join code before giving up. With four letters, there are nearly half a
+	// million codes, so running out of attempts means that something is very
+	// wrong.
+	maxJoinCodeAttempts = 100
+	// maxRoomEvents is how many public events each room keeps around for
+	// people who have just loaded the room
+	maxRoomEvents    = 100
+	maxNameLength    = 50
+	maxRequestBytes  = 1 << 16
+	maxMessageBytes  = 1 << 16
+	writeWait        = 10 * time.Second
+	pongWait         = 60 * time.Second
+	pingPeriod       = pongWait * 9 / 10
+	joinCodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// outboundQueueSize is how many messages can be waiting to be written to
+	// a single connection. A connection that falls this far behind is closed,
+	// so that one slow client can never hold up the rest of the room.
+	outboundQueueSize = 256
 )
 
 // JoinCode is a four-letter code for joining a game that a host has already
@@ -15,17 +57,612 @@ type Room struct {
 	id       uuid.UUID
 	joinCode JoinCode
 	game     bingo.GameManager
-	events   []bingo.GameEvent
-}
-
-type playerSessionSnapshot struct {
-	RoomID         uuid.UUID               `json:"roomId"`
-	PlayerID       uuid.UUID               `json:"playerId"`
-	JoinCode       JoinCode                `json:"joinCode"`
-	Phase          bingo.GamePhase         `json:"phase"`
-	PlayerCount    int                     `json:"playerCount"`
-	WinningPlayers []bingo.Player          `json:"winningPlayers"`
-	Suspension     *bingo.PlayerSuspension `json:"suspension"`
-	Cards          []bingo.Card            `json:"cards"`
-	Events         []bingo.GameEvent       `json:"events"`
+	// hostID is whoever is currently hosting the game, and is kept up to date
+	// by recordEvents
+	hostID uuid.UUID
+	// bans is shared by every room on the server, so that the host's bans
+	// carry over to all of their games
+	bans   *banLists
+	events []bingo.GameEvent
+	// sessions maps each session token to the session it belongs to
+	sessions map[string]*session
+	// store and journal will be nil if the server isn't persisting games, or
+	// once the room has been detached from the store
+	store   gamestore.GameStore
+	journal gamestore.Journal
+	// closed is closed once the room's game has been terminated, and all of
+	// the room's resources have been cleaned up
+	closed chan struct{}
+	mtx    sync.Mutex
+}
+
+// Options configures every game created by a Server.
+type Options struct {
+	// SeededRNG makes every game generate its cards and IDs from a seed, so
+	// that games can be replayed from their recordings. Otherwise, everything
+	// comes from the operating system's secure random number generator, and
+	// no recordings are saved. Either way, each game's balls are shuffled with
+	// a secure random seed, since players could otherwise work it out from
+	// their own cards and IDs.
+	SeededRNG bool
+	// RNGSeed is used as the base seed for every new game when SeededRNG is
+	// enabled. If it is zero, each game will be seeded from the current time
+	// instead.
+	RNGSeed int64
+	// MaxPlayers and MaxRounds will fall back to the game package defaults if
+	// they are nil
+	MaxPlayers *int
+	MaxRounds  *int
+	// EventLogDir is the directory where each room will log every event for
+	// its game, with one file per room. Once a game is over, its recording is
+	// also saved there, so that it can be replayed later (e.g., to settle a
+	// disputed win). Logging is disabled if it is empty.
+	EventLogDir string
+	// Store lets rooms survive the server restarting. Persistence is disabled
+	// if it is nil. If the store is also a gamestore.BanStore, each host's
+	// ban list is saved there, too.
+	Store gamestore.GameStore
+}
+
+// Server is an http.Handler that lets hosts create rooms, and lets players
+// join those rooms via their join codes. All live gameplay happens over a
+// WebSocket connection for each player.
+type Server struct {
+	options   Options
+	systemID  uuid.UUID
+	mux       *http.ServeMux
+	upgrader  websocket.Upgrader
+	rooms     map[JoinCode]*Room
+	bans      *banLists
+	roomCount int64
+	closed    bool
+	mtx       sync.Mutex
+}
+
+var _ http.Handler = &Server{}
+
+// New creates a new Server with no rooms.
+func New(options Options) *Server {
+	s := &Server{
+		options:   options,
+		systemID:  uuid.New(),
+		mux:       http.NewServeMux(),
+		rooms:     map[JoinCode]*Room{},
+		bans:      newBanLists(options.Store),
+		roomCount: 0,
+		closed:    false,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+	}
+
+	s.mux.HandleFunc("POST /api/rooms", s.handleCreateRoom)
+	s.mux.HandleFunc("GET /api/rooms/{joinCode}", s.handleGetRoom)
+	s.mux.HandleFunc("POST /api/rooms/{joinCode}/players", s.handleJoinRoom)
+	s.mux.HandleFunc("POST /api/rooms/{joinCode}/leave", s.handleLeaveRoom)
+	s.mux.HandleFunc("GET /api/rooms/{joinCode}/socket", s.handleSocket)
+	return s
+}
+
+// ServeHTTP routes a request to the correct handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close disposes the game for every room, which also closes every open
+// WebSocket connection. It blocks until every room has been cleaned up. Once
+// closed, the server will refuse to create any new rooms. Calling Close more
+// than once results in a no-op.
+//
+// If the server is persisting games, every game is left in the store as-is,
+// so that it can be restored with RestoreRooms later.
+func (s *Server) Close() error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return nil
+	}
+	s.closed = true
+	var rooms []*Room
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mtx.Unlock()
+
+	var errs []error
+	for _, room := range rooms {
+		// Detaching first keeps the shutdown itself from being recorded, so
+		// that the game can be recovered the next time the server starts
+		room.detachStore()
+		err := room.game.IssueCommand(bingo.GameCommand{
+			Type:        bingo.GameCommandSystemDispose,
+			CommanderID: s.systemID,
+			Payload:     nil,
+		})
+		// Games are allowed to end on their own while the server is closing
+		if err != nil && room.game.Snapshot().Phase != bingo.GamePhaseGameOver {
+			errs = append(errs, fmt.Errorf("unable to dispose game for room %s: %v", room.joinCode, err))
+		}
+	}
+	for _, room := range rooms {
+		<-room.closed
+	}
+	return errors.Join(errs...)
+}
+
+type createRoomRequest struct {
+	HostName string `json:"hostName"`
+	// Variant is optional, and falls back to 75-ball bingo
+	Variant bingo.VariantID `json:"variant"`
+	// HostKey is the identity key from one of the host's earlier sessions. It
+	// is optional, but the host's ban list only carries over if it is given.
+	HostKey string `json:"hostKey"`
+}
+
+type joinRoomRequest struct {
+	PlayerName string `json:"playerName"`
+	// CardCount is optional, and falls back to as many cards as the host
+	// allows
+	CardCount int `json:"cardCount"`
+	// PlayerKey is the identity key from one of the player's earlier
+	// sessions. It is optional. Keys aren't verified, so a player who was
+	// banned can get past the ban by leaving the key out.
+	PlayerKey string `json:"playerKey"`
+}
+
+// sessionResponse tells a client everything they need to connect to a room.
+// The token must be provided when opening a WebSocket connection, and should
+// be kept secret. The identity key should also be kept secret, and sent when
+// creating or joining any future rooms, so that the viewer keeps the same ID.
+type sessionResponse struct {
+	RoomID      uuid.UUID        `json:"roomId"`
+	JoinCode    JoinCode         `json:"joinCode"`
+	PlayerID    uuid.UUID        `json:"playerId"`
+	Role        bingo.ViewerRole `json:"role"`
+	Token       string           `json:"token"`
+	IdentityKey string           `json:"identityKey"`
+}
+
+type roomResponse struct {
+	RoomID   uuid.UUID           `json:"roomId"`
+	JoinCode JoinCode            `json:"joinCode"`
+	Game     *bingo.GameSnapshot `json:"game"`
+	Events   []bingo.GameEvent   `json:"events"`
+}
+
+func (s *Server) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
+	var body createRoomRequest
+	if err := decodeRequest(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	hostName, err := validateName(body.HostName)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := bingo.LookupVariant(body.Variant); !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%q is not a supported variant", body.Variant))
+		return
+	}
+	hostID, hostKey, err := resolveIdentity(body.HostKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	room, host, err := s.createRoom(hostID, hostName, body.Variant)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sessionResponse{
+		RoomID:      room.id,
+		JoinCode:    room.joinCode,
+		PlayerID:    host.viewerID,
+		Role:        host.role,
+		Token:       host.token,
+		IdentityKey: hostKey,
+	})
+}
+
+func (s *Server) handleGetRoom(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.findRoom(r.PathValue("joinCode"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("room does not exist"))
+		return
+	}
+
+	snapshot := room.game.Snapshot()
+	room.mtx.Lock()
+	events := append([]bingo.GameEvent{}, room.events...)
+	room.mtx.Unlock()
+
+	writeJSON(w, http.StatusOK, roomResponse{
+		RoomID:   room.id,
+		JoinCode: room.joinCode,
+		Game:     &snapshot,
+		Events:   events,
+	})
+}
+
+func (s *Server) handleJoinRoom(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.findRoom(r.PathValue("joinCode"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("room does not exist"))
+		return
+	}
+
+	var body joinRoomRequest
+	if err := decodeRequest(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	playerName, err := validateName(body.PlayerName)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if body.CardCount < 0 || body.CardCount > bingo.MaxCards {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("card count must be between %d and %d", bingo.MinCards, bingo.MaxCards))
+		return
+	}
+	playerID, playerKey, err := resolveIdentity(body.PlayerKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	player, leaveGame, err := room.game.JoinGame(playerID, playerName, body.CardCount)
+	if err != nil {
+		if leaveGame != nil {
+			_ = leaveGame()
+		}
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	token, err := newSessionToken()
+	if err != nil {
+		_ = leaveGame()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sess := room.addSession(token, player.ID, bingo.ViewerRolePlayer, player.EventReceiver, leaveGame)
+
+	writeJSON(w, http.StatusCreated, sessionResponse{
+		RoomID:      room.id,
+		JoinCode:    room.joinCode,
+		PlayerID:    sess.viewerID,
+		Role:        sess.role,
+		Token:       sess.token,
+		IdentityKey: playerKey,
+	})
+}
+
+func (s *Server) handleLeaveRoom(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.findRoom(r.PathValue("joinCode"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("room does not exist"))
+		return
+	}
+	sess, ok := room.findSession(r.URL.Query().Get("token"))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, errors.New("session token is not valid for this room"))
+		return
+	}
+	// The session will clean itself up once the game stops sending it events
+	if err := sess.leaveGame(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSocket(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.findRoom(r.PathValue("joinCode"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("room does not exist"))
+		return
+	}
+	sess, ok := room.findSession(r.URL.Query().Get("token"))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, errors.New("session token is not valid for this room"))
+		return
+	}
+
+	// The upgrader writes its own error response if the upgrade fails
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	room.serveConnection(sess, ws)
+}
+
+// createRoom spins up a new game, and puts it in a room with a unique join
+// code. Every player on the host's ban list is banned from the game before it
+// starts. The returned session belongs to the host.
+func (s *Server) createRoom(hostID uuid.UUID, hostName string, variant bingo.VariantID) (*Room, *session, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return nil, nil, errors.New("server is shutting down")
+	}
+	joinCode, err := s.generateJoinCode()
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	bannedPlayerIDs, err := s.bans.get(hostID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load ban list: %v", err)
+	}
+
+	roomID := uuid.New()
+	var journal gamestore.Journal
+	if s.options.Store != nil {
+		journal, err = s.options.Store.Open(roomID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to open game storage: %v", err)
+		}
+	}
+
+	ballRNG, cardRNG, ids := s.newSources()
+	g, err := game.New(game.Init{
+		CreatorID:       s.systemID,
+		HostID:          hostID,
+		HostName:        hostName,
+		RNGSeed:         s.nextSeed(),
+		Variant:         variant,
+		BallRNG:         ballRNG,
+		CardRNG:         cardRNG,
+		IDSource:        ids,
+		MaxPlayers:      s.options.MaxPlayers,
+		MaxRounds:       s.options.MaxRounds,
+		WinPattern:      nil,
+		BannedPlayerIDs: bannedPlayerIDs,
+		Journal:         journal,
+	})
+	if err != nil {
+		if s.options.Store != nil {
+			_ = s.options.Store.Delete(roomID)
+		}
+		return nil, nil, fmt.Errorf("unable to create game: %v", err)
+	}
+
+	room, err := s.openRoom(roomID, joinCode, g, journal)
+	if err != nil {
+		return nil, nil, err
+	}
+	host := room.addSession(token, hostID, bingo.ViewerRoleHost, g.Host().EventReceiver, func() error {
+		return g.LeaveAsHost(hostID)
+	})
+	return room, host, nil
+}
+
+// openRoom wraps a game that has just been created (or restored) in a room,
+// and registers the room with the server. The room cleans itself up once its
+// game has been terminated. This method is NOT thread-safe.
+func (s *Server) openRoom(roomID uuid.UUID, joinCode JoinCode, g *game.Game, journal gamestore.Journal) (*Room, error) {
+	room := &Room{
+		id:       roomID,
+		joinCode: joinCode,
+		game:     g,
+		hostID:   g.Host().ID,
+		bans:     s.bans,
+		events:   nil,
+		sessions: map[string]*session{},
+		store:    s.options.Store,
+		journal:  journal,
+		closed:   make(chan struct{}),
+	}
+	roomEvents, _, err := g.Subscribe(nil)
+	if err != nil {
+		s.abandonRoom(room)
+		return nil, fmt.Errorf("unable to subscribe to game: %v", err)
+	}
+
+	var logger *eventlogger.EventLogger
+	if s.options.EventLogDir != "" {
+		logger, err = eventlogger.New(eventlogger.Init{
+			Subscriber: g,
+			OutputPath: filepath.Join(s.options.EventLogDir, fmt.Sprintf("room-%s.jsonl", room.id)),
+		})
+		if err != nil {
+			s.abandonRoom(room)
+			return nil, fmt.Errorf("unable to start event logger: %v", err)
+		}
+	}
+
+	s.rooms[joinCode] = room
+	go func() {
+		room.recordEvents(roomEvents)
+		s.removeRoom(joinCode)
+		if logger != nil {
+			_ = logger.Close()
+			_ = saveRecording(g, filepath.Join(s.options.EventLogDir, fmt.Sprintf("room-%s.recording.json", room.id)))
+		}
+		// If the room is still attached to the store, the game ended on its
+		// own, and there's nothing left to recover
+		if store := room.detachStore(); store != nil {
+			_ = store.Delete(room.id)
+		}
+		close(room.closed)
+	}()
+	return room, nil
+}
+
+// saveRecording writes every input a game has received to a file, so that the
+// game can be replayed. Restored games and games that don't use seeded
+// randomness don't have recordings, so nothing is written for them.
+func saveRecording(g *game.Game, outputPath string) error {
+	recording, err := g.Recording()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(recording)
+	if err != nil {
+		return fmt.Errorf("unable to serialize recording: %v", err)
+	}
+	if err := os.WriteFile(outputPath, b, 0o644); err != nil {
+		return fmt.Errorf("unable to write recording to %q: %v", outputPath, err)
+	}
+	return nil
+}
+
+// abandonRoom tears down a room that could not be fully opened, including
+// any of its persisted data.
+func (s *Server) abandonRoom(room *Room) {
+	if store := room.detachStore(); store != nil {
+		_ = store.Delete(room.id)
+	}
+	_ = room.game.IssueCommand(bingo.GameCommand{
+		Type:        bingo.GameCommandSystemDispose,
+		CommanderID: s.systemID,
+		Payload:     nil,
+	})
+}
+
+// nextSeed produces the RNG seed for a new game. This method is NOT
+// thread-safe.
+func (s *Server) nextSeed() int64 {
+	s.roomCount++
+	if s.options.RNGSeed == 0 {
+		return time.Now().UnixNano()
+	}
+	// Offset the seed for every room, so that two rooms never end up with the
+	// exact same cards and balls
+	return s.options.RNGSeed + s.roomCount
+}
+
+// newSources produces the sources for a new game's balls, cards, and IDs.
+// They are all nil when the server uses seeded randomness, so that the game
+// falls back to its seeds.
+func (s *Server) newSources() (ballRNG game.RNG, cardRNG game.RNG, ids game.IDSource) {
+	if s.options.SeededRNG {
+		return nil, nil, nil
+	}
+	return game.NewCryptoRNG(), game.NewCryptoRNG(), game.NewRandomIDSource()
+}
+
+// generateJoinCode produces a join code that isn't being used by any other
+// room. This method is NOT thread-safe.
+func (s *Server) generateJoinCode() (JoinCode, error) {
+	for i := 0; i < maxJoinCodeAttempts; i++ {
+		b := make([]byte, joinCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("unable to generate join code: %v", err)
+		}
+		for j := range b {
+			b[j] = joinCodeAlphabet[int(b[j])%len(joinCodeAlphabet)]
+		}
+
+		code := JoinCode(b)
+		if _, taken := s.rooms[code]; !taken {
+			return code, nil
+		}
+	}
+	return "", errors.New("unable to find an unused join code")
+}
+
+// findRoom looks up a room by its join code. Join codes are case-insensitive,
+// since people will often be typing them in by hand.
+func (s *Server) findRoom(rawJoinCode string) (*Room, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	room, ok := s.rooms[JoinCode(strings.ToUpper(rawJoinCode))]
+	return room, ok
+}
+
+func (s *Server) removeRoom(joinCode JoinCode) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.rooms, joinCode)
+}
+
+// recordEvents keeps track of every public event dispatched for the room's
+// game, and adds every banned player to the host's ban list. It blocks until
+// the game has been terminated.
+func (r *Room) recordEvents(events <-chan bingo.GameEvent) {
+	for event := range events {
+		switch event.Type {
+		case bingo.EventTypePlayerBanned:
+			r.recordBan(event)
+		case bingo.EventTypeHostTransferred:
+			r.recordHostTransfer(event)
+		}
+		if len(event.RecipientIDs) != 0 {
+			continue
+		}
+
+		r.mtx.Lock()
+		r.events = append(r.events, event)
+		if len(r.events) > maxRoomEvents {
+			r.events = r.events[len(r.events)-maxRoomEvents:]
+		}
+		r.mtx.Unlock()
+	}
+}
+
+// recordBan adds the player from a ban event to the host's ban list. Saving
+// is best-effort; the player is still banned from the current game either
+// way.
+func (r *Room) recordBan(event bingo.GameEvent) {
+	var payload bingo.GameEventPayloadPlayer
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return
+	}
+	r.mtx.Lock()
+	hostID := r.hostID
+	r.mtx.Unlock()
+	_ = r.bans.add(hostID, payload.PlayerID)
+}
+
+// recordHostTransfer keeps track of the room's new host, so that any bans
+// from then on go on their ban list.
+func (r *Room) recordHostTransfer(event bingo.GameEvent) {
+	var payload bingo.GameEventPayloadHostTransferred
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return
+	}
+	r.mtx.Lock()
+	r.hostID = payload.NewHostID
+	r.mtx.Unlock()
+}
+
+// decodeRequest parses the JSON body of a request, rejecting any bodies that
+// are too large.
+func decodeRequest(w http.ResponseWriter, r *http.Request, body any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err := decoder.Decode(body); err != nil {
+		return fmt.Errorf("unable to parse request body: %v", err)
+	}
+	return nil
+}
+
+// validateName makes sure that a display name is safe to show to other
+// players, returning the trimmed name.
+func validateName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return "", errors.New("name must not be empty")
+	}
+	if utf8.RuneCountInString(trimmed) > maxNameLength {
+		return "", fmt.Errorf("name must not be longer than %d characters", maxNameLength)
+	}
+	return trimmed, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
 }
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer starts a server with seeded randomness, which is closed once
+// the test finishes.
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	s := New(Options{
+		SeededRNG:   true,
+		RNGSeed:     1,
+		MaxPlayers:  nil,
+		MaxRounds:   nil,
+		EventLogDir: "",
+		Store:       nil,
+	})
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		if err := s.Close(); err != nil {
+			t.Errorf("unable to close server: %v", err)
+		}
+	})
+	return s, ts
+}
+
+// postTestRequest sends a JSON request to the server, and decodes the session
+// it responds with.
+func postTestRequest(t *testing.T, ts *httptest.Server, path string, body any) sessionResponse {
+	t.Helper()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("unable to encode request: %v", err)
+	}
+	res, err := http.Post(ts.URL+path, "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("unable to send request to %s: %v", path, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %d from %s, got %d", http.StatusCreated, path, res.StatusCode)
+	}
+
+	var session sessionResponse
+	if err := json.NewDecoder(res.Body).Decode(&session); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+	return session
+}
+
+func TestJoinRoomTwiceWithSameKey(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+	host := postTestRequest(t, ts, "/api/rooms", createRoomRequest{
+		HostName: "host",
+		Variant:  "",
+		HostKey:  "",
+	})
+	joinPath := "/api/rooms/" + string(host.JoinCode) + "/players"
+	first := postTestRequest(t, ts, joinPath, joinRoomRequest{
+		PlayerName: "player",
+		CardCount:  1,
+		PlayerKey:  "",
+	})
+	second := postTestRequest(t, ts, joinPath, joinRoomRequest{
+		PlayerName: "player",
+		CardCount:  1,
+		PlayerKey:  first.IdentityKey,
+	})
+
+	if second.PlayerID != first.PlayerID {
+		t.Fatalf("expected the same player ID, got %s and %s", first.PlayerID, second.PlayerID)
+	}
+	if second.Token != first.Token {
+		t.Fatal("expected joining again to hand back the existing session token")
+	}
+
+	room, ok := s.findRoom(string(host.JoinCode))
+	if !ok {
+		t.Fatal("room does not exist")
+	}
+	room.mtx.Lock()
+	sessionCount := len(room.sessions)
+	room.mtx.Unlock()
+	if sessionCount != 2 {
+		t.Fatalf("expected one session for the host and one for the player, got %d", sessionCount)
+	}
+}
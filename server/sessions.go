@@ -0,0 +1,347 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Parkreiner/bingo"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// serverMessageType indicates what kind of data a server message contains
+type serverMessageType string
+
+const (
+	// serverMessageSnapshot is sent as soon as a connection opens, and
+	// contains the full game state as the viewer is allowed to see it
+	serverMessageSnapshot serverMessageType = "snapshot"
+	// serverMessageDiff contains everything needed to bring the viewer's state
+	// up to date with the game
+	serverMessageDiff serverMessageType = "diff"
+	// serverMessageEvent contains a single event dispatched by the game
+	serverMessageEvent serverMessageType = "event"
+	// serverMessageError indicates that the server couldn't process a message
+	// sent by the client
+	serverMessageError serverMessageType = "error"
+)
+
+// serverMessage is the envelope for every message sent over a WebSocket. Only
+// the field matching the message type will be defined.
+type serverMessage struct {
+	Type     serverMessageType     `json:"type"`
+	Snapshot *bingo.ViewerSnapshot `json:"snapshot,omitempty"`
+	Diff     *bingo.StateDiff      `json:"diff,omitempty"`
+	Event    *bingo.GameEvent      `json:"event,omitempty"`
+	Error    *serverMessageErr     `json:"error,omitempty"`
+}
+
+type serverMessageErr struct {
+	CommandType bingo.GameCommandType `json:"commandType"`
+	Reason      string                `json:"reason"`
+}
+
+// session represents a single host or player in a room. A session outlives
+// any individual WebSocket connection, so that people can reconnect without
+// losing their place in the game. Only one connection can be active for a
+// session at a time.
+type session struct {
+	token    string
+	viewerID uuid.UUID
+	role     bingo.ViewerRole
+	// events is the receiver that the session drains. Every receiver can only
+	// ever be drained by one session.
+	events <-chan bingo.GameEvent
+	// leaveGame hands the game over to someone else when the host leaves
+	leaveGame func() error
+	// ended indicates that the game will never send the session any more
+	// events, and that no new connections should be accepted
+	ended bool
+	conn  *connection
+	mtx   sync.Mutex
+}
+
+// connection is a single WebSocket connection for a session. Every message is
+// written by the connection's own writer goroutine, so that nothing else ever
+// has to wait on the network.
+type connection struct {
+	ws *websocket.Conn
+	// outbound holds every message that is waiting to be written
+	outbound chan serverMessage
+	// stale is signaled whenever the game has (probably) changed, and the
+	// viewer needs a new diff. It is buffered with a size of 1, so that any
+	// number of changes can be coalesced into a single diff.
+	stale chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newConnection(ws *websocket.Conn) *connection {
+	return &connection{
+		ws:       ws,
+		outbound: make(chan serverMessage, outboundQueueSize),
+		stale:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// close shuts down the underlying WebSocket. Calling it more than once results
+// in a no-op.
+func (c *connection) close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.ws.Close()
+	})
+}
+
+// writeMessages writes every queued message to the WebSocket, and keeps the
+// connection alive with pings, until the connection closes. Any write
+// failures close the connection.
+func (c *connection) writeMessages() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close()
+				return
+			}
+		case message := <-c.outbound:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteJSON(&message); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// enqueue adds a message to the connection's queue without blocking,
+// returning false if the queue is full.
+func (c *connection) enqueue(message serverMessage) bool {
+	select {
+	case c.outbound <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *connection) markStale() {
+	select {
+	case c.stale <- struct{}{}:
+	default:
+	}
+}
+
+// addSession registers a new session for the room, and immediately starts
+// draining events for it. The game blocks while waiting for subscribers to
+// receive events, so every session must keep reading, even while it doesn't
+// have any active connections.
+//
+// Joining a game with an ID that is already in it hands back the same event
+// receiver, so if a session is already draining the receiver, that session is
+// returned instead, and the new token is never used.
+func (r *Room) addSession(token string, viewerID uuid.UUID, role bingo.ViewerRole, events <-chan bingo.GameEvent, leaveGame func() error) *session {
+	r.mtx.Lock()
+	for _, existing := range r.sessions {
+		if existing.events == events {
+			r.mtx.Unlock()
+			return existing
+		}
+	}
+	sess := &session{
+		token:     token,
+		viewerID:  viewerID,
+		role:      role,
+		events:    events,
+		leaveGame: leaveGame,
+		ended:     false,
+		conn:      nil,
+	}
+	r.sessions[sess.token] = sess
+	r.mtx.Unlock()
+	r.saveMetadata()
+
+	go func() {
+		sess.forwardEvents()
+		r.mtx.Lock()
+		delete(r.sessions, sess.token)
+		r.mtx.Unlock()
+		r.saveMetadata()
+	}()
+	return sess
+}
+
+// newSessionToken generates a random token that is practically impossible to
+// guess.
+func newSessionToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("unable to generate session token: %v", err)
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+func (r *Room) findSession(token string) (*session, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	sess, ok := r.sessions[token]
+	return sess, ok
+}
+
+// serveConnection handles a WebSocket connection for a session until either
+// side closes it. Any previous connection for the session is closed first.
+func (r *Room) serveConnection(sess *session, ws *websocket.Conn) {
+	conn := newConnection(ws)
+	if !sess.connect(conn) {
+		_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session has ended"), time.Now().Add(writeWait))
+		_ = ws.Close()
+		return
+	}
+	defer sess.disconnect(conn)
+
+	go conn.writeMessages()
+	go r.syncConnection(sess, conn)
+
+	ws.SetReadLimit(maxMessageBytes)
+	_ = ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var command bingo.GameCommand
+		if err := json.Unmarshal(data, &command); err != nil {
+			sess.write(conn, serverMessage{
+				Type:  serverMessageError,
+				Error: &serverMessageErr{CommandType: "", Reason: fmt.Sprintf("unable to parse command: %v", err)},
+			})
+			continue
+		}
+
+		// Clients are never trusted to say who they are; the session decides
+		command.CommanderID = sess.viewerID
+		if err := r.game.IssueCommand(command); err != nil {
+			sess.write(conn, serverMessage{
+				Type:  serverMessageError,
+				Error: &serverMessageErr{CommandType: command.Type, Reason: err.Error()},
+			})
+		}
+	}
+}
+
+// syncConnection sends the viewer a full snapshot, and then keeps them up to
+// date with diffs whenever the game changes. It runs until the connection
+// closes.
+func (r *Room) syncConnection(sess *session, conn *connection) {
+	snapshot := r.game.SnapshotFor(sess.viewerID)
+	version := snapshot.Game.Version
+	sess.write(conn, serverMessage{Type: serverMessageSnapshot, Snapshot: &snapshot})
+
+	for {
+		select {
+		case <-conn.done:
+			return
+		case <-conn.stale:
+			diff := r.game.DiffSince(sess.viewerID, version)
+			version = diff.ToVersion
+			// Plenty of events (e.g., errors) don't change the state at all
+			if diff.Snapshot == nil && len(diff.Patch) == 0 {
+				continue
+			}
+			sess.write(conn, serverMessage{Type: serverMessageDiff, Diff: &diff})
+		}
+	}
+}
+
+// forwardEvents sends every event from the game to the session's active
+// connection (if there is one). Events are only ever queued, so the game never
+// has to wait on a slow client. Events that arrive while the session is
+// disconnected are dropped; the next connection will start from a fresh
+// snapshot anyway. Blocks until the game stops sending events to the session.
+func (s *session) forwardEvents() {
+	for event := range s.events {
+		s.mtx.Lock()
+		conn := s.conn
+		s.mtx.Unlock()
+		if conn == nil {
+			continue
+		}
+
+		s.write(conn, serverMessage{Type: serverMessageEvent, Event: &event})
+		conn.markStale()
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.ended = true
+	if s.conn != nil {
+		_ = s.conn.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session has ended"), time.Now().Add(writeWait))
+		s.conn.close()
+		s.conn = nil
+	}
+}
+
+// connect makes a connection the active one for the session, returning false
+// if the session has already ended.
+func (s *session) connect(conn *connection) bool {
+	s.mtx.Lock()
+	if s.ended {
+		s.mtx.Unlock()
+		return false
+	}
+	prevConn := s.conn
+	s.conn = conn
+	s.mtx.Unlock()
+
+	// The old connection might be stalled, so it's closed without holding
+	// onto the session
+	if prevConn != nil {
+		_ = prevConn.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "replaced by a newer connection"), time.Now().Add(writeWait))
+		prevConn.close()
+	}
+	return true
+}
+
+// disconnect closes a connection, and detaches it from the session if it is
+// still the active one.
+func (s *session) disconnect(conn *connection) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	conn.close()
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+// write queues a message for a connection, as long as that connection is
+// still the active one for the session. A connection whose queue is full has
+// fallen too far behind, so it is closed; the client can reconnect and start
+// over from a fresh snapshot.
+func (s *session) write(conn *connection, message serverMessage) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.conn != conn {
+		return
+	}
+
+	// Sending a close message would mean waiting on the same stalled writer,
+	// so the connection is just dropped
+	if !conn.enqueue(message) {
+		conn.close()
+		s.conn = nil
+	}
+}
@@ -0,0 +1,50 @@
+package bingo
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// PatchOperationType indicates what a single patch operation does. The values
+// match the operations defined by JSON Patch (RFC 6902), so that clients can
+// apply patches with any off-the-shelf JSON Patch library.
+type PatchOperationType string
+
+const (
+	// PatchOperationAdd adds a new value. If the path points into an array,
+	// the value is inserted at that index.
+	PatchOperationAdd PatchOperationType = "add"
+	// PatchOperationRemove removes the value at the path.
+	PatchOperationRemove PatchOperationType = "remove"
+	// PatchOperationReplace replaces the value at the path.
+	PatchOperationReplace PatchOperationType = "replace"
+)
+
+// PatchOperation describes a single change to a JSON document. Path is a JSON
+// Pointer (RFC 6901) into the serialized ViewerSnapshot.
+type PatchOperation struct {
+	Op   PatchOperationType `json:"op"`
+	Path string             `json:"path"`
+	// Value will be empty for remove operations
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// StateDiff describes everything that a viewer needs to go from the last
+// version of the game state they received to the current version. Exactly one
+// of Patch and Snapshot will be defined.
+type StateDiff struct {
+	ViewerID    uuid.UUID `json:"viewerId"`
+	FromVersion uint64    `json:"fromVersion"`
+	ToVersion   uint64    `json:"toVersion"`
+	// Patch contains the operations that turn the viewer's snapshot at
+	// FromVersion into their snapshot at ToVersion, in the order they need to
+	// be applied. An empty patch means that nothing the viewer can see has
+	// changed.
+	Patch []PatchOperation `json:"patch"`
+	// Snapshot is only defined when a patch could not be produced (e.g., the
+	// viewer has fallen too far behind, or the server doesn't remember what
+	// they were last sent). The viewer should throw away their old state and
+	// use the snapshot as-is.
+	Snapshot *ViewerSnapshot `json:"snapshot"`
+}
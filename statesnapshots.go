@@ -1,13 +1,50 @@
 package bingo
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // GameSnapshot is a snapshot of the current game state. It should be treated as
 // a 100% immutable value.
-// TODO: Figure out what other fields need to be on here
 type GameSnapshot struct {
-	Phase  GamePhase `json:"phase"`
-	Called []Ball    `json:"called"`
+	// Version is the state version that the snapshot was taken at. It
+	// increases every time the game state changes.
+	Version    uint64     `json:"version"`
+	Phase      GamePhase  `json:"phase"`
+	Called     []Ball     `json:"called"`
+	WinPattern WinPattern `json:"winPattern"`
+	// CallingMode indicates whether the host is requesting balls from the
+	// game, or entering them from a physical ball machine
+	CallingMode CallingMode `json:"callingMode"`
+	// Variant indicates which style of bingo the game is being played with
+	Variant VariantID `json:"variant"`
+	// MaxCardsPerPlayer is the most cards that the host allows each player to
+	// have. Each player's current count is in their PlayerSummary.
+	MaxCardsPerPlayer int `json:"maxCardsPerPlayer"`
+	// AutoCaller describes the state of the game's auto-caller
+	AutoCaller AutoCallerStatus `json:"autoCaller"`
+	Round      int              `json:"round"`
+	MaxRounds  int              `json:"maxRounds"`
+	// DrawCommitment is the commitment for the current round's draw. See
+	// GameEventPayloadDrawCommitted.
+	DrawCommitment string `json:"drawCommitment"`
+	// HostID and HostName belong to whoever is currently hosting the game
+	HostID   uuid.UUID `json:"hostId"`
+	HostName string    `json:"hostName"`
+	// CoHosts contains every co-host, in the order they were appointed
+	CoHosts []CoHost `json:"coHosts"`
+	// Players contains every card player currently in the game (minus the
+	// host)
+	Players []PlayerSummary `json:"players"`
+	// WinningPlayers contains every player who has won a round, in the order
+	// that they won. A player can appear more than once.
+	WinningPlayers []PlayerSummary `json:"winningPlayers"`
+	// Tiebreaker describes the tiebreaker currently being played. It is nil
+	// outside of the tiebreaker phase.
+	Tiebreaker *TiebreakerStatus `json:"tiebreaker"`
 }
 
 var _ json.Marshaler = &GameSnapshot{}
@@ -17,12 +54,161 @@ var _ json.Marshaler = &GameSnapshot{}
 // null.
 func (gs *GameSnapshot) MarshalJSON() ([]byte, error) {
 	snapCopy := GameSnapshot{
-		Phase:  gs.Phase,
-		Called: gs.Called,
+		Version:           gs.Version,
+		Phase:             gs.Phase,
+		Called:            gs.Called,
+		WinPattern:        gs.WinPattern,
+		CallingMode:       gs.CallingMode,
+		Variant:           gs.Variant,
+		MaxCardsPerPlayer: gs.MaxCardsPerPlayer,
+		AutoCaller:        gs.AutoCaller,
+		Round:             gs.Round,
+		MaxRounds:         gs.MaxRounds,
+		DrawCommitment:    gs.DrawCommitment,
+		HostID:            gs.HostID,
+		HostName:          gs.HostName,
+		CoHosts:           gs.CoHosts,
+		Players:           gs.Players,
+		WinningPlayers:    gs.WinningPlayers,
+		Tiebreaker:        gs.Tiebreaker,
 	}
 	if snapCopy.Called == nil {
 		snapCopy.Called = []Ball{}
 	}
+	if snapCopy.WinPattern.Masks == nil {
+		snapCopy.WinPattern.Masks = []PatternMask{}
+	}
+	if snapCopy.CoHosts == nil {
+		snapCopy.CoHosts = []CoHost{}
+	}
+	if snapCopy.Players == nil {
+		snapCopy.Players = []PlayerSummary{}
+	}
+	if snapCopy.WinningPlayers == nil {
+		snapCopy.WinningPlayers = []PlayerSummary{}
+	}
 
 	return json.Marshal(snapCopy)
 }
+
+// AutoCallerStatus describes whether a game is calling balls on its own.
+type AutoCallerStatus struct {
+	// Running indicates that the host has started the auto-caller. Balls are
+	// still only called while the round is in the round start or calling
+	// phases.
+	Running    bool  `json:"running"`
+	IntervalMs int64 `json:"intervalMs"`
+	// NextCallAt is when the next ball will be called. It is nil whenever
+	// the auto-caller is paused.
+	NextCallAt *time.Time `json:"nextCallAt"`
+}
+
+// TiebreakerStatus describes a tiebreaker that is currently being played.
+type TiebreakerStatus struct {
+	Rule TiebreakerRule `json:"rule"`
+	// PlayerIDs contains every player still taking part in the tiebreaker.
+	// Every other player is a spectator until the tiebreaker is over.
+	PlayerIDs []uuid.UUID `json:"playerIds"`
+	// Called contains every ball called for the tiebreaker, in order
+	Called []Ball `json:"called"`
+	// HighBalls contains the ball drawn for each player so far. It is only
+	// used by TiebreakerRuleHighBall.
+	HighBalls []TiebreakerDraw `json:"highBalls"`
+	// Commitment is the commitment for the tiebreaker's draw. See
+	// GameEventPayloadTiebreakerStarted.
+	Commitment string `json:"commitment"`
+}
+
+// TiebreakerDraw is a single ball drawn for a player during a high-ball
+// tiebreaker.
+type TiebreakerDraw struct {
+	PlayerID uuid.UUID `json:"playerId"`
+	Ball     Ball      `json:"ball"`
+}
+
+// PlayerSummary contains all the information about a player that is safe for
+// anyone to see.
+type PlayerSummary struct {
+	ID          uuid.UUID    `json:"id"`
+	Name        string       `json:"name"`
+	Status      PlayerStatus `json:"status"`
+	CardCount   int          `json:"cardCount"`
+	CalledBingo bool         `json:"calledBingo"`
+}
+
+// CardValidation describes whether a single card is able to back up a bingo
+// claim.
+type CardValidation struct {
+	CardID       uuid.UUID      `json:"cardId"`
+	WinningCells []CellPosition `json:"winningCells"`
+	// Any daubs on balls that haven't been called yet. A card with invalid
+	// daubs cannot win, even if it satisfies the win pattern.
+	InvalidDaubs []CellPosition `json:"invalidDaubs"`
+}
+
+// ClaimValidation contains the results of the server automatically checking
+// whether a player's cards back up their bingo claim. It is purely advisory;
+// the host always makes the final call.
+type ClaimValidation struct {
+	PlayerID uuid.UUID        `json:"playerId"`
+	Valid    bool             `json:"valid"`
+	Cards    []CardValidation `json:"cards"`
+}
+
+// ViewerRole indicates how a viewer is related to a game, which determines how
+// much of the game state they are allowed to see.
+type ViewerRole string
+
+const (
+	// ViewerRoleHost indicates that the viewer is hosting the game, and can
+	// see the state for every player.
+	ViewerRoleHost ViewerRole = "host"
+	// ViewerRolePlayer indicates that the viewer is a card player, and can see
+	// their own private state.
+	ViewerRolePlayer ViewerRole = "player"
+	// ViewerRoleCoHost indicates that the viewer is a card player who has
+	// been made a co-host. They can see their own private state, along with
+	// the host's.
+	ViewerRoleCoHost ViewerRole = "co_host"
+	// ViewerRoleSpectator indicates that the viewer is not part of the game,
+	// and can only see public state.
+	ViewerRoleSpectator ViewerRole = "spectator"
+)
+
+// PlayerView contains the private state that only a specific player is allowed
+// to see.
+type PlayerView struct {
+	Player PlayerSummary `json:"player"`
+	Cards  []Card        `json:"cards"`
+	// Suspension will be nil if the player is not currently suspended
+	Suspension *PlayerSuspension `json:"suspension"`
+}
+
+// PlayerClaimStatus describes where a single player stands in terms of calling
+// bingo.
+type PlayerClaimStatus struct {
+	PlayerID    uuid.UUID `json:"playerId"`
+	CalledBingo bool      `json:"calledBingo"`
+	// Validation will be nil if the player has not called bingo
+	Validation *ClaimValidation `json:"validation"`
+}
+
+// HostView contains the private state that only the host is allowed to see.
+type HostView struct {
+	Claims          []PlayerClaimStatus `json:"claims"`
+	Suspensions     []PlayerSuspension  `json:"suspensions"`
+	BannedPlayerIDs []uuid.UUID         `json:"bannedPlayerIds"`
+}
+
+// ViewerSnapshot is a snapshot of the game state, projected for a specific
+// viewer. Public state is always included, but only one of the Player and Host
+// fields will be defined (and both will be nil for spectators). Co-hosts are
+// the one exception, and get both. Like GameSnapshot, it should be treated as
+// a 100% immutable value.
+type ViewerSnapshot struct {
+	ViewerID uuid.UUID    `json:"viewerId"`
+	Role     ViewerRole   `json:"role"`
+	Game     GameSnapshot `json:"game"`
+	Player   *PlayerView  `json:"player"`
+	Host     *HostView    `json:"host"`
+}
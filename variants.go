@@ -0,0 +1,327 @@
+package bingo
+
+import "fmt"
+
+// VariantID identifies one of the styles of bingo that a game can be played
+// with.
+type VariantID string
+
+const (
+	// VariantID75Ball identifies 75-ball American bingo. It is the default
+	// for every game.
+	VariantID75Ball VariantID = "75_ball"
+	// VariantID90Ball identifies 90-ball bingo, as it is played in the UK.
+	VariantID90Ball VariantID = "90_ball"
+	// VariantID80Ball identifies 80-ball bingo, which uses 4x4 cards with
+	// color-coded columns.
+	VariantID80Ball VariantID = "80_ball"
+	// VariantID30Ball identifies 30-ball speed bingo, which uses 3x3 cards.
+	VariantID30Ball VariantID = "30_ball"
+)
+
+// BallRange is a contiguous range of bingo balls. Both ends are inclusive.
+type BallRange struct {
+	Min Ball `json:"min"`
+	Max Ball `json:"max"`
+	// Label is how the column is identified to players (e.g., the letters in
+	// B-I-N-G-O, or the colors on an 80-ball card). It is empty for columns
+	// that don't have a label.
+	Label string `json:"label,omitempty"`
+}
+
+// Variant describes the rules for a single style of bingo: which balls can be
+// drawn, what every card looks like, and what players need to daub to win.
+type Variant struct {
+	ID   VariantID `json:"id"`
+	Name string    `json:"name"`
+	// MaxBall is the highest ball that can be drawn. Balls always start at 1.
+	MaxBall int `json:"maxBall"`
+	// Rows is the number of rows on every card
+	Rows int `json:"rows"`
+	// Columns contains the range of numbers for each column on a card, from
+	// left to right. Every column's numbers are drawn from its range without
+	// any repeats.
+	Columns []BallRange `json:"columns"`
+	// NumbersPerRow is how many cells in each row have a number, with every
+	// other cell left blank. Zero means that every cell has a number.
+	NumbersPerRow int `json:"numbersPerRow"`
+	// FreeSpace indicates that the cell in the middle of every card is a free
+	// space, instead of a number
+	FreeSpace bool `json:"freeSpace"`
+	// StripSize is the number of cards in a strip, which together contain
+	// every ball exactly once. Zero means that the variant doesn't have
+	// strips. Only variants with sparse cards (see NumbersPerRow) can have
+	// strips.
+	StripSize int `json:"stripSize"`
+	// WinPatterns lists every standard win pattern for the variant, in the
+	// order that they should be presented to hosts. The first pattern is the
+	// default.
+	WinPatterns []WinPattern `json:"winPatterns"`
+}
+
+// Cols returns the number of columns on every card.
+func (v Variant) Cols() int {
+	return len(v.Columns)
+}
+
+// NumbersPerCard returns how many cells on every card have a number (i.e.,
+// every cell that isn't blank or a free space).
+func (v Variant) NumbersPerCard() int {
+	perRow := v.NumbersPerRow
+	if perRow == 0 {
+		perRow = v.Cols()
+	}
+	total := perRow * v.Rows
+	if v.FreeSpace {
+		total--
+	}
+	return total
+}
+
+// ParseBall takes any arbitrary int, and attempts to turn it into a bingo
+// ball for the variant. Zero is allowed, since it represents the free space
+// (or a blank cell), so callers need to reject it when it doesn't make sense.
+func (v Variant) ParseBall(rawBallValue int) (Ball, error) {
+	if rawBallValue > v.MaxBall {
+		return FreeSpace, fmt.Errorf("value %d is not allowed to exceed %d", rawBallValue, v.MaxBall)
+	}
+	if rawBallValue < 0 {
+		return FreeSpace, fmt.Errorf("value %d is not allowed to fall below 0", rawBallValue)
+	}
+	return Ball(rawBallValue), nil
+}
+
+// ValidateWinPattern makes sure that a pattern (particularly a custom one)
+// fits the variant's cards.
+func (v Variant) ValidateWinPattern(wp WinPattern) error {
+	return wp.validateShape(v.Rows, v.Cols())
+}
+
+// LookupWinPattern finds one of the variant's standard win patterns by its
+// ID.
+func (v Variant) LookupWinPattern(id string) (WinPattern, bool) {
+	for _, p := range v.WinPatterns {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return WinPattern{}, false
+}
+
+// DefaultWinPattern returns the pattern that every game of the variant starts
+// with.
+func (v Variant) DefaultWinPattern() WinPattern {
+	return v.WinPatterns[0]
+}
+
+// All variants that a game can be played with.
+var (
+	// Variant75Ball is American bingo: 5x5 cards with a free space in the
+	// middle, where each column corresponds to a letter in B-I-N-G-O.
+	Variant75Ball = Variant{
+		ID:      VariantID75Ball,
+		Name:    "75-Ball",
+		MaxBall: MaxBallValue,
+		Rows:    CardSize,
+		Columns: []BallRange{
+			{Min: 1, Max: 15, Label: "B"},
+			{Min: 16, Max: 30, Label: "I"},
+			{Min: 31, Max: 45, Label: "N"},
+			{Min: 46, Max: 60, Label: "G"},
+			{Min: 61, Max: 75, Label: "O"},
+		},
+		NumbersPerRow: 0,
+		FreeSpace:     true,
+		StripSize:     0,
+		WinPatterns:   StandardWinPatterns,
+	}
+	// Variant90Ball is UK bingo: 3x9 tickets where every row has five numbers
+	// and four blanks. The first column has the numbers 1-9, the last column
+	// has 80-90, and every column in between covers the next ten numbers.
+	// Tickets can also be sold as strips of six, which cover all 90 balls.
+	//
+	// The traditional prizes (one line, two lines, and a full house) are each
+	// a separate win pattern, so the host picks which prize each round is
+	// played for.
+	Variant90Ball = Variant{
+		ID:      VariantID90Ball,
+		Name:    "90-Ball",
+		MaxBall: 90,
+		Rows:    3,
+		Columns: []BallRange{
+			{Min: 1, Max: 9},
+			{Min: 10, Max: 19},
+			{Min: 20, Max: 29},
+			{Min: 30, Max: 39},
+			{Min: 40, Max: 49},
+			{Min: 50, Max: 59},
+			{Min: 60, Max: 69},
+			{Min: 70, Max: 79},
+			{Min: 80, Max: 90},
+		},
+		NumbersPerRow: 5,
+		FreeSpace:     false,
+		StripSize:     6,
+		WinPatterns: []WinPattern{
+			WinPatternOneLine,
+			WinPatternTwoLines,
+			WinPatternFullHouse,
+		},
+	}
+	// Variant80Ball uses 4x4 cards without a free space. Each column is a
+	// different color, and covers twenty numbers.
+	Variant80Ball = Variant{
+		ID:      VariantID80Ball,
+		Name:    "80-Ball",
+		MaxBall: 80,
+		Rows:    4,
+		Columns: []BallRange{
+			{Min: 1, Max: 20, Label: "Red"},
+			{Min: 21, Max: 40, Label: "Yellow"},
+			{Min: 41, Max: 60, Label: "Blue"},
+			{Min: 61, Max: 80, Label: "White"},
+		},
+		NumbersPerRow: 0,
+		FreeSpace:     false,
+		StripSize:     0,
+		WinPatterns: []WinPattern{
+			WinPattern80BallAnyLine,
+			WinPattern80BallFourCorners,
+			WinPattern80BallCenterSquare,
+			WinPattern80BallFullHouse,
+		},
+	}
+	// Variant30Ball is speed bingo: 3x3 cards without a free space, where each
+	// column covers ten numbers. Rounds are usually played for a full house,
+	// so they only last a few minutes.
+	Variant30Ball = Variant{
+		ID:      VariantID30Ball,
+		Name:    "30-Ball",
+		MaxBall: 30,
+		Rows:    3,
+		Columns: []BallRange{
+			{Min: 1, Max: 10},
+			{Min: 11, Max: 20},
+			{Min: 21, Max: 30},
+		},
+		NumbersPerRow: 0,
+		FreeSpace:     false,
+		StripSize:     0,
+		WinPatterns: []WinPattern{
+			WinPattern30BallFullHouse,
+			WinPattern30BallAnyLine,
+		},
+	}
+)
+
+// Win patterns for 90-ball bingo. Blank cells always count towards a pattern,
+// so a row is complete once all five of its numbers have been daubed.
+var (
+	// WinPatternOneLine is satisfied by any complete row on a ticket.
+	WinPatternOneLine = WinPattern{
+		ID:   "one_line",
+		Name: "One Line",
+		Masks: []PatternMask{
+			mustParsePatternMask("XXXXXXXXX", ".........", "........."),
+			mustParsePatternMask(".........", "XXXXXXXXX", "........."),
+			mustParsePatternMask(".........", ".........", "XXXXXXXXX"),
+		},
+	}
+	// WinPatternTwoLines is satisfied by any two complete rows on a ticket.
+	WinPatternTwoLines = WinPattern{
+		ID:   "two_lines",
+		Name: "Two Lines",
+		Masks: []PatternMask{
+			mustParsePatternMask("XXXXXXXXX", "XXXXXXXXX", "........."),
+			mustParsePatternMask("XXXXXXXXX", ".........", "XXXXXXXXX"),
+			mustParsePatternMask(".........", "XXXXXXXXX", "XXXXXXXXX"),
+		},
+	}
+	// WinPatternFullHouse is satisfied by daubing every number on a ticket.
+	WinPatternFullHouse = WinPattern{
+		ID:    "full_house",
+		Name:  "Full House",
+		Masks: []PatternMask{fullMask(3, 9)},
+	}
+)
+
+// Win patterns for 80-ball bingo.
+var (
+	// WinPattern80BallAnyLine is satisfied by any complete row, column, or
+	// diagonal.
+	WinPattern80BallAnyLine = WinPattern{
+		ID:    "any_line",
+		Name:  "Any Line",
+		Masks: lineMasks(4),
+	}
+	// WinPattern80BallFourCorners is satisfied by daubing the four corners of
+	// a card.
+	WinPattern80BallFourCorners = WinPattern{
+		ID:   "four_corners",
+		Name: "Four Corners",
+		Masks: []PatternMask{mustParsePatternMask(
+			"X..X",
+			"....",
+			"....",
+			"X..X",
+		)},
+	}
+	// WinPattern80BallCenterSquare is satisfied by daubing the 2x2 square in
+	// the middle of a card.
+	WinPattern80BallCenterSquare = WinPattern{
+		ID:   "center_square",
+		Name: "Center Square",
+		Masks: []PatternMask{mustParsePatternMask(
+			"....",
+			".XX.",
+			".XX.",
+			"....",
+		)},
+	}
+	// WinPattern80BallFullHouse is satisfied by daubing every cell on a card.
+	WinPattern80BallFullHouse = WinPattern{
+		ID:    "full_house",
+		Name:  "Full House",
+		Masks: []PatternMask{fullMask(4, 4)},
+	}
+)
+
+// Win patterns for 30-ball bingo.
+var (
+	// WinPattern30BallFullHouse is satisfied by daubing every cell on a card.
+	WinPattern30BallFullHouse = WinPattern{
+		ID:    "full_house",
+		Name:  "Full House",
+		Masks: []PatternMask{fullMask(3, 3)},
+	}
+	// WinPattern30BallAnyLine is satisfied by any complete row, column, or
+	// diagonal.
+	WinPattern30BallAnyLine = WinPattern{
+		ID:    "any_line",
+		Name:  "Any Line",
+		Masks: lineMasks(3),
+	}
+)
+
+// StandardVariants lists every variant that a game can be played with, in the
+// order that they should be presented to hosts.
+var StandardVariants = []Variant{
+	Variant75Ball,
+	Variant90Ball,
+	Variant80Ball,
+	Variant30Ball,
+}
+
+// LookupVariant finds a standard variant by its ID. An empty ID refers to the
+// default variant (75-ball).
+func LookupVariant(id VariantID) (Variant, bool) {
+	if id == "" {
+		return Variant75Ball, true
+	}
+	for _, v := range StandardVariants {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
@@ -0,0 +1,290 @@
+package bingo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CardSize is the number of rows and columns in a standard American bingo
+// card.
+const CardSize int = 5
+
+// CellPosition describes where a single cell lives on a bingo card. Both
+// indices are zero-based.
+type CellPosition struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// PatternMask is a grid of booleans with the same shape as a bingo card. Every
+// true value indicates a cell that must be daubed for the mask to be
+// satisfied.
+type PatternMask [][]bool
+
+// ParsePatternMask turns a set of strings into a pattern mask, where each
+// string represents a single row. An 'X' indicates a cell that must be
+// daubed, and a '.' indicates a cell that doesn't matter. For example, the
+// four corners pattern looks like:
+//
+//	X...X
+//	.....
+//	.....
+//	.....
+//	X...X
+func ParsePatternMask(rows ...string) (PatternMask, error) {
+	var mask PatternMask
+	for i, row := range rows {
+		var parsedRow []bool
+		for _, char := range row {
+			switch char {
+			case 'X':
+				parsedRow = append(parsedRow, true)
+			case '.':
+				parsedRow = append(parsedRow, false)
+			default:
+				return nil, fmt.Errorf("row %d has invalid character %q", i+1, char)
+			}
+		}
+		mask = append(mask, parsedRow)
+	}
+	return mask, nil
+}
+
+// mustParsePatternMask is a convenience wrapper over ParsePatternMask for
+// masks that are known to be valid ahead of time.
+func mustParsePatternMask(rows ...string) PatternMask {
+	mask, err := ParsePatternMask(rows...)
+	if err != nil {
+		panic(err)
+	}
+	return mask
+}
+
+// Positions returns the positions of every cell that must be daubed for the
+// mask to be satisfied.
+func (pm PatternMask) Positions() []CellPosition {
+	var positions []CellPosition
+	for i, row := range pm {
+		for j, required := range row {
+			if required {
+				positions = append(positions, CellPosition{Row: i, Col: j})
+			}
+		}
+	}
+	return positions
+}
+
+// validate makes sure that the mask is the right shape for a card with the
+// given dimensions, and that it requires at least one cell.
+func (pm PatternMask) validate(rows int, cols int) error {
+	if len(pm) != rows {
+		return fmt.Errorf("mask must have %d rows, but has %d", rows, len(pm))
+	}
+	for i, row := range pm {
+		if len(row) != cols {
+			return fmt.Errorf("mask row %d must have %d columns, but has %d", i+1, cols, len(row))
+		}
+	}
+	if len(pm.Positions()) == 0 {
+		return errors.New("mask must require at least one cell")
+	}
+	return nil
+}
+
+// WinPattern describes what a player needs to daub on a single card in order
+// to win a round. A pattern can have multiple masks, and is satisfied as soon
+// as ANY of them are fully daubed. This makes it possible to express patterns
+// like "any single line" without needing special logic for each one.
+type WinPattern struct {
+	ID    string        `json:"id"`
+	Name  string        `json:"name"`
+	Masks []PatternMask `json:"masks"`
+}
+
+// Validate makes sure that a pattern (particularly a custom one) can be used
+// for a game of American bingo. Use Variant.ValidateWinPattern for every other
+// variant.
+func (wp WinPattern) Validate() error {
+	return wp.validateShape(CardSize, CardSize)
+}
+
+// validateShape makes sure that a pattern is well-formed, and that every mask
+// fits a card with the given dimensions.
+func (wp WinPattern) validateShape(rows int, cols int) error {
+	if wp.ID == "" {
+		return errors.New("win pattern must have an ID")
+	}
+	if wp.Name == "" {
+		return fmt.Errorf("win pattern %q must have a name", wp.ID)
+	}
+	if len(wp.Masks) == 0 {
+		return fmt.Errorf("win pattern %q must have at least one mask", wp.ID)
+	}
+	for i, mask := range wp.Masks {
+		if err := mask.validate(rows, cols); err != nil {
+			return fmt.Errorf("win pattern %q has invalid mask %d: %v", wp.ID, i+1, err)
+		}
+	}
+	return nil
+}
+
+// Match checks a card against every mask in the pattern, and returns the
+// positions of the first mask that is fully marked. The isMarked callback
+// decides whether a given cell counts towards a pattern, which lets callers
+// decide how to handle things like the free space, or daubs on balls that
+// haven't been called yet.
+func (wp WinPattern) Match(isMarked func(pos CellPosition) bool) ([]CellPosition, bool) {
+	for _, mask := range wp.Masks {
+		positions := mask.Positions()
+		matched := true
+		for _, pos := range positions {
+			if !isMarked(pos) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return positions, true
+		}
+	}
+	return nil, false
+}
+
+// All standard win patterns that hosts can choose from without needing to
+// define their own masks.
+var (
+	// WinPatternAnyLine is satisfied by any complete row, column, or diagonal.
+	// It is the default pattern for all games.
+	WinPatternAnyLine = WinPattern{
+		ID:    "any_line",
+		Name:  "Any Line",
+		Masks: lineMasks(CardSize),
+	}
+	// WinPatternFourCorners is satisfied by daubing the four corners of a card.
+	WinPatternFourCorners = WinPattern{
+		ID:   "four_corners",
+		Name: "Four Corners",
+		Masks: []PatternMask{mustParsePatternMask(
+			"X...X",
+			".....",
+			".....",
+			".....",
+			"X...X",
+		)},
+	}
+	// WinPatternX is satisfied by daubing both diagonals of a card.
+	WinPatternX = WinPattern{
+		ID:   "x",
+		Name: "X",
+		Masks: []PatternMask{mustParsePatternMask(
+			"X...X",
+			".X.X.",
+			"..X..",
+			".X.X.",
+			"X...X",
+		)},
+	}
+	// WinPatternPostageStamp is satisfied by daubing a 2x2 square in any of
+	// the four corners of a card.
+	WinPatternPostageStamp = WinPattern{
+		ID:   "postage_stamp",
+		Name: "Postage Stamp",
+		Masks: []PatternMask{
+			mustParsePatternMask("XX...", "XX...", ".....", ".....", "....."),
+			mustParsePatternMask("...XX", "...XX", ".....", ".....", "....."),
+			mustParsePatternMask(".....", ".....", ".....", "XX...", "XX..."),
+			mustParsePatternMask(".....", ".....", ".....", "...XX", "...XX"),
+		},
+	}
+	// WinPatternPictureFrame is satisfied by daubing every cell on the outer
+	// edge of a card.
+	WinPatternPictureFrame = WinPattern{
+		ID:   "picture_frame",
+		Name: "Picture Frame",
+		Masks: []PatternMask{mustParsePatternMask(
+			"XXXXX",
+			"X...X",
+			"X...X",
+			"X...X",
+			"XXXXX",
+		)},
+	}
+	// WinPatternBlackout is satisfied by daubing every single cell on a card.
+	WinPatternBlackout = WinPattern{
+		ID:   "blackout",
+		Name: "Blackout",
+		Masks: []PatternMask{mustParsePatternMask(
+			"XXXXX",
+			"XXXXX",
+			"XXXXX",
+			"XXXXX",
+			"XXXXX",
+		)},
+	}
+)
+
+// StandardWinPatterns lists every built-in win pattern, in the order that they
+// should be presented to hosts.
+var StandardWinPatterns = []WinPattern{
+	WinPatternAnyLine,
+	WinPatternFourCorners,
+	WinPatternX,
+	WinPatternPostageStamp,
+	WinPatternPictureFrame,
+	WinPatternBlackout,
+}
+
+// LookupWinPattern finds a standard win pattern by its ID.
+func LookupWinPattern(id string) (WinPattern, bool) {
+	for _, p := range StandardWinPatterns {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return WinPattern{}, false
+}
+
+// lineMasks generates a separate mask for every row, column, and diagonal on a
+// square card with the given size.
+func lineMasks(size int) []PatternMask {
+	var masks []PatternMask
+	for i := 0; i < size; i++ {
+		row := newPatternMask(size, size)
+		col := newPatternMask(size, size)
+		for j := 0; j < size; j++ {
+			row[i][j] = true
+			col[j][i] = true
+		}
+		masks = append(masks, row, col)
+	}
+
+	diagonal := newPatternMask(size, size)
+	antiDiagonal := newPatternMask(size, size)
+	for i := 0; i < size; i++ {
+		diagonal[i][i] = true
+		antiDiagonal[i][size-1-i] = true
+	}
+	return append(masks, diagonal, antiDiagonal)
+}
+
+// fullMask generates a mask that requires every cell on a card with the given
+// dimensions.
+func fullMask(rows int, cols int) PatternMask {
+	mask := newPatternMask(rows, cols)
+	for _, row := range mask {
+		for j := range row {
+			row[j] = true
+		}
+	}
+	return mask
+}
+
+// newPatternMask creates a mask with the given dimensions that doesn't
+// require any cells.
+func newPatternMask(rows int, cols int) PatternMask {
+	mask := make(PatternMask, rows)
+	for i := range mask {
+		mask[i] = make([]bool, cols)
+	}
+	return mask
+}